@@ -0,0 +1,94 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.QueriesGeneratorFileType;
+import java.io.File;
+import java.net.InetAddress;
+import java.net.UnknownHostException;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.concurrent.Callable;
+import java.util.logging.Logger;
+import picocli.CommandLine;
+
+/**
+ * agent subcommand, runs a stress.json as one of several machines driving the same cluster. Each
+ * agent writes its results to a file named after it so they can be combined with report merge
+ */
+@CommandLine.Command(
+    name = "agent",
+    description =
+        "run a stress.json as one agent of a distributed run, writing"
+            + " dremio-stress-results-<agent-id>.json for report merge. Connection options are"
+            + " given before the subcommand",
+    usageHelpWidth = 300)
+public class AgentCommand implements Callable<Integer> {
+  private static final Logger logger = Logger.getLogger(AgentCommand.class.getName());
+
+  @CommandLine.ParentCommand private DremioStress parent;
+
+  /** workload to run */
+  @CommandLine.Parameters(
+      index = "0",
+      description = "the stress.json to run, a quoted glob of files to merge, - for stdin")
+  private File config;
+
+  /** name of the agent in the results file name */
+  @CommandLine.Option(
+      names = {"--agent-id"},
+      description = "name of this agent used in the results file name, defaults to the host name")
+  private String agentId;
+
+  /** when all agents start */
+  @CommandLine.Option(
+      names = {"--start-at"},
+      description =
+          "ISO-8601 instant to wait for before starting, e.g. 2024-01-01T10:00:00Z, so all agents"
+              + " start together")
+  private Instant startAt;
+
+  @CommandLine.Mixin private RunOptions runOptions;
+
+  @CommandLine.Mixin private StressJsonOptions stressJsonOptions;
+
+  /**
+   * @return the exit code of the run 0 is success
+   * @throws Exception when the run fails
+   */
+  @Override
+  public Integer call() throws Exception {
+    final String id = agentId != null ? agentId : hostName();
+    runOptions.defaultResultsFile(new File("dremio-stress-results-" + id + ".json"));
+    if (startAt != null) {
+      final Duration wait = Duration.between(Instant.now(), startAt);
+      if (!wait.isNegative()) {
+        logger.info(() -> String.format("agent %s waiting %s until %s", id, wait, startAt));
+        Thread.sleep(wait.toMillis());
+      } else {
+        logger.warning(() -> String.format("agent %s: %s has already passed", id, startAt));
+      }
+    }
+    return parent.run(
+        config, QueriesGeneratorFileType.STRESS_JSON, runOptions, stressJsonOptions, null);
+  }
+
+  private static String hostName() {
+    try {
+      return InetAddress.getLocalHost().getHostName();
+    } catch (UnknownHostException e) {
+      return "agent";
+    }
+  }
+}
@@ -0,0 +1,68 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.Bench;
+import com.dremio.support.diagnostics.stress.DremioApi;
+import com.dremio.support.diagnostics.stress.SqlContext;
+import java.util.concurrent.Callable;
+import java.util.logging.Logger;
+import picocli.CommandLine;
+
+/** bench subcommand, profiles the latency of a single query */
+@CommandLine.Command(
+    name = "bench",
+    description =
+        "run one query serially and report min/mean/p95/p99 latency and per phase timings."
+            + " Connection options are given before the subcommand",
+    usageHelpWidth = 300)
+public class BenchCommand implements Callable<Integer> {
+
+  @CommandLine.ParentCommand private DremioStress parent;
+
+  /** query to benchmark */
+  @CommandLine.Parameters(index = "0", description = "the sql to benchmark")
+  private String sql;
+
+  /** number of measured runs */
+  @CommandLine.Option(
+      names = {"-n", "--iterations"},
+      description = "number of measured runs",
+      defaultValue = "10")
+  private Integer iterations;
+
+  /** number of runs before measuring */
+  @CommandLine.Option(
+      names = {"--warmup"},
+      description = "number of runs before measuring, their timings are discarded",
+      defaultValue = "2")
+  private Integer warmup;
+
+  /** context to run the query in */
+  @CommandLine.Option(
+      names = {"--context"},
+      description = "context path to run the query in, for example Samples,\"samples.dremio.com\"")
+  private String context;
+
+  /**
+   * @return the exit code of the bench 0 is success
+   * @throws Exception when the bench fails a general catch all exception
+   */
+  @Override
+  public Integer call() throws Exception {
+    parent.setLogging(Logger.getLogger(""));
+    final DremioApi api = parent.connect();
+    return new Bench(api, sql, SqlContext.parse(context), iterations, warmup).run(System.out);
+  }
+}
@@ -0,0 +1,135 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.concurrent.Callable;
+import picocli.AutoComplete;
+import picocli.CommandLine;
+
+/** completion subcommand, prints a shell completion script for dremio-stress */
+@CommandLine.Command(
+    name = "completion",
+    description =
+        "print a completion script for bash, zsh or fish. It completes the dremio-stress command,"
+            + " so install the jar behind a dremio-stress wrapper script or alias",
+    usageHelpWidth = 300)
+public class CompletionCommand implements Callable<Integer> {
+  private static final String COMMAND = "dremio-stress";
+
+  @CommandLine.Spec private CommandLine.Model.CommandSpec spec;
+
+  /** shell the script is for */
+  @CommandLine.Parameters(index = "0", description = "the shell: ${COMPLETION-CANDIDATES}")
+  private Shell shell;
+
+  /** supported shells */
+  enum Shell {
+    bash,
+    zsh,
+    fish
+  }
+
+  /** @return the exit code 0 is success */
+  @Override
+  public Integer call() {
+    final CommandLine root = spec.root().commandLine();
+    switch (shell) {
+      case fish:
+        System.out.print(fish(root.getCommandSpec()));
+        break;
+      default:
+        // the picocli script loads bashcompinit when sourced from zsh
+        System.out.print(AutoComplete.bash(COMMAND, root));
+        break;
+    }
+    return 0;
+  }
+
+  /**
+   * fish completions for a command and its subcommands
+   *
+   * @param root the top level command
+   * @return the script
+   */
+  static String fish(final CommandLine.Model.CommandSpec root) {
+    final StringBuilder script = new StringBuilder();
+    script.append("# fish completion for ").append(COMMAND).append(System.lineSeparator());
+    fish(script, root, new ArrayList<>());
+    return script.toString();
+  }
+
+  private static void fish(
+      final StringBuilder script,
+      final CommandLine.Model.CommandSpec command,
+      final List<String> path) {
+    final String condition =
+        path.isEmpty()
+            ? "__fish_use_subcommand"
+            : "__fish_seen_subcommand_from " + path.get(path.size() - 1);
+    for (final CommandLine.Model.OptionSpec option : command.options()) {
+      if (option.hidden()) {
+        continue;
+      }
+      final StringBuilder line = new StringBuilder("complete -c " + COMMAND);
+      line.append(" -n '").append(condition).append("'");
+      for (final String name : option.names()) {
+        if (name.startsWith("--")) {
+          line.append(" -l ").append(name.substring(2));
+        } else if (name.length() == 2) {
+          line.append(" -s ").append(name.substring(1));
+        } else {
+          line.append(" -o ").append(name.substring(1));
+        }
+      }
+      if (option.arity().max() > 0) {
+        line.append(" -r");
+        if (option.completionCandidates() != null) {
+          final List<String> candidates = new ArrayList<>();
+          option.completionCandidates().forEach(candidates::add);
+          line.append(" -f -a '").append(String.join(" ", candidates)).append("'");
+        }
+      }
+      script.append(line).append(description(option.description()));
+      script.append(System.lineSeparator());
+    }
+    for (final Map.Entry<String, CommandLine> sub : command.subcommands().entrySet()) {
+      final CommandLine.Model.CommandSpec subSpec = sub.getValue().getCommandSpec();
+      if (subSpec.usageMessage().hidden() || !sub.getKey().equals(subSpec.name())) {
+        continue;
+      }
+      script.append("complete -c ").append(COMMAND).append(" -f -n '").append(condition);
+      script.append("' -a ").append(sub.getKey());
+      script.append(description(subSpec.usageMessage().description()));
+      script.append(System.lineSeparator());
+      final List<String> subPath = new ArrayList<>(path);
+      subPath.add(sub.getKey());
+      fish(script, subSpec, subPath);
+    }
+  }
+
+  private static String description(final String[] description) {
+    if (description == null || description.length == 0) {
+      return "";
+    }
+    String text = description[0];
+    final int sentence = text.indexOf(". ");
+    if (sentence > 0) {
+      text = text.substring(0, sentence);
+    }
+    return " -d '" + text.replace("'", "\\'") + "'";
+  }
+}
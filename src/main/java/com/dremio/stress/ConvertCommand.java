@@ -0,0 +1,127 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.ConfigWizard;
+import com.dremio.support.diagnostics.stress.JobHistoryConverter;
+import com.dremio.support.diagnostics.stress.QueryConfig;
+import java.io.File;
+import java.util.List;
+import java.util.Random;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** convert subcommand, builds a stress.json from a job history export */
+@CommandLine.Command(
+    name = "convert",
+    description =
+        "build a stress.json from a job history csv or json export, frequencies follow how often"
+            + " each query ran and literals that changed become parameters",
+    usageHelpWidth = 300)
+public class ConvertCommand implements Callable<Integer> {
+
+  @CommandLine.Spec private CommandLine.Model.CommandSpec spec;
+
+  /** exports to read */
+  @CommandLine.Parameters(
+      arity = "1..*",
+      description =
+          "job history exports, csv with a header row or json such as queries.json, optionally gzipped")
+  private List<File> exports;
+
+  /** where to write the config */
+  @CommandLine.Option(
+      names = {"-o", "--output"},
+      description = "file to write",
+      defaultValue = "stress.json")
+  private File output;
+
+  /** overwrite an existing file */
+  @CommandLine.Option(
+      names = {"--force"},
+      description = "overwrite the output file when it exists",
+      defaultValue = "false")
+  private boolean force;
+
+  /** leave out rare queries */
+  @CommandLine.Option(
+      names = {"--min-count"},
+      description = "leave out queries seen fewer times than this",
+      defaultValue = "1")
+  private int minCount;
+
+  /** keep only the most frequent queries */
+  @CommandLine.Option(
+      names = {"--top"},
+      description = "keep only this many of the most frequent queries, 0 keeps all of them",
+      defaultValue = "0")
+  private int top;
+
+  /** bound on the values kept per parameter */
+  @CommandLine.Option(
+      names = {"--max-values"},
+      description = "the most distinct values kept per parameter",
+      defaultValue = "100")
+  private int maxValues;
+
+  /** only merge identical texts */
+  @CommandLine.Option(
+      names = {"--exact"},
+      description =
+          "only merge identical query texts into one entry instead of turning literals that changed into parameters",
+      defaultValue = "false")
+  private boolean exact;
+
+  /** bound on the jobs read */
+  @CommandLine.Option(
+      names = {"--sample"},
+      description =
+          "keep a uniform random sample of this many jobs across all exports before grouping them, 0 keeps every job",
+      defaultValue = "0")
+  private int sample;
+
+  /**
+   * @return the exit code 0 is success
+   * @throws Exception when unable to read the exports or write the file
+   */
+  @Override
+  public Integer call() throws Exception {
+    if (output.exists() && !force) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), output + " already exists, use --force to overwrite it");
+    }
+    if (maxValues < 1) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "--max-values must be at least 1");
+    }
+    if (sample < 0) {
+      throw new CommandLine.ParameterException(spec.commandLine(), "--sample cannot be negative");
+    }
+    final JobHistoryConverter converter =
+        new JobHistoryConverter(maxValues, exact, sample, new Random());
+    for (final File export : exports) {
+      converter.read(export.toPath());
+    }
+    final List<QueryConfig> queries = converter.queries(minCount, top);
+    final ConfigWizard wizard = new ConfigWizard();
+    for (final QueryConfig query : queries) {
+      wizard.addQuery(query);
+    }
+    wizard.write(output);
+    System.out.printf(
+        "wrote %d queries to %s, run it with: java -jar dremio-stress.jar %s%n",
+        queries.size(), output, output);
+    return 0;
+  }
+}
@@ -0,0 +1,59 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.ConfigReader;
+import com.dremio.support.diagnostics.stress.StressConfig;
+import com.dremio.support.diagnostics.stress.WorkloadDescription;
+import java.io.File;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** describe subcommand, prints the workload mix of a stress.json without running it */
+@CommandLine.Command(
+    name = "describe",
+    description =
+        "print the queries of a stress.json with their weights, expected share of the traffic,"
+            + " parameter cardinalities and query group sizes without connecting to dremio",
+    usageHelpWidth = 300)
+public class DescribeCommand implements Callable<Integer> {
+
+  /** config to describe */
+  @CommandLine.Parameters(
+      index = "0",
+      description = "the stress.json to describe, a quoted glob of files to merge, - for stdin")
+  private File config;
+
+  /** unknown fields are errors */
+  @CommandLine.Option(
+      names = {"--strict"},
+      description =
+          "refuse unknown fields and queries without a frequency or weight instead of warning",
+      defaultValue = "false")
+  private boolean strict;
+
+  /**
+   * @return the exit code 0 is success
+   * @throws Exception when unable to read or parse the config
+   */
+  @Override
+  public Integer call() throws Exception {
+    final StressConfig parsed =
+        "-".equals(config.getPath())
+            ? ConfigReader.parse("stdin", ConfigReader.readAll(System.in), strict)
+            : ConfigReader.read(config, strict);
+    new WorkloadDescription(parsed).print(System.out);
+    return 0;
+  }
+}
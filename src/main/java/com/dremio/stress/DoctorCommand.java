@@ -0,0 +1,51 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import java.util.concurrent.Callable;
+import java.util.logging.Logger;
+import picocli.CommandLine;
+
+/** doctor subcommand, checks that a run can start before running it */
+@CommandLine.Command(
+    name = "doctor",
+    description =
+        "check the java runtime, JDBC drivers, connectivity, login, clock skew against dremio and"
+            + " that the output files are writable, printing how to fix each failed check. Exits"
+            + " with 1 when a check fails. Connection options are given before the subcommand, it"
+            + " takes the run options so the command line of a run can be checked as it is",
+    usageHelpWidth = 300)
+public class DoctorCommand implements Callable<Integer> {
+
+  @CommandLine.ParentCommand private DremioStress parent;
+
+  /** how long to wait for a coordinator */
+  @CommandLine.Option(
+      names = {"--connect-timeout-seconds"},
+      description = "how long to wait for each coordinator to accept a connection",
+      defaultValue = "10")
+  private Integer connectTimeoutSeconds;
+
+  @CommandLine.Mixin private RunOptions runOptions;
+
+  /**
+   * @return 0 when every check passed, 1 otherwise
+   * @throws Exception when the checks fail unexpectedly
+   */
+  @Override
+  public Integer call() throws Exception {
+    parent.setLogging(Logger.getLogger(""));
+    return parent.doctor(connectTimeoutSeconds, runOptions).run(System.out);
+  }
+}
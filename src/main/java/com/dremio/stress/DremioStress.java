@@ -15,13 +15,34 @@ package com.dremio.stress;
 
 import static java.util.logging.Level.*;
 
+import com.dremio.support.diagnostics.stress.AnnotationFile;
+import com.dremio.support.diagnostics.stress.AzureDeviceLogin;
 import com.dremio.support.diagnostics.stress.ConnectDremioApi;
+import com.dremio.support.diagnostics.stress.ControlServer;
+import com.dremio.support.diagnostics.stress.Coordinators;
+import com.dremio.support.diagnostics.stress.CredentialProvider;
 import com.dremio.support.diagnostics.stress.CustomLogFormatter;
+import com.dremio.support.diagnostics.stress.DiagnosticsServer;
+import com.dremio.support.diagnostics.stress.Doctor;
+import com.dremio.support.diagnostics.stress.DremioApi;
+import com.dremio.support.diagnostics.stress.HttpApiCall;
+import com.dremio.support.diagnostics.stress.HttpAuth;
+import com.dremio.support.diagnostics.stress.InteractiveConsole;
+import com.dremio.support.diagnostics.stress.Profiles;
 import com.dremio.support.diagnostics.stress.Protocol;
 import com.dremio.support.diagnostics.stress.QueriesGeneratorFileType;
-import com.dremio.support.diagnostics.stress.QueriesSequence;
+import com.dremio.support.diagnostics.stress.Spnego;
 import com.dremio.support.diagnostics.stress.StressExec;
+import com.dremio.support.diagnostics.stress.StressOptions;
+import com.dremio.support.diagnostics.stress.TokenCache;
+import com.dremio.support.diagnostics.stress.WebServer;
 import java.io.File;
+import java.io.IOException;
+import java.security.InvalidParameterException;
+import java.security.Security;
+import java.util.List;
+import java.util.Map;
+import java.util.Properties;
 import java.util.concurrent.Callable;
 import java.util.logging.*;
 import picocli.CommandLine;
@@ -67,7 +88,20 @@ import picocli.CommandLine;
             + "              ]\n"
             + "            }\n",
     usageHelpWidth = 300,
-    subcommands = CommandLine.HelpCommand.class)
+    subcommands = {
+      CommandLine.HelpCommand.class,
+      RunCommand.class,
+      ReplayCommand.class,
+      AgentCommand.class,
+      ValidateCommand.class,
+      DoctorCommand.class,
+      BenchCommand.class,
+      ReportCommand.class,
+      InitCommand.class,
+      ConvertCommand.class,
+      DescribeCommand.class,
+      CompletionCommand.class
+    })
 public class DremioStress implements Callable<Integer> {
 
   public static void main(final String[] args) {
@@ -81,40 +115,148 @@ public class DremioStress implements Callable<Integer> {
       version = rawVersion;
     }
     System.out.println("stress version " + version); // NOPMD
-    final int rc = new CommandLine(app).execute(args);
+    final int rc =
+        new CommandLine(app)
+            .setDefaultValueProvider(new EnvironmentDefaults(System.getenv()))
+            .execute(args);
     System.exit(rc);
   }
 
+  @CommandLine.Spec private CommandLine.Model.CommandSpec spec;
+
+  // optional so subcommands can run without it, required when running a stress job
   @CommandLine.Parameters(
       index = "0",
+      arity = "0..1",
       description =
-          "The file to use for query definitions. Supports queries.json.gz, queries.json, or a directory of queries.json and a stress.json file with a defined workload (see example)")
+          "The file to use for query definitions. Supports queries.json.gz, queries.json, a directory or quoted glob of rotated queries.json logs and a stress.json file with a defined workload (see example) or a quoted glob of stress.json files to merge. Use - to read it from stdin, or leave it out and pass --profile")
   private File jsonConfig;
 
   @CommandLine.Option(
-      names = {"-q", "--max-queries-in-flight"},
-      description = "max number of queries in flight (if possible)",
-      defaultValue = "32")
-  private Integer maxQueriesInFlight;
+      names = {"--jdbc-trust-store"},
+      description =
+          "JDBC only: verify the flight endpoint against this JKS trust store instead of the trust store of the system, for gateways with a private certificate authority")
+  private File jdbcTrustStore;
+
+  @CommandLine.Option(
+      names = {"--jdbc-trust-store-password"},
+      description = "JDBC only: the password of --jdbc-trust-store")
+  private String jdbcTrustStorePassword;
 
   @CommandLine.Option(
       names = {"-t", "--http-timeout-seconds"},
-      description = "HTTP timeout for queries",
+      description =
+          "timeout for queries, over JDBC the statement is also cancelled client side so a wedged server cannot hang a worker",
       defaultValue = "600")
   private Integer httpTimeoutSeconds;
 
+  @CommandLine.Option(
+      names = {"--results-page-size"},
+      description =
+          "page through the rows of every completed job with this many rows per request over HTTP, like BI tools do, or read every row with this fetch size over JDBC. Dremio serves at most 500 rows per page, 0 does not read results",
+      defaultValue = "0")
+  private int resultsPageSize;
+
+  @CommandLine.Option(
+      names = {"--http-gzip"},
+      description = "HTTP only: ask for gzip compressed responses",
+      defaultValue = "false")
+  private boolean httpGzip;
+
+  @CommandLine.Option(
+      names = {"--http-header"},
+      description =
+          "HTTP only: header sent with every request as 'Name: value', such as X-Forwarded-User or the tenant header of a gateway, repeat for more headers")
+  private List<String> httpHeaders;
+
+  @CommandLine.Option(
+      names = {"--http-basic-auth"},
+      description =
+          "HTTP only: send the user and password as basic auth on every request instead of logging in for a token, for auth proxies in front of dremio",
+      defaultValue = "false")
+  private boolean httpBasicAuth;
+
+  @CommandLine.Option(
+      names = {"--cache-logins"},
+      description =
+          "HTTP only: log in once per url and user and share the token between every connection, refreshing it ahead of its expiry, so hundreds of sessions, --connection-per-worker or recycled connections do not stampede /apiv2/login",
+      defaultValue = "false")
+  private boolean cacheLogins;
+
+  // shared by every connection of the run when --cache-logins is given
+  private TokenCache tokenCache;
+
+  @CommandLine.Option(
+      names = {"--http-spnego"},
+      description =
+          "HTTP only: authenticate every request with kerberos (SPNEGO) instead of logging in, the service principal is HTTP@ the coordinator host",
+      defaultValue = "false")
+  private boolean httpSpnego;
+
+  @CommandLine.Option(
+      names = {"--kerberos-principal"},
+      description =
+          "with --http-spnego: the client principal, required with --kerberos-keytab, defaults to the principal of the ticket cache")
+  private String kerberosPrincipal;
+
+  @CommandLine.Option(
+      names = {"--kerberos-keytab"},
+      description = "with --http-spnego: log in from this keytab instead of a ticket cache")
+  private File kerberosKeytab;
+
+  @CommandLine.Option(
+      names = {"--kerberos-ccache"},
+      description = "with --http-spnego: the ticket cache to use, defaults to the cache of kinit")
+  private File kerberosCcache;
+
+  @CommandLine.Option(
+      names = {"--kerberos-conf"},
+      description = "with --http-spnego: the krb5.conf to use instead of the default of the JVM")
+  private File kerberosConf;
+
+  @CommandLine.Option(
+      names = {"--ldap-domain"},
+      description =
+          "HTTP only: qualify users without a domain for coordinators that log in against LDAP or active directory, a domain with a dot gives user@domain, otherwise DOMAIN\\user")
+  private String ldapDomain;
+
+  @CommandLine.Option(
+      names = {"--azure-client-id"},
+      description =
+          "HTTP only: sign in to Azure AD with a device code for this app registration and exchange the token for a Dremio token instead of logging in with a password")
+  private String azureClientId;
+
+  @CommandLine.Option(
+      names = {"--azure-tenant"},
+      description = "with --azure-client-id: the tenant id or domain to sign in to",
+      defaultValue = "organizations")
+  private String azureTenant;
+
+  @CommandLine.Option(
+      names = {"--azure-scope"},
+      description =
+          "with --azure-client-id: the scopes to request, defaults to '<client id>/.default offline_access'")
+  private String azureScope;
+
+  @CommandLine.Option(
+      names = {"--no-http-keepalive"},
+      description =
+          "HTTP only: open a new socket for every request instead of keeping connections alive, so requests spread over the coordinators behind a load balancer",
+      defaultValue = "false")
+  private boolean noHttpKeepAlive;
+
+  @CommandLine.Option(
+      names = {"--dns-ttl-seconds"},
+      description =
+          "cache host name lookups for this many seconds so new connections follow coordinators being replaced, 0 disables caching. Defaults to the JVM setting")
+  private Integer dnsTtlSeconds;
+
   @CommandLine.Option(
       names = {"-s", "--http-skip-ssl-verification"},
       description = "whether to skip ssl verification for HTTP queries or not",
       defaultValue = "false")
   private boolean skipHttpSSLVerification;
 
-  @CommandLine.Option(
-      names = {"-d", "--duration-seconds"},
-      description = "duration in seconds to run stress",
-      defaultValue = "600")
-  private Integer durationSeconds;
-
   /** protocol to use */
   @CommandLine.Option(
       names = {"--protocol"},
@@ -125,7 +267,8 @@ public class DremioStress implements Callable<Integer> {
   /** http url or jdbc connection string */
   @CommandLine.Option(
       names = {"-l", "--url"},
-      description = "JDBC connection string or HTTP url to connect")
+      description =
+          "JDBC connection string or HTTP url to connect. Separate several coordinators with commas to spread the load over them round robin, append |weight to give one a larger share")
   private String dremioUrl;
 
   /** dremio user for the rest api */
@@ -141,13 +284,14 @@ public class DremioStress implements Callable<Integer> {
       description = "the password of the user used to submit HTTP queries")
   private String dremioHttpPassword;
 
-  /** limit queries results to said limit */
   @CommandLine.Option(
-      names = {"--limit-results"},
-      interactive = false,
+      names = {"--password-source"},
       description =
-          "limit results to the specified number assuming there is not already a LIMIT in the query. This is an easy way to just add some limits on the result set size")
-  private Integer limitResults;
+          "read the password when the run starts instead of passing it with --http-password: env:NAME, file:PATH, keychain:SERVICE (macOS keychain or Linux secret-tool, looked up with --http-user) or vault:PATH#FIELD (VAULT_ADDR and VAULT_TOKEN or ~/.vault-token)")
+  private String passwordSource;
+
+  // the password source is read once, the first connection replaces it with the password
+  private boolean passwordResolved;
 
   /** query generator file type aka what file type to use */
   @CommandLine.Option(
@@ -155,19 +299,11 @@ public class DremioStress implements Callable<Integer> {
       description = "specify QUERIES_JSON or STRESS_JSON to specify the engine type")
   private QueriesGeneratorFileType queriesGeneratorFileType;
 
-  /** query execution sequence */
-  @CommandLine.Option(
-      names = {"--execution-sequence", "-x"},
-      description = "specify RANDOM or SEQUENTIAL to specify the execution sequence",
-      defaultValue = "RANDOM")
-  private QueriesSequence queriesSequence;
+  @CommandLine.Mixin private RunOptions runOptions;
 
-  /** query index to restart from */
-  @CommandLine.Option(
-      names = {"--restart-index", "-i"},
-      description = "specify query index to restart from (for SEQUENTIAL execution-sequence only)",
-      defaultValue = "-1")
-  private Integer queryIndexForRestart;
+  @CommandLine.Mixin private StressJsonOptions stressJsonOptions;
+
+  @CommandLine.Mixin private ReplayOptions replayOptions;
 
   private Package getPackage() {
     return this.getClass().getPackage();
@@ -177,31 +313,327 @@ public class DremioStress implements Callable<Integer> {
     return this.getPackage().getImplementationVersion();
   }
 
+  /**
+   * connects with the connection options, used by subcommands. With several coordinators the first
+   * one is used.
+   *
+   * @return the connected api
+   * @throws IOException when unable to connect
+   */
+  DremioApi connect() throws IOException {
+    resolvePassword();
+    return connectApi()
+        .connect(
+            dremioHttpUser,
+            dremioHttpPassword,
+            Coordinators.parse(dremioUrl).urls().get(0),
+            httpTimeoutSeconds,
+            protocol,
+            skipHttpSSLVerification);
+  }
+
+  /**
+   * runs a workload, used by the subcommands that select the kind of workload with the options
+   * given after them
+   *
+   * @param config the workload, null with --profile
+   * @param type the kind of workload
+   * @param run the run options of the subcommand
+   * @param stressJson the stress.json options of the subcommand, null when it takes none
+   * @param replay the replay options of the subcommand, null when it takes none
+   * @return the exit code of the run
+   * @throws Exception when the run fails
+   */
+  Integer run(
+      final File config,
+      final QueriesGeneratorFileType type,
+      final RunOptions run,
+      final StressJsonOptions stressJson,
+      final ReplayOptions replay)
+      throws Exception {
+    refuseRunOptions();
+    return execute(config, type, run, stressJson, replay);
+  }
+
+  /**
+   * checks the setup of a run, used by the doctor subcommand
+   *
+   * @param connectTimeoutSeconds how long to wait for a coordinator to accept a connection
+   * @param run the run options given to doctor
+   * @return the checks of the run
+   */
+  Doctor doctor(final int connectTimeoutSeconds, final RunOptions run) {
+    refuseRunOptions();
+    return new Doctor(
+        protocol,
+        dremioUrl,
+        connectTimeoutSeconds,
+        this::connect,
+        run.getOutputs(),
+        run.getResultsDb() != null);
+  }
+
+  /**
+   * the flat -g form takes the run options before the workload, the subcommands take them after
+   * their name. Refuse them before a subcommand instead of silently running without them.
+   */
+  private void refuseRunOptions() {
+    final CommandLine.ParseResult parsed = spec.commandLine().getParseResult();
+    for (final CommandLine.Model.CommandSpec mixin : spec.mixins().values()) {
+      for (final CommandLine.Model.OptionSpec option : mixin.options()) {
+        if (parsed.hasMatchedOption(option.longestName())) {
+          throw new CommandLine.ParameterException(
+              spec.commandLine(),
+              String.format(
+                  "%s is an option of the subcommand, give it after the subcommand name",
+                  option.longestName()));
+        }
+      }
+    }
+  }
+
+  private void resolvePassword() {
+    if (passwordSource == null || passwordResolved) {
+      return;
+    }
+    if (dremioHttpPassword != null) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "use either --http-password or --password-source, not both");
+    }
+    try {
+      dremioHttpPassword = CredentialProvider.parse(passwordSource).password(dremioHttpUser);
+    } catch (IllegalArgumentException e) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "--password-source " + e.getMessage());
+    } catch (IOException e) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(),
+          String.format("unable to read the password from %s: %s", passwordSource, e.getMessage()));
+    }
+    passwordResolved = true;
+  }
+
+  private ConnectDremioApi connectApi() throws IOException {
+    if (resultsPageSize < 0 || resultsPageSize > 500) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "--results-page-size must be between 0 and 500");
+    }
+    // set before the first connection as lookups and sockets are cached for the whole JVM
+    if (dnsTtlSeconds != null) {
+      Security.setProperty("networkaddress.cache.ttl", String.valueOf(dnsTtlSeconds));
+    }
+    if (noHttpKeepAlive) {
+      System.setProperty("http.keepAlive", "false");
+    }
+    final Map<String, String> headers;
+    try {
+      headers = ConnectDremioApi.parseHeaders(httpHeaders);
+    } catch (IllegalArgumentException e) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "--http-header " + e.getMessage());
+    }
+    final Properties jdbcProperties = new Properties();
+    if (jdbcTrustStore != null) {
+      if (!jdbcTrustStore.isFile()) {
+        throw new CommandLine.ParameterException(
+            spec.commandLine(), "--jdbc-trust-store " + jdbcTrustStore + " does not exist");
+      }
+      // the driver ignores the trust store while it uses the one of the system
+      jdbcProperties.setProperty("useEncryption", "true");
+      jdbcProperties.setProperty("useSystemTrustStore", "false");
+      jdbcProperties.setProperty("trustStore", jdbcTrustStore.getPath());
+      if (jdbcTrustStorePassword != null) {
+        jdbcProperties.setProperty("trustStorePassword", jdbcTrustStorePassword);
+      }
+    } else if (jdbcTrustStorePassword != null) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "--jdbc-trust-store-password needs --jdbc-trust-store");
+    }
+    // so the url can leave out the credentials, the ones in the url win
+    if (dremioHttpUser != null) {
+      jdbcProperties.setProperty("user", dremioHttpUser);
+    }
+    if (dremioHttpPassword != null) {
+      jdbcProperties.setProperty("password", dremioHttpPassword);
+    }
+    if (cacheLogins && tokenCache == null) {
+      tokenCache = new TokenCache();
+    }
+    if ((httpBasicAuth ? 1 : 0) + (httpSpnego ? 1 : 0) + (azureClientId != null ? 1 : 0) > 1) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(),
+          "use only one of --http-basic-auth, --http-spnego and --azure-client-id");
+    }
+    if (!httpSpnego
+        && (kerberosPrincipal != null
+            || kerberosKeytab != null
+            || kerberosCcache != null
+            || kerberosConf != null)) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "the --kerberos options need --http-spnego");
+    }
+    if (azureClientId == null && azureScope != null) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "--azure-scope needs --azure-client-id");
+    }
+    if (httpSpnego) {
+      if (kerberosKeytab != null) {
+        if (kerberosCcache != null) {
+          throw new CommandLine.ParameterException(
+              spec.commandLine(), "use either --kerberos-keytab or --kerberos-ccache, not both");
+        }
+        if (kerberosPrincipal == null) {
+          throw new CommandLine.ParameterException(
+              spec.commandLine(), "--kerberos-keytab needs --kerberos-principal");
+        }
+      }
+      if (kerberosConf != null) {
+        // read by the kerberos login so it has to be set before it
+        System.setProperty("java.security.krb5.conf", kerberosConf.getPath());
+      }
+      return new ConnectDremioApi(
+          resultsPageSize,
+          httpGzip,
+          headers,
+          HttpAuth.SPNEGO,
+          new Spnego(kerberosPrincipal, kerberosKeytab, kerberosCcache),
+          null,
+          ldapDomain,
+          jdbcProperties);
+    }
+    if (azureClientId != null) {
+      return new ConnectDremioApi(
+          resultsPageSize,
+          httpGzip,
+          headers,
+          HttpAuth.AZURE_AD,
+          null,
+          new AzureDeviceLogin(new HttpApiCall(false), azureTenant, azureClientId, azureScope),
+          ldapDomain,
+          jdbcProperties,
+          tokenCache);
+    }
+    return new ConnectDremioApi(
+        resultsPageSize,
+        httpGzip,
+        headers,
+        httpBasicAuth ? HttpAuth.BASIC : HttpAuth.LOGIN,
+        null,
+        null,
+        ldapDomain,
+        jdbcProperties,
+        tokenCache);
+  }
+
   /**
    * @return the exit code of the job 0 is success
    * @throws Exception when the job fails a general catch all exception
    */
   @Override
   public Integer call() throws Exception {
+    return execute(
+        jsonConfig, queriesGeneratorFileType, runOptions, stressJsonOptions, replayOptions);
+  }
+
+  private Integer execute(
+      final File config,
+      final QueriesGeneratorFileType type,
+      final RunOptions run,
+      final StressJsonOptions stressJson,
+      final ReplayOptions replay)
+      throws Exception {
+    File jsonConfig = config;
+    QueriesGeneratorFileType fileType = type;
+    if (run.getProfile() != null) {
+      if (jsonConfig != null) {
+        throw new CommandLine.ParameterException(
+            spec.commandLine(), "use either <jsonConfig> or --profile, not both");
+      }
+      try {
+        jsonConfig = Profiles.extract(run.getProfile());
+      } catch (InvalidParameterException e) {
+        throw new CommandLine.ParameterException(spec.commandLine(), e.getMessage());
+      }
+      fileType = QueriesGeneratorFileType.STRESS_JSON;
+    }
+    if (jsonConfig == null) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "Missing required parameter: '<jsonConfig>' or --profile");
+    }
+    if (run.isInteractive() && "-".equals(jsonConfig.getPath())) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "--interactive reads commands from stdin, pass the config as a file");
+    }
+    resolvePassword();
+    final File runDir = run.createRunDir();
     final Logger root = Logger.getLogger("");
     setLogging(root);
-    final StressExec r =
-        new StressExec(
-            new ConnectDremioApi(),
-            jsonConfig,
-            queriesGeneratorFileType,
-            queriesSequence,
-            queryIndexForRestart,
-            limitResults,
-            protocol,
-            dremioUrl,
-            dremioHttpUser,
-            dremioHttpPassword,
-            maxQueriesInFlight,
-            httpTimeoutSeconds,
-            durationSeconds,
-            skipHttpSSLVerification);
-    return r.run();
+    if (runDir != null) {
+      final FileHandler log =
+          new FileHandler(new File(runDir, "dremio-stress.log").getPath(), false);
+      log.setFormatter(new CustomLogFormatter());
+      log.setLevel(root.getLevel());
+      root.addHandler(log);
+      System.out.printf("writing the artifacts of the run to %s%n", runDir);
+    }
+    final StressOptions options = new StressOptions();
+    options.setJsonConfig(jsonConfig);
+    options.setFileType(fileType);
+    options.setProtocol(protocol);
+    options.setDremioHost(dremioUrl);
+    options.setDremioUser(dremioHttpUser);
+    options.setDremioPassword(dremioHttpPassword);
+    options.setTimeoutSeconds(httpTimeoutSeconds);
+    options.setSkipSSLVerification(skipHttpSSLVerification);
+    run.apply(options, runDir);
+    if (stressJson != null) {
+      stressJson.apply(options);
+    }
+    if (replay != null) {
+      replay.apply(options);
+    }
+    final StressExec r = new StressExec(connectApi(), options);
+    ControlServer controlServer = null;
+    DiagnosticsServer diagnosticsServer = null;
+    WebServer webServer = null;
+    try {
+      final String controlAddress = run.getControlAddress();
+      if (controlAddress != null && !controlAddress.isEmpty()) {
+        controlServer = new ControlServer(controlAddress, r);
+        controlServer.start();
+      }
+      final String diagnosticsAddress = run.getDiagnosticsAddress();
+      if (diagnosticsAddress != null && !diagnosticsAddress.isEmpty()) {
+        diagnosticsServer = new DiagnosticsServer(diagnosticsAddress);
+        diagnosticsServer.start();
+      }
+      final String webAddress = run.getWebAddress();
+      if (webAddress != null && !webAddress.isEmpty()) {
+        webServer = new WebServer(webAddress, r);
+        webServer.start();
+      }
+      if (run.getAnnotationsFile() != null) {
+        new AnnotationFile(run.getAnnotationsFile(), r).install();
+      }
+      if (run.isInteractive()) {
+        new InteractiveConsole(System.in, System.out, r).start();
+      }
+      return r.run();
+    } finally {
+      if (controlServer != null) {
+        controlServer.stop();
+      }
+      if (diagnosticsServer != null) {
+        diagnosticsServer.stop();
+      }
+      if (webServer != null) {
+        webServer.stop();
+      }
+      if (tokenCache != null) {
+        // stderr as the logs go to stdout, the json summary has to stay the last line of stdout
+        System.err.println(tokenCache.summary());
+      }
+    }
   }
 
   @CommandLine.Option( // W: Use explicit scoping instead of the default package private level
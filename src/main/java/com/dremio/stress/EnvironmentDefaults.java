@@ -0,0 +1,80 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import java.util.Locale;
+import java.util.Map;
+import picocli.CommandLine;
+
+/**
+ * EnvironmentDefaults reads the value of every option from a DREMIO_STRESS_ environment variable
+ * when it is not given on the command line, so a container can be configured without a long
+ * command line that breaks on quoting. --http-user is read from DREMIO_STRESS_HTTP_USER, options of
+ * a subcommand include its name, so --connect-timeout-seconds of doctor is read from
+ * DREMIO_STRESS_DOCTOR_CONNECT_TIMEOUT_SECONDS, and fall back to the name without it so the run
+ * options shared by run, replay and agent are set once. The command line wins over the
+ * environment, which wins over the defaults.
+ */
+public class EnvironmentDefaults implements CommandLine.IDefaultValueProvider {
+
+  static final String prefix = "DREMIO_STRESS_";
+
+  private final Map<String, String> environment;
+
+  /** @param environment the environment variables, usually System.getenv() */
+  public EnvironmentDefaults(final Map<String, String> environment) {
+    this.environment = environment;
+  }
+
+  /**
+   * @param argSpec option to find the value of
+   * @return the value of the environment variable of the option, null keeps the default
+   */
+  @Override
+  public String defaultValue(final CommandLine.Model.ArgSpec argSpec) {
+    if (!argSpec.isOption()) {
+      // positional parameters such as the stress.json stay on the command line
+      return null;
+    }
+    final CommandLine.Model.OptionSpec option = (CommandLine.Model.OptionSpec) argSpec;
+    String value = environment.get(variable(option));
+    // an empty variable is how compose files and helm charts leave an option unset
+    if (value == null || value.isEmpty()) {
+      value = environment.get(prefix + name(option));
+    }
+    return value == null || value.isEmpty() ? null : value;
+  }
+
+  /**
+   * name of the environment variable of an option
+   *
+   * @param option the option
+   * @return DREMIO_STRESS_ followed by the subcommands and the longest name of the option
+   */
+  static String variable(final CommandLine.Model.OptionSpec option) {
+    final StringBuilder name = new StringBuilder();
+    CommandLine.Model.CommandSpec command = option.command();
+    while (command != null && command.parent() != null) {
+      name.insert(0, command.name() + "_");
+      command = command.parent();
+    }
+    name.append(option.longestName().replaceFirst("^-+", ""));
+    return prefix + name.toString().replace('-', '_').toUpperCase(Locale.ROOT);
+  }
+
+  private static String name(final CommandLine.Model.OptionSpec option) {
+    final String name = option.longestName().replaceFirst("^-+", "");
+    return name.replace('-', '_').toUpperCase(Locale.ROOT);
+  }
+}
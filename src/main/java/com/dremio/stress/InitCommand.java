@@ -0,0 +1,104 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.ConfigWizard;
+import java.io.BufferedReader;
+import java.io.File;
+import java.io.InputStreamReader;
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** init subcommand, scaffolds a stress.json */
+@CommandLine.Command(
+    name = "init",
+    description =
+        "scaffold a valid stress.json, prompts for queries, frequencies and parameter values unless"
+            + " --query is given",
+    usageHelpWidth = 300)
+public class InitCommand implements Callable<Integer> {
+
+  @CommandLine.Spec private CommandLine.Model.CommandSpec spec;
+
+  /** where to write the config */
+  @CommandLine.Option(
+      names = {"-o", "--output"},
+      description = "file to write",
+      defaultValue = "stress.json")
+  private File output;
+
+  /** overwrite an existing file */
+  @CommandLine.Option(
+      names = {"--force"},
+      description = "overwrite the output file when it exists",
+      defaultValue = "false")
+  private boolean force;
+
+  /** queries given as flags */
+  @CommandLine.Option(
+      names = {"--query"},
+      description = "a query of the workload with frequency 1, repeat for more queries")
+  private List<String> queries = new ArrayList<>();
+
+  /** parameter values given as flags */
+  @CommandLine.Option(
+      names = {"--parameter"},
+      description =
+          "values for a :name parameter of the queries as name=value1,value2, repeat for more parameters")
+  private Map<String, String> parameters = new LinkedHashMap<>();
+
+  /**
+   * @return the exit code 0 is success
+   * @throws Exception when unable to read the answers or write the file
+   */
+  @Override
+  public Integer call() throws Exception {
+    if (output.exists() && !force) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), output + " already exists, use --force to overwrite it");
+    }
+    final ConfigWizard wizard = new ConfigWizard();
+    if (queries.isEmpty()) {
+      wizard.prompt(
+          new BufferedReader(new InputStreamReader(System.in, StandardCharsets.UTF_8)),
+          System.out);
+    } else {
+      for (final String query : queries) {
+        final Map<String, List<Object>> queryParameters = new LinkedHashMap<>();
+        for (final String name : ConfigWizard.parameterNames(query)) {
+          final String values = parameters.get(name);
+          if (values == null) {
+            throw new CommandLine.ParameterException(
+                spec.commandLine(),
+                String.format("missing --parameter %s=... for %s", name, query));
+          }
+          final List<Object> parsed = new ArrayList<>();
+          for (final String value : values.split(",")) {
+            parsed.add(ConfigWizard.parseValue(value));
+          }
+          queryParameters.put(name, parsed);
+        }
+        wizard.addQuery(query, 1, queryParameters);
+      }
+    }
+    wizard.write(output);
+    System.out.printf("wrote %s, run it with: java -jar dremio-stress.jar %s%n", output, output);
+    return 0;
+  }
+}
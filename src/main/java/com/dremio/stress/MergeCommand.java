@@ -0,0 +1,63 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.ResultsMerger;
+import com.fasterxml.jackson.core.type.TypeReference;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import java.io.File;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** report merge subcommand, combines the results of agents that ran at the same time */
+@CommandLine.Command(
+    name = "merge",
+    description =
+        "combine the results files of agents that ran at the same time into the results of a single"
+            + " run, the latency percentiles are computed over the statements of every agent",
+    usageHelpWidth = 300)
+public class MergeCommand implements Callable<Integer> {
+
+  /** results to merge */
+  @CommandLine.Parameters(
+      arity = "2..*",
+      description = "results files written with --results-file or --output-dir")
+  private List<File> inputs;
+
+  /** where the merged results go */
+  @CommandLine.Option(
+      names = {"-o", "--output"},
+      description = "results file to write",
+      required = true)
+  private File output;
+
+  /**
+   * @return 0 when the results were merged
+   * @throws Exception when unable to read or merge the results
+   */
+  @Override
+  public Integer call() throws Exception {
+    final ObjectMapper mapper = new ObjectMapper();
+    final List<Map<String, Object>> runs = new ArrayList<>();
+    for (final File input : inputs) {
+      runs.add(mapper.readValue(input, new TypeReference<Map<String, Object>>() {}));
+    }
+    mapper.writerWithDefaultPrettyPrinter().writeValue(output, ResultsMerger.merge(runs));
+    System.out.printf("%d results merged into %s%n", runs.size(), output);
+    return 0;
+  }
+}
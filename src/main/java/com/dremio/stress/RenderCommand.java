@@ -0,0 +1,70 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.HtmlReport;
+import com.fasterxml.jackson.core.type.TypeReference;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import java.io.File;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.util.Map;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** report render subcommand, writes the html report of an existing results file */
+@CommandLine.Command(
+    name = "render",
+    description =
+        "write the html report of a results file written with --results-file or --output-dir, to"
+            + " regenerate or restyle it without running the stress test again",
+    usageHelpWidth = 300)
+public class RenderCommand implements Callable<Integer> {
+
+  /** results to render */
+  @CommandLine.Option(names = {"-i", "--input"}, description = "the results file", required = true)
+  private File input;
+
+  /** where the report goes */
+  @CommandLine.Option(
+      names = {"-o", "--output"},
+      description = "html file to write, defaults to the results file with an .html extension")
+  private File output;
+
+  /** restyling */
+  @CommandLine.Option(
+      names = {"--css"},
+      description = "css file whose rules are added after the default style and override it")
+  private File css;
+
+  /**
+   * @return 0 when the report was written
+   * @throws Exception when unable to read the results or write the report
+   */
+  @Override
+  public Integer call() throws Exception {
+    final Map<String, Object> results =
+        new ObjectMapper().readValue(input, new TypeReference<Map<String, Object>>() {});
+    File report = output;
+    if (report == null) {
+      final String name = input.getName().replaceFirst("\\.json$", "");
+      report = new File(input.getAbsoluteFile().getParentFile(), name + ".html");
+    }
+    final String style =
+        css == null ? null : new String(Files.readAllBytes(css.toPath()), StandardCharsets.UTF_8);
+    HtmlReport.write(results, report, style);
+    System.out.printf("report written to %s%n", report);
+    return 0;
+  }
+}
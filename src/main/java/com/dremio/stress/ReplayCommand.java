@@ -0,0 +1,52 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.QueriesGeneratorFileType;
+import java.io.File;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** replay subcommand, replays the queries of queries.json logs */
+@CommandLine.Command(
+    name = "replay",
+    description =
+        "replay the queries of queries.json logs, same as -g QUERIES_JSON. Connection options are"
+            + " given before the subcommand",
+    usageHelpWidth = 300)
+public class ReplayCommand implements Callable<Integer> {
+
+  @CommandLine.ParentCommand private DremioStress parent;
+
+  /** queries to replay */
+  @CommandLine.Parameters(
+      index = "0",
+      description =
+          "queries.json, queries.json.gz, a directory or quoted glob of rotated logs, - for stdin")
+  private File queries;
+
+  @CommandLine.Mixin private RunOptions runOptions;
+
+  @CommandLine.Mixin private ReplayOptions replayOptions;
+
+  /**
+   * @return the exit code of the replay 0 is success
+   * @throws Exception when the replay fails
+   */
+  @Override
+  public Integer call() throws Exception {
+    return parent.run(
+        queries, QueriesGeneratorFileType.QUERIES_JSON, runOptions, null, replayOptions);
+  }
+}
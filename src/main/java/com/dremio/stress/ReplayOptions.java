@@ -0,0 +1,86 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.ReplayFilter;
+import com.dremio.support.diagnostics.stress.StressOptions;
+import java.util.ArrayList;
+import java.util.List;
+import picocli.CommandLine;
+
+/** ReplayOptions pick the queries of a queries.json replay and its pace, for replay and -g */
+public class ReplayOptions {
+
+  @CommandLine.Option(
+      names = {"--replay-user"},
+      description =
+          "QUERIES_JSON only: replay only the queries of this user, repeat for more users")
+  private List<String> replayUsers = new ArrayList<>();
+
+  @CommandLine.Option(
+      names = {"--replay-queue"},
+      description =
+          "QUERIES_JSON only: replay only the queries that ran in this queue, repeat for more queues")
+  private List<String> replayQueues = new ArrayList<>();
+
+  @CommandLine.Option(
+      names = {"--replay-query-type"},
+      description =
+          "QUERIES_JSON only: replay only queries of this queryType such as UI_RUN, JDBC, ODBC or REST, repeat for more types")
+  private List<String> replayQueryTypes = new ArrayList<>();
+
+  @CommandLine.Option(
+      names = {"--replay-min-duration-seconds"},
+      description =
+          "QUERIES_JSON only: replay only queries that originally took at least this long",
+      defaultValue = "0")
+  private double replayMinDurationSeconds;
+
+  @CommandLine.Option(
+      names = {"--replay-exclude-metadata"},
+      description =
+          "QUERIES_JSON only: leave out metadata refreshes, SHOW, DESCRIBE and INFORMATION_SCHEMA queries",
+      defaultValue = "false")
+  private boolean replayExcludeMetadata;
+
+  @CommandLine.Option(
+      names = {"--replay-speed"},
+      description =
+          "QUERIES_JSON only: submit the queries at their original inter-arrival times divided by this factor, in the order they started. 2 replays twice as intense, 0.5 at half the pace. Without it queries are submitted as fast as the workers allow")
+  private Double replaySpeed;
+
+  @CommandLine.Option(
+      names = {"--replay-original-concurrency"},
+      description =
+          "QUERIES_JSON only: start every query when it originally started (at --replay-speed, default 1) on a thread of its own instead of waiting for one of the --max-queries-in-flight workers, so the original overlap of the queries is reproduced",
+      defaultValue = "false")
+  private boolean replayOriginalConcurrency;
+
+  /**
+   * fills the replay settings of the run
+   *
+   * @param options the settings of the run
+   */
+  void apply(final StressOptions options) {
+    options.setReplayFilter(
+        new ReplayFilter(
+            replayUsers,
+            replayQueues,
+            replayQueryTypes,
+            replayMinDurationSeconds,
+            replayExcludeMetadata));
+    options.setReplaySpeed(replaySpeed);
+    options.setReplayOriginalConcurrency(replayOriginalConcurrency);
+  }
+}
@@ -0,0 +1,24 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import picocli.CommandLine;
+
+/** report subcommand, groups the commands that work on the results of earlier runs */
+@CommandLine.Command(
+    name = "report",
+    description = "work with the results of earlier runs",
+    usageHelpWidth = 300,
+    subcommands = {TrendCommand.class, RenderCommand.class, MergeCommand.class})
+public class ReportCommand {}
@@ -0,0 +1,54 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.QueriesGeneratorFileType;
+import java.io.File;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** run subcommand, runs a stress.json workload */
+@CommandLine.Command(
+    name = "run",
+    description =
+        "run the workload of a stress.json, same as -g STRESS_JSON. Connection options are given"
+            + " before the subcommand",
+    usageHelpWidth = 300)
+public class RunCommand implements Callable<Integer> {
+
+  @CommandLine.ParentCommand private DremioStress parent;
+
+  /** workload to run */
+  @CommandLine.Parameters(
+      index = "0",
+      arity = "0..1",
+      description =
+          "the stress.json to run, a quoted glob of stress.json files to merge, - for stdin, leave"
+              + " it out when --profile is given")
+  private File config;
+
+  @CommandLine.Mixin private RunOptions runOptions;
+
+  @CommandLine.Mixin private StressJsonOptions stressJsonOptions;
+
+  /**
+   * @return the exit code of the run 0 is success
+   * @throws Exception when the run fails
+   */
+  @Override
+  public Integer call() throws Exception {
+    return parent.run(
+        config, QueriesGeneratorFileType.STRESS_JSON, runOptions, stressJsonOptions, null);
+  }
+}
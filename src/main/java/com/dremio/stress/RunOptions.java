@@ -0,0 +1,483 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.QueriesSequence;
+import com.dremio.support.diagnostics.stress.StressOptions;
+import java.io.File;
+import java.time.LocalDateTime;
+import java.time.format.DateTimeFormatter;
+import java.util.Arrays;
+import java.util.List;
+import picocli.CommandLine;
+
+/**
+ * RunOptions are the options of running a workload, shared by the run, replay and agent
+ * subcommands and the flat -g form. The connection options stay on the top level command as every
+ * subcommand that talks to dremio needs them.
+ */
+public class RunOptions {
+
+  @CommandLine.Spec(CommandLine.Spec.Target.MIXEE)
+  private CommandLine.Model.CommandSpec spec;
+
+  @CommandLine.Option(
+      names = {"-q", "--max-queries-in-flight"},
+      description = "max number of queries in flight (if possible)",
+      defaultValue = "32")
+  private Integer maxQueriesInFlight;
+
+  /** number of simulated users */
+  @CommandLine.Option(
+      names = {"--sessions"},
+      description =
+          "simulate this many users, each with its own connection and think time, multiplexed over the max queries in flight workers. 0 disables session simulation",
+      defaultValue = "0")
+  private Integer sessions;
+
+  /** time a session waits between its statements */
+  @CommandLine.Option(
+      names = {"--think-time-ms"},
+      description =
+          "average time in milliseconds a session waits after a statement (or query group) before running the next one",
+      defaultValue = "0")
+  private Integer thinkTimeMS;
+
+  /** give every worker its own connection instead of sharing one */
+  @CommandLine.Option(
+      names = {"--connection-per-worker"},
+      description =
+          "every worker logs in (HTTP) or opens a connection (JDBC) of its own on its first statement instead of all workers sharing one, to compare token sharing and connection contention with many clients",
+      defaultValue = "false")
+  private boolean connectionPerWorker;
+
+  /** bind parameters instead of substituting them into the sql text */
+  @CommandLine.Option(
+      names = {"--prepared-statements"},
+      description =
+          "JDBC only, run queries as prepared statements binding the stress.json parameters instead of substituting them into the sql text",
+      defaultValue = "false")
+  private boolean preparedStatements;
+
+  /** number of queued queries before the generator pauses */
+  @CommandLine.Option(
+      names = {"--queue-size"},
+      description =
+          "number of queries that can be queued waiting for a worker before submission pauses, 0 uses 10 times the number of workers",
+      defaultValue = "0")
+  private Integer queueSize;
+
+  /** scale factor applied to the workload intensity */
+  @CommandLine.Option(
+      names = {"--scale"},
+      description =
+          "multiplies the workload intensity (max queries in flight, the dedicated workers of query groups and the queriesPerMinute of users) uniformly so the same stress.json can represent 1x, 2x, 5x of an observed workload. Frequencies in the stress.json are relative weights so the query mix is unchanged",
+      defaultValue = "1.0")
+  private Double scale;
+
+  /** rate of queries to mutate with the sql fuzzer */
+  @CommandLine.Option(
+      names = {"--fuzz-rate"},
+      description =
+          "fraction between 0 and 1 of queries to mutate (random column subsets, random predicates, bad casts) to stress planner and error handling paths. Only SELECT and WITH statements are mutated, they are logged as warnings for reproduction and their failures are counted apart from the failures of the run",
+      defaultValue = "0")
+  private Double fuzzRate;
+
+  @CommandLine.Option(
+      names = {"--recycle-connections-seconds"},
+      description =
+          "replace connections (logins over HTTP) once they are this old so multi-day runs rebalance over the coordinators, 0 keeps them for the whole run",
+      defaultValue = "0")
+  private int recycleConnectionsSeconds;
+
+  @CommandLine.Option(
+      names = {"--chaos-interval-seconds"},
+      description =
+          "every this many seconds drop --chaos-percent of the connections, or delay --chaos-percent of the statements of the next interval by --chaos-delay-ms, to test how the workload recovers from coordinator restarts. 0 disables chaos",
+      defaultValue = "0")
+  private int chaosIntervalSeconds;
+
+  @CommandLine.Option(
+      names = {"--chaos-percent"},
+      description = "percentage of connections or statements chaos affects",
+      defaultValue = "10")
+  private double chaosPercent;
+
+  @CommandLine.Option(
+      names = {"--chaos-delay-ms"},
+      description = "client side delay chaos injects before statements, 0 only drops connections",
+      defaultValue = "0")
+  private int chaosDelayMS;
+
+  @CommandLine.Option(
+      names = {"-d", "--duration-seconds"},
+      description = "duration in seconds to run stress",
+      defaultValue = "600")
+  private Integer durationSeconds;
+
+  /** how long to wait for engines to start */
+  @CommandLine.Option(
+      names = {"--warm-up-seconds"},
+      description =
+          "run the --warm-up-sql until it succeeds, for at most this many seconds, before timing begins so engines that start on demand are running, 0 disables warm-up",
+      defaultValue = "0")
+  private Integer warmUpSeconds;
+
+  /** statement used to start the engines */
+  @CommandLine.Option(
+      names = {"--warm-up-sql"},
+      description = "statement run to start the engines",
+      defaultValue = "SELECT 1")
+  private String warmUpSql;
+
+  @CommandLine.Option(
+      names = {"--max-queries"},
+      description =
+          "stop after submitting this many queries or when the duration is reached, whichever comes first. A query group that no longer fits is not started instead of being cut short. 0 means no limit",
+      defaultValue = "0")
+  private Integer maxQueries;
+
+  @CommandLine.Option(
+      names = {"--max-error-rate"},
+      description =
+          "fail the run when more than this percent of the submitted statements fail, 0 fails the run on any failure",
+      defaultValue = "0")
+  private Double maxErrorRate;
+
+  @CommandLine.Option(
+      names = {"--print-queries"},
+      description =
+          "print this many sampled, fully expanded queries (parameters substituted, context shown) and exit without running them",
+      defaultValue = "0")
+  private Integer printQueries;
+
+  @CommandLine.Option(
+      names = {"--hard-deadline"},
+      description =
+          "when the run ends also cancel the queries still running on dremio (job cancel over HTTP, statement cancel over JDBC) instead of leaving them running after the client exits",
+      defaultValue = "false")
+  private boolean hardDeadline;
+
+  @CommandLine.Option(
+      names = {"--watchdog-factor"},
+      description =
+          "log diagnostics for workers stuck in a single statement for longer than this many times the timeout, 0 disables the watchdog",
+      defaultValue = "2")
+  private Integer watchdogFactor;
+
+  @CommandLine.Option(
+      names = {"--watchdog-restart"},
+      description =
+          "interrupt workers found by the watchdog and switch new queries to a fresh connection",
+      defaultValue = "false")
+  private boolean watchdogRestart;
+
+  @CommandLine.Option(
+      names = {"--restart-after-failures"},
+      description =
+          "reconnect a worker, logging in again, after this many consecutive failures so a poisoned connection does not fail the rest of the run, 0 disables it",
+      defaultValue = "0")
+  private Integer restartAfterFailures;
+
+  @CommandLine.Option(
+      names = {"--health-check-seconds"},
+      description =
+          "health check every connection this often and reconnect the unhealthy ones, such as an expired login, 0 disables the checks",
+      defaultValue = "60")
+  private Integer healthCheckSeconds;
+
+  @CommandLine.Option(
+      names = {"--probe-seconds"},
+      description =
+          "run --probe-sql this often on a connection of its own next to the workload and report its latency apart, to show how interactive queries suffer under the load, 0 disables the probe",
+      defaultValue = "0")
+  private Integer probeSeconds;
+
+  @CommandLine.Option(
+      names = {"--probe-sql"},
+      description = "the statement --probe-seconds runs",
+      defaultValue = "SELECT 1")
+  private String probeSql;
+
+  @CommandLine.Option(
+      names = {"--bucket-seconds"},
+      description =
+          "width of the buckets of the throughput, error and latency time series in the results file, 0 leaves the time series out",
+      defaultValue = "1")
+  private Integer bucketSeconds;
+
+  /** webhook to notify */
+  @CommandLine.Option(
+      names = {"--notify-webhook"},
+      description =
+          "Slack or Teams incoming webhook url to post the start, summary and aborts of the run to")
+  private String notifyWebhook;
+
+  /** where to write the results */
+  @CommandLine.Option(
+      names = {"--results-file"},
+      description =
+          "write the results of the run (totals, per query latency percentiles, phases, reflection hit rate and client stats) as json to this file")
+  private File resultsFile;
+
+  /** parent of the directory collecting the artifacts of the run */
+  @CommandLine.Option(
+      names = {"--output-dir"},
+      description =
+          "collect every artifact of the run (results.json, the effective config, report.html, the jobs.csv job id manifest, the profiles of failed jobs and the log) in a new <yyyyMMdd-HHmmss> directory under this directory, replaces --results-file")
+  private File outputDir;
+
+  /** sqlite file to append the results to */
+  @CommandLine.Option(
+      names = {"--results-db"},
+      description =
+          "append the results of the run to this sqlite file (tables runs and run_queries), created when missing")
+  private File resultsDb;
+
+  /** where to upload the artifacts */
+  @CommandLine.Option(
+      names = {"--upload-uri"},
+      description =
+          "s3:// or gs:// uri to upload the results file to at the end of the run, requires the aws cli or gsutil. Without --results-file the results are written to dremio-stress-results-<run id>.json")
+  private String uploadUri;
+
+  @CommandLine.Option(
+      names = {"--provision"},
+      description =
+          "HTTP only: create the --provision-space space and the Samples source before the run when they are missing and remove what was created after it, so the example configs work against a fresh cluster",
+      defaultValue = "false")
+  private boolean provision;
+
+  @CommandLine.Option(
+      names = {"--provision-space"},
+      description = "name of the space created by --provision",
+      defaultValue = "space")
+  private String provisionSpace;
+
+  @CommandLine.Option(
+      names = {"--read-only"},
+      description =
+          "refuse a stress.json with a statement that is not SELECT, WITH, SHOW, EXPLAIN, DESCRIBE, USE or ALTER SESSION, a query group with one refuses the group, and leave such statements out of a queries.json replay, for runs against production",
+      defaultValue = "false")
+  private boolean readOnly;
+
+  @CommandLine.Option(
+      names = {"--explain-only"},
+      description =
+          "wrap every statement in EXPLAIN PLAN FOR so only the planner and metadata are exercised, without executor cost. EXPLAIN, SHOW, DESCRIBE, USE and ALTER SESSION run as they are",
+      defaultValue = "false")
+  private boolean explainOnly;
+
+  @CommandLine.Option(
+      names = {"--profile"},
+      description =
+          "run a built-in workload against the Samples source instead of a config file: light, dashboard or etl-mixed")
+  private String profile;
+
+  /** address for the control api */
+  @CommandLine.Option(
+      names = {"--control-addr"},
+      description =
+          "host:port to expose a control api on (POST /pause, POST /resume, POST /stop, POST /annotate, GET /status), disabled when not set. It has no authentication, :port listens on localhost only, give 0.0.0.0:port to expose it")
+  private String controlAddress;
+
+  /** commands typed while the run is live */
+  @CommandLine.Option(
+      names = {"--interactive"},
+      description =
+          "read commands from the terminal while the run is live: set qps <n>, set workers <n>, pause [group <name>], resume [group <name>], note <text>, stats and stop",
+      defaultValue = "false")
+  private boolean interactive;
+
+  /** file read on SIGHUP for annotations */
+  @CommandLine.Option(
+      names = {"--annotations-file"},
+      description =
+          "on SIGHUP add the lines appended to this file as annotations to the timeline of the results, for example \"executor killed\"")
+  private File annotationsFile;
+
+  /** address for the diagnostics endpoint */
+  @CommandLine.Option(
+      names = {"--diagnostics-addr"},
+      description =
+          "host:port to expose client diagnostics on (GET /debug/jvm, GET /debug/threads), disabled when not set. :port listens on localhost only, give 0.0.0.0:port to expose it")
+  private String diagnosticsAddress;
+
+  /** address for the web ui */
+  @CommandLine.Option(
+      names = {"--web-addr"},
+      description =
+          "host:port to serve a web page with live throughput and latency charts and a stop button on, disabled when not set. It has no authentication, :port listens on localhost only, give 0.0.0.0:port to expose it")
+  private String webAddress;
+
+  /** second cluster to compare with */
+  @CommandLine.Option(
+      names = {"--compare-url"},
+      description =
+          "HTTP url or JDBC connection string of a second cluster, every generated statement is also run there and a side by side latency and error report is printed at the end")
+  private String compareUrl;
+
+  /** cluster to send shadow traffic to */
+  @CommandLine.Option(
+      names = {"--shadow-url"},
+      description =
+          "HTTP url or JDBC connection string of a cluster to duplicate a percentage of the statements to, shadow statements do not change the statistics of the run")
+  private String shadowUrl;
+
+  /** percentage of statements to duplicate */
+  @CommandLine.Option(
+      names = {"--shadow-percent"},
+      description = "percentage of the generated statements to duplicate to the --shadow-url",
+      defaultValue = "10")
+  private Double shadowPercent;
+
+  /** limit queries results to said limit */
+  @CommandLine.Option(
+      names = {"--limit-results"},
+      interactive = false,
+      description =
+          "limit results to the specified number assuming there is not already a LIMIT in the query. This is an easy way to just add some limits on the result set size")
+  private Integer limitResults;
+
+  /** query execution sequence */
+  @CommandLine.Option(
+      names = {"--execution-sequence", "-x"},
+      description = "specify RANDOM or SEQUENTIAL to specify the execution sequence",
+      defaultValue = "RANDOM")
+  private QueriesSequence queriesSequence;
+
+  /** query index to restart from */
+  @CommandLine.Option(
+      names = {"--restart-index", "-i"},
+      description = "specify query index to restart from (for SEQUENTIAL execution-sequence only)",
+      defaultValue = "-1")
+  private Integer queryIndexForRestart;
+
+  /**
+   * fills the settings of the run these options control
+   *
+   * @param options the settings of the run
+   * @param runDir directory collecting the artifacts of the run, null without --output-dir
+   */
+  void apply(final StressOptions options, final File runDir) {
+    options.setQueriesSequence(queriesSequence);
+    options.setQueryIndexForRestart(queryIndexForRestart);
+    options.setLimitResults(limitResults);
+    options.setCompareHost(compareUrl);
+    options.setShadowHost(shadowUrl);
+    options.setShadowPercent(shadowPercent);
+    options.setMaxQueriesInFlight(maxQueriesInFlight);
+    options.setSessions(sessions);
+    options.setThinkTimeMS(thinkTimeMS);
+    options.setConnectionPerWorker(connectionPerWorker);
+    options.setRecycleConnectionsSeconds(recycleConnectionsSeconds);
+    options.setChaosIntervalSeconds(chaosIntervalSeconds);
+    options.setChaosPercent(chaosPercent);
+    options.setChaosDelayMS(chaosDelayMS);
+    options.setQueueSize(queueSize);
+    options.setScale(scale);
+    options.setFuzzRate(fuzzRate);
+    options.setPreparedStatements(preparedStatements);
+    options.setWarmUpSeconds(warmUpSeconds);
+    options.setWarmUpSql(warmUpSql);
+    options.setDurationSeconds(durationSeconds);
+    options.setMaxQueries(maxQueries);
+    options.setMaxErrorRate(maxErrorRate);
+    options.setPrintQueries(printQueries);
+    options.setHardDeadline(hardDeadline);
+    options.setWatchdogFactor(watchdogFactor);
+    options.setWatchdogRestart(watchdogRestart);
+    options.setRestartAfterFailures(restartAfterFailures);
+    options.setHealthCheckSeconds(healthCheckSeconds);
+    options.setProbeSeconds(probeSeconds);
+    options.setProbeSql(probeSql);
+    options.setBucketSeconds(bucketSeconds);
+    options.setNotifyWebhook(notifyWebhook);
+    options.setResultsFile(resultsFile);
+    options.setResultsDb(resultsDb);
+    options.setOutputDir(runDir);
+    options.setUploadUri(uploadUri);
+    options.setProvisionSpace(provision ? provisionSpace : null);
+    options.setReadOnly(readOnly);
+    options.setExplainOnly(explainOnly);
+  }
+
+  /**
+   * creates the directory of this run under --output-dir, named after the time the run started
+   *
+   * @return the new directory, null without --output-dir
+   */
+  File createRunDir() {
+    if (outputDir == null) {
+      return null;
+    }
+    if (resultsFile != null) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "--output-dir writes results.json, drop --results-file");
+    }
+    final String name = LocalDateTime.now().format(DateTimeFormatter.ofPattern("yyyyMMdd-HHmmss"));
+    File runDir = new File(outputDir, name);
+    // two runs started within the same second get their own directory
+    for (int i = 2; runDir.exists(); i++) {
+      runDir = new File(outputDir, name + "-" + i);
+    }
+    if (!runDir.mkdirs()) {
+      throw new CommandLine.ParameterException(
+          spec.commandLine(), "unable to create the output directory " + runDir);
+    }
+    return runDir;
+  }
+
+  /** @param file results file written when neither --results-file nor --output-dir is given */
+  void defaultResultsFile(final File file) {
+    if (resultsFile == null && outputDir == null) {
+      resultsFile = file;
+    }
+  }
+
+  /** @return the files and directories the run writes to, null when not given */
+  List<File> getOutputs() {
+    return Arrays.asList(resultsFile, outputDir, resultsDb);
+  }
+
+  /** @return the sqlite file the results are appended to, null when not given */
+  File getResultsDb() {
+    return resultsDb;
+  }
+
+  /** @return the built-in workload to run, null when not given */
+  String getProfile() {
+    return profile;
+  }
+
+  boolean isInteractive() {
+    return interactive;
+  }
+
+  String getControlAddress() {
+    return controlAddress;
+  }
+
+  String getDiagnosticsAddress() {
+    return diagnosticsAddress;
+  }
+
+  String getWebAddress() {
+    return webAddress;
+  }
+
+  File getAnnotationsFile() {
+    return annotationsFile;
+  }
+}
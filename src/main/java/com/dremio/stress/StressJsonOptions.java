@@ -0,0 +1,71 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.LabelFilter;
+import com.dremio.support.diagnostics.stress.LabelWeights;
+import com.dremio.support.diagnostics.stress.ParameterOverrides;
+import com.dremio.support.diagnostics.stress.StressOptions;
+import java.util.ArrayList;
+import java.util.List;
+import picocli.CommandLine;
+
+/** StressJsonOptions select and adjust the queries of a stress.json, for run, agent and -g */
+public class StressJsonOptions {
+
+  @CommandLine.Option(
+      names = {"--only"},
+      split = ",",
+      description =
+          "STRESS_JSON only: run only the queries with at least one of these labels, comma separated. A query has its own labels and those of its query group")
+  private List<String> onlyLabels = new ArrayList<>();
+
+  @CommandLine.Option(
+      names = {"--exclude"},
+      split = ",",
+      description =
+          "STRESS_JSON only: leave out the queries with any of these labels, comma separated, applied after --only")
+  private List<String> excludeLabels = new ArrayList<>();
+
+  @CommandLine.Option(
+      names = {"--weight"},
+      description =
+          "STRESS_JSON only: replace the frequency or weight of the queries with a label as label=weight, such as dashboards=10, repeat for more labels. A query with several of the labels gets the weight given last")
+  private List<String> labelWeights = new ArrayList<>();
+
+  @CommandLine.Option(
+      names = {"--param"},
+      description =
+          "STRESS_JSON only: replace the values of a parameter of the stress.json as name=value, such as start=2024-01-01, repeat the name for a list of values")
+  private List<String> parameterOverrides = new ArrayList<>();
+
+  @CommandLine.Option(
+      names = {"--strict"},
+      description =
+          "STRESS_JSON only: refuse unknown fields and queries without a frequency or weight instead of warning and running them at frequency 1, for CI",
+      defaultValue = "false")
+  private boolean strict;
+
+  /**
+   * fills the stress.json settings of the run
+   *
+   * @param options the settings of the run
+   */
+  void apply(final StressOptions options) {
+    options.setLabelFilter(new LabelFilter(onlyLabels, excludeLabels));
+    options.setLabelWeights(new LabelWeights(labelWeights));
+    options.setParameterOverrides(new ParameterOverrides(parameterOverrides));
+    options.setStrict(strict);
+  }
+}
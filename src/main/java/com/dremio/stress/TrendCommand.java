@@ -0,0 +1,82 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.TrendReport;
+import java.io.File;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** report trend subcommand, latency of every query across historical runs */
+@CommandLine.Command(
+    name = "trend",
+    description =
+        "print the latency of every query across historical runs and flag regressions, exits with 1"
+            + " when a query regressed",
+    usageHelpWidth = 300)
+public class TrendCommand implements Callable<Integer> {
+
+  /** where the runs come from */
+  @CommandLine.ArgGroup(multiplicity = "1")
+  private Source source;
+
+  static class Source {
+    @CommandLine.Option(
+        names = {"--results-db"},
+        description = "sqlite file written with --results-db")
+    private File resultsDb;
+
+    @CommandLine.Option(
+        names = {"--dir"},
+        description = "directory of results files written with --results-file")
+    private File dir;
+  }
+
+  /** latency to trend */
+  @CommandLine.Option(
+      names = {"--metric"},
+      description = "latency to trend: meanMS, p50MS, p95MS or p99MS",
+      defaultValue = "p95MS")
+  private String metric;
+
+  /** how unusual the latest run must be */
+  @CommandLine.Option(
+      names = {"--threshold"},
+      description =
+          "standard deviations above the mean of the earlier runs the latest run must be to count as a regression",
+      defaultValue = "3")
+  private Double threshold;
+
+  /** baseline size */
+  @CommandLine.Option(
+      names = {"--min-runs"},
+      description = "earlier runs needed before regressions are flagged",
+      defaultValue = "3")
+  private Integer minRuns;
+
+  /**
+   * @return 0 when no query regressed, 1 otherwise
+   * @throws Exception when unable to read the runs
+   */
+  @Override
+  public Integer call() throws Exception {
+    final TrendReport report = new TrendReport(metric, threshold, minRuns);
+    if (source.resultsDb != null) {
+      report.loadStore(source.resultsDb);
+    } else {
+      report.loadDirectory(source.dir);
+    }
+    return report.print(System.out) == 0 ? 0 : 1;
+  }
+}
@@ -0,0 +1,75 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import com.dremio.support.diagnostics.stress.ConfigReader;
+import com.dremio.support.diagnostics.stress.StressConfig;
+import com.dremio.support.diagnostics.stress.StressExec;
+import java.io.File;
+import java.io.IOException;
+import java.security.InvalidParameterException;
+import java.util.concurrent.Callable;
+import picocli.CommandLine;
+
+/** validate subcommand, checks a stress.json without running it */
+@CommandLine.Command(
+    name = "validate",
+    description =
+        "check a stress.json without connecting to dremio: json syntax, query groups, weights,"
+            + " parameter values, timeouts, SLAs and dependencies. Exits with 1 when it is invalid",
+    usageHelpWidth = 300)
+public class ValidateCommand implements Callable<Integer> {
+
+  /** config to check */
+  @CommandLine.Parameters(
+      index = "0",
+      description = "the stress.json to check, a quoted glob of files to merge, - for stdin")
+  private File config;
+
+  /** also check nothing writes */
+  @CommandLine.Option(
+      names = {"--read-only"},
+      description = "also refuse statements that write, as a run with --read-only would",
+      defaultValue = "false")
+  private boolean readOnly;
+
+  /** unknown fields are errors */
+  @CommandLine.Option(
+      names = {"--strict"},
+      description =
+          "refuse unknown fields and queries without a frequency or weight instead of warning",
+      defaultValue = "false")
+  private boolean strict;
+
+  /**
+   * @return 0 when the config is valid, 1 otherwise
+   * @throws Exception when the check fails unexpectedly
+   */
+  @Override
+  public Integer call() throws Exception {
+    final StressConfig parsed;
+    try {
+      parsed =
+          "-".equals(config.getPath())
+              ? ConfigReader.parse("stdin", ConfigReader.readAll(System.in), strict)
+              : ConfigReader.read(config, strict);
+      StressExec.validate(parsed, readOnly);
+    } catch (IOException | InvalidParameterException e) {
+      System.out.printf("%s is invalid: %s%n", config, e.getMessage());
+      return 1;
+    }
+    System.out.printf("%s is valid, %d queries%n", config, parsed.getQueries().size());
+    return 0;
+  }
+}
@@ -0,0 +1,83 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.util.List;
+import java.util.logging.Level;
+import java.util.logging.Logger;
+import sun.misc.Signal;
+
+/**
+ * AnnotationFile adds the lines appended to a file as annotations to the timeline of the run
+ * whenever the process receives SIGHUP, for hosts where the control api is not reachable:
+ *
+ * <pre>
+ * echo "executor 3 killed" >> annotations.txt && kill -HUP $(pgrep -f dremio-stress)
+ * </pre>
+ */
+public class AnnotationFile {
+
+  private static final Logger logger = Logger.getLogger(AnnotationFile.class.getName());
+
+  private final File file;
+  private final StressControl control;
+  // lines already added, so every line is only annotated once
+  private int linesRead;
+
+  /**
+   * @param file file operators append annotations to, one per line
+   * @param control the run to annotate
+   */
+  public AnnotationFile(final File file, final StressControl control) {
+    this.file = file;
+    this.control = control;
+  }
+
+  /**
+   * skips the lines already in the file and reads the new ones on every SIGHUP
+   *
+   * @throws IOException when the file exists but cannot be read
+   * @throws IllegalArgumentException when the platform has no SIGHUP, such as Windows
+   */
+  public void install() throws IOException {
+    if (file.exists()) {
+      linesRead = Files.readAllLines(file.toPath(), StandardCharsets.UTF_8).size();
+    }
+    Signal.handle(new Signal("HUP"), signal -> read());
+    logger.info(() -> String.format("send SIGHUP to annotate the lines added to %s", file));
+  }
+
+  /** annotates the lines added since the last read */
+  synchronized void read() {
+    try {
+      final List<String> lines = Files.readAllLines(file.toPath(), StandardCharsets.UTF_8);
+      if (lines.size() < linesRead) {
+        // the file was truncated, start over
+        linesRead = 0;
+      }
+      for (final String line : lines.subList(linesRead, lines.size())) {
+        if (!line.trim().isEmpty()) {
+          control.annotate(line.trim());
+        }
+      }
+      linesRead = lines.size();
+    } catch (IOException e) {
+      logger.log(Level.WARNING, "unable to read annotations from " + file, e);
+    }
+  }
+}
@@ -13,6 +13,7 @@
  */
 package com.dremio.support.diagnostics.stress;
 
+import java.io.File;
 import java.io.IOException;
 import java.net.URL;
 import java.util.Map;
@@ -21,4 +22,18 @@ public interface ApiCall {
   HttpApiResponse submitPost(URL url, Map<String, String> headers, String body) throws IOException;
 
   HttpApiResponse submitGet(URL url, Map<String, String> headers) throws IOException;
+
+  HttpApiResponse submitDelete(URL url, Map<String, String> headers) throws IOException;
+
+  /**
+   * posts to the url and writes the raw body of the response to a file, for binary downloads such
+   * as job profiles
+   *
+   * @param url url to post to
+   * @param headers headers of the request
+   * @param file where to write the body
+   * @return true when the body was written, false when the server answered with an error
+   * @throws IOException when the request or writing the file fails
+   */
+  boolean submitDownload(URL url, Map<String, String> headers, File file) throws IOException;
 }
@@ -0,0 +1,91 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.io.IOException;
+import java.security.InvalidParameterException;
+import java.util.Arrays;
+import java.util.List;
+import java.util.concurrent.TimeUnit;
+import java.util.logging.Logger;
+
+/**
+ * ArtifactUploader copies the artifacts of a run to object storage at the end of the run, stress
+ * machines are often ephemeral cloud instances that are torn down right after. To avoid bundling
+ * cloud sdks the copy is done with the aws cli for s3:// and gsutil for gs:// uris, which must be
+ * installed and authenticated on the machine.
+ */
+public class ArtifactUploader {
+
+  private static final Logger logger = Logger.getLogger(ArtifactUploader.class.getName());
+  private static final long timeoutMinutes = 30;
+
+  private final String uri;
+
+  /**
+   * @param uri prefix to upload to, for example s3://bucket/stress/run1 or gs://bucket/stress/run1
+   * @throws InvalidParameterException when the scheme is not supported
+   */
+  public ArtifactUploader(final String uri) {
+    if (!uri.startsWith("s3://") && !uri.startsWith("gs://")) {
+      throw new InvalidParameterException(
+          String.format("upload uri '%s' must start with s3:// or gs://", uri));
+    }
+    this.uri = uri.endsWith("/") ? uri.substring(0, uri.length() - 1) : uri;
+  }
+
+  /**
+   * uploads every file under the uri, keeping the file name
+   *
+   * @param files files to upload, missing files are skipped
+   * @return true when every file was uploaded
+   */
+  public boolean upload(final List<File> files) {
+    boolean uploaded = true;
+    for (final File file : files) {
+      if (file == null || !file.exists()) {
+        continue;
+      }
+      final String target = uri + "/" + file.getName();
+      final List<String> command =
+          uri.startsWith("s3://")
+              ? Arrays.asList("aws", "s3", "cp", file.getPath(), target)
+              : Arrays.asList("gsutil", "cp", file.getPath(), target);
+      try {
+        final Process process = new ProcessBuilder(command).inheritIO().start();
+        if (!process.waitFor(timeoutMinutes, TimeUnit.MINUTES)) {
+          process.destroyForcibly();
+          logger.severe(String.format("upload of %s to %s timed out", file, target));
+          uploaded = false;
+        } else if (process.exitValue() != 0) {
+          final int exitCode = process.exitValue();
+          logger.severe(
+              String.format("upload of %s to %s failed with exit code %d", file, target, exitCode));
+          uploaded = false;
+        } else {
+          System.out.printf("uploaded %s to %s%n", file, target);
+        }
+      } catch (IOException e) {
+        logger.severe(
+            String.format("unable to run %s: %s", String.join(" ", command), e.getMessage()));
+        uploaded = false;
+      } catch (InterruptedException e) {
+        Thread.currentThread().interrupt();
+        return false;
+      }
+    }
+    return uploaded;
+  }
+}
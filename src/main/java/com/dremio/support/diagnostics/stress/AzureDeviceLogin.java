@@ -0,0 +1,191 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.IOException;
+import java.io.InterruptedIOException;
+import java.io.UnsupportedEncodingException;
+import java.net.URL;
+import java.net.URLEncoder;
+import java.util.Collections;
+import java.util.Map;
+import java.util.concurrent.TimeUnit;
+import java.util.logging.Logger;
+
+/**
+ * AzureDeviceLogin signs in to Azure AD with the device code flow, the user opens the printed url
+ * on any device and enters the code, so no token has to be minted by hand. The Azure AD token is
+ * kept and refreshed silently, logins after the first one, such as reconnects, do not prompt again
+ * until the refresh token expires.
+ */
+public class AzureDeviceLogin {
+
+  private static final Logger logger = Logger.getLogger(AzureDeviceLogin.class.getName());
+  private static final String AUTHORITY = "https://login.microsoftonline.com/";
+  private static final String DEVICE_CODE_GRANT = "urn:ietf:params:oauth:grant-type:device_code";
+  // refreshed a minute early so a token does not expire between handing it out and using it
+  private static final long EXPIRY_MARGIN_MS = TimeUnit.MINUTES.toMillis(1);
+
+  private final ApiCall apiCall;
+  private final String tenant;
+  private final String clientId;
+  private final String scope;
+  private String accessToken;
+  private String refreshToken;
+  private long expiresAt;
+
+  /**
+   * @param apiCall makes the calls to Azure AD
+   * @param tenant tenant id or domain, organizations allows any work account
+   * @param clientId application (client) id of the app registration Dremio trusts
+   * @param scope scopes to request, null requests the default scope of the app
+   */
+  public AzureDeviceLogin(
+      final ApiCall apiCall, final String tenant, final String clientId, final String scope) {
+    this.apiCall = apiCall;
+    this.tenant = tenant;
+    this.clientId = clientId;
+    this.scope = scope != null ? scope : clientId + "/.default offline_access";
+  }
+
+  /**
+   * @return a valid Azure AD access token, signs in with a device code when there is none that can
+   *     be refreshed
+   * @throws IOException when the sign in fails or is not completed in time
+   */
+  public synchronized String token() throws IOException {
+    if (accessToken != null && System.currentTimeMillis() < expiresAt) {
+      return accessToken;
+    }
+    if (refreshToken != null) {
+      final HttpApiResponse response =
+          post(
+              "token",
+              form(
+                  "grant_type",
+                  "refresh_token",
+                  "client_id",
+                  clientId,
+                  "refresh_token",
+                  refreshToken,
+                  "scope",
+                  scope));
+      if (response.getResponse() != null) {
+        store(response.getResponse());
+        return accessToken;
+      }
+      logger.warning(
+          () -> String.format("refreshing the azure ad token failed: %s", response.getMessage()));
+    }
+    return signIn();
+  }
+
+  private String signIn() throws IOException {
+    final HttpApiResponse code = post("devicecode", form("client_id", clientId, "scope", scope));
+    if (code.getResponse() == null) {
+      throw new IOException("azure ad device code request failed: " + code.getMessage());
+    }
+    // tells the user which url to open and which code to enter
+    System.out.println(code.getResponse().get("message"));
+    long intervalSeconds = seconds(code.getResponse().get("interval"), 5);
+    final long expires =
+        System.currentTimeMillis()
+            + TimeUnit.SECONDS.toMillis(seconds(code.getResponse().get("expires_in"), 900));
+    while (System.currentTimeMillis() < expires) {
+      try {
+        Thread.sleep(TimeUnit.SECONDS.toMillis(intervalSeconds));
+      } catch (InterruptedException e) {
+        Thread.currentThread().interrupt();
+        throw new InterruptedIOException("interrupted waiting for the azure ad sign in");
+      }
+      final HttpApiResponse response =
+          post(
+              "token",
+              form(
+                  "grant_type",
+                  DEVICE_CODE_GRANT,
+                  "client_id",
+                  clientId,
+                  "device_code",
+                  String.valueOf(code.getResponse().get("device_code"))));
+      if (response.getResponse() != null) {
+        store(response.getResponse());
+        return accessToken;
+      }
+      final String message = String.valueOf(response.getMessage());
+      if (message.contains("slow_down")) {
+        intervalSeconds += 5;
+      } else if (!message.contains("authorization_pending")) {
+        throw new IOException("azure ad sign in failed: " + message);
+      }
+    }
+    throw new IOException("the azure ad sign in was not completed before the device code expired");
+  }
+
+  private void store(final Map<String, Object> response) throws IOException {
+    if (!response.containsKey("access_token")) {
+      throw new IOException(
+          String.format("access_token was not contained in the response '%s'", response));
+    }
+    accessToken = String.valueOf(response.get("access_token"));
+    if (response.containsKey("refresh_token")) {
+      refreshToken = String.valueOf(response.get("refresh_token"));
+    }
+    expiresAt =
+        System.currentTimeMillis()
+            + TimeUnit.SECONDS.toMillis(seconds(response.get("expires_in"), 3600))
+            - EXPIRY_MARGIN_MS;
+  }
+
+  private HttpApiResponse post(final String endpoint, final String body) throws IOException {
+    final URL url = new URL(AUTHORITY + tenant + "/oauth2/v2.0/" + endpoint);
+    return apiCall.submitPost(
+        url, Collections.singletonMap("Content-Type", "application/x-www-form-urlencoded"), body);
+  }
+
+  // azure ad sends some numbers as strings
+  private static long seconds(final Object value, final long defaultSeconds) {
+    if (value == null) {
+      return defaultSeconds;
+    }
+    try {
+      return Long.parseLong(String.valueOf(value));
+    } catch (NumberFormatException e) {
+      return defaultSeconds;
+    }
+  }
+
+  /**
+   * encodes a form body
+   *
+   * @param pairs names and values taking turns
+   * @return the url encoded body
+   */
+  static String form(final String... pairs) {
+    final StringBuilder body = new StringBuilder();
+    try {
+      for (int i = 0; i + 1 < pairs.length; i += 2) {
+        if (body.length() > 0) {
+          body.append('&');
+        }
+        body.append(URLEncoder.encode(pairs[i], "UTF-8"))
+            .append('=')
+            .append(URLEncoder.encode(pairs[i + 1], "UTF-8"));
+      }
+    } catch (UnsupportedEncodingException e) {
+      throw new RuntimeException(e);
+    }
+    return body.toString();
+  }
+}
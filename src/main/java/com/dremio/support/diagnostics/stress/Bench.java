@@ -0,0 +1,142 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.IOException;
+import java.io.PrintStream;
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.logging.Logger;
+
+/**
+ * Bench runs a single query serially a fixed number of times and reports latency percentiles, so
+ * the effect of a change on one query can be compared before and after without writing a full
+ * stress.json
+ */
+public class Bench {
+
+  private static final Logger logger = Logger.getLogger(Bench.class.getName());
+
+  private final DremioApi api;
+  private final String sql;
+  private final SqlContext context;
+  private final int iterations;
+  private final int warmup;
+
+  /**
+   * @param api connection to run the query on
+   * @param sql the query to benchmark
+   * @param context context path to run the query in
+   * @param iterations number of measured runs
+   * @param warmup number of runs before measuring, their timings are discarded
+   */
+  public Bench(
+      final DremioApi api,
+      final String sql,
+      final SqlContext context,
+      final int iterations,
+      final int warmup) {
+    if (iterations < 1) {
+      throw new IllegalArgumentException("iterations must be at least 1");
+    }
+    if (warmup < 0) {
+      throw new IllegalArgumentException("warmup cannot be negative");
+    }
+    this.api = api;
+    this.sql = sql;
+    this.context = context;
+    this.iterations = iterations;
+    this.warmup = warmup;
+  }
+
+  /**
+   * runs the warmup and measured iterations and prints the report
+   *
+   * @param out where to print the report
+   * @return 0 when every measured run succeeded, 1 otherwise
+   * @throws IOException when the api is unable to submit the query
+   */
+  public int run(final PrintStream out) throws IOException {
+    for (int i = 0; i < warmup; i++) {
+      final DremioApiResponse response = api.runSQL(sql, context);
+      if (!response.isSuccessful()) {
+        logger.warning(() -> String.format("warmup run failed: %s", response.getErrorMessage()));
+      }
+    }
+    final List<Long> latencies = new ArrayList<>();
+    final Map<String, List<Long>> phases = new LinkedHashMap<>();
+    int failures = 0;
+    for (int i = 0; i < iterations; i++) {
+      final long start = System.nanoTime();
+      final DremioApiResponse response = api.runSQL(sql, context);
+      final long elapsedMS = (System.nanoTime() - start) / 1_000_000;
+      if (!response.isSuccessful()) {
+        failures++;
+        logger.warning(() -> String.format("run failed: %s", response.getErrorMessage()));
+        continue;
+      }
+      latencies.add(elapsedMS);
+      if (response.getPhases() != null) {
+        for (final Map.Entry<String, Long> phase : response.getPhases().entrySet()) {
+          phases.computeIfAbsent(phase.getKey(), k -> new ArrayList<>()).add(phase.getValue());
+        }
+      }
+    }
+    out.printf("bench: %d iterations after %d warmup runs of:%n%s%n", iterations, warmup, sql);
+    out.printf("failures: %d%n", failures);
+    if (latencies.isEmpty()) {
+      out.println("no successful runs to report");
+      return 1;
+    }
+    out.println("latency: " + describe(latencies));
+    for (final Map.Entry<String, List<Long>> phase : phases.entrySet()) {
+      out.printf("  %s: %s%n", phase.getKey(), describe(phase.getValue()));
+    }
+    if (phases.isEmpty()) {
+      out.println("phase timings are only available over HTTP");
+    }
+    return failures == 0 ? 0 : 1;
+  }
+
+  private static String describe(final List<Long> values) {
+    final List<Long> sorted = new ArrayList<>(values);
+    Collections.sort(sorted);
+    long total = 0;
+    for (final long value : sorted) {
+      total += value;
+    }
+    return String.format(
+        "min %s; mean %s; p95 %s; p99 %s; max %s",
+        Human.getHumanDurationFromMillis(sorted.get(0)),
+        Human.getHumanDurationFromMillis(total / sorted.size()),
+        Human.getHumanDurationFromMillis(percentile(sorted, 95)),
+        Human.getHumanDurationFromMillis(percentile(sorted, 99)),
+        Human.getHumanDurationFromMillis(sorted.get(sorted.size() - 1)));
+  }
+
+  /**
+   * nearest rank percentile
+   *
+   * @param sorted values in ascending order, must not be empty
+   * @param percentile percentile between 1 and 100
+   * @return the value at the percentile
+   */
+  static long percentile(final List<Long> sorted, final int percentile) {
+    final int rank = (int) Math.ceil(percentile / 100.0 * sorted.size());
+    return sorted.get(Math.max(rank, 1) - 1);
+  }
+}
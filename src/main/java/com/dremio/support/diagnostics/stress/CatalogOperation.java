@@ -0,0 +1,56 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.security.InvalidParameterException;
+import java.util.Locale;
+
+/**
+ * CatalogOperation is a call to the catalog rest api run as part of the workload, so the
+ * coordinator sees the metadata traffic of BI tools discovering datasets next to the sql.
+ */
+public enum CatalogOperation {
+  /** lists the top level of the catalog, sources, spaces and home */
+  LIST,
+  /** reads an entity by path */
+  GET,
+  /** promotes a file or folder of a source to a dataset */
+  PROMOTE,
+  /** removes the dataset definition of a promoted file or folder */
+  UNPROMOTE;
+
+  /** @return true when the operation works on the entity at a path */
+  public boolean needsPath() {
+    return this != LIST;
+  }
+
+  /**
+   * looks up the operation by name ignoring case
+   *
+   * @param name operation name from the stress.json
+   * @return the operation
+   * @throws InvalidParameterException when there is no such operation
+   */
+  public static CatalogOperation of(final String name) {
+    try {
+      return CatalogOperation.valueOf(name.trim().toUpperCase(Locale.ROOT));
+    } catch (IllegalArgumentException e) {
+      throw new InvalidParameterException(
+          String.format(
+              "unknown catalog operation '%s', supported operations are list, get, promote and"
+                  + " unpromote",
+              name));
+    }
+  }
+}
@@ -0,0 +1,95 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.lang.management.GarbageCollectorMXBean;
+import java.lang.management.ManagementFactory;
+import java.lang.management.MemoryUsage;
+import java.lang.management.OperatingSystemMXBean;
+import java.lang.management.ThreadInfo;
+import java.util.LinkedHashMap;
+import java.util.Map;
+
+/**
+ * ClientStats reads cpu, memory and gc statistics of the stress process itself, so users can
+ * verify the load generator was not the bottleneck when interpreting throughput numbers.
+ */
+public class ClientStats {
+
+  /** prevent instantiation */
+  private ClientStats() {}
+
+  /**
+   * current statistics of the jvm running the stress job
+   *
+   * @return map of stat name to value suitable for serializing to json
+   */
+  public static Map<String, Object> snapshot() {
+    final Map<String, Object> stats = new LinkedHashMap<>();
+    final OperatingSystemMXBean os = ManagementFactory.getOperatingSystemMXBean();
+    stats.put("availableProcessors", os.getAvailableProcessors());
+    stats.put("systemLoadAverage", os.getSystemLoadAverage());
+    if (os instanceof com.sun.management.OperatingSystemMXBean) {
+      final com.sun.management.OperatingSystemMXBean sunOs =
+          (com.sun.management.OperatingSystemMXBean) os;
+      stats.put("processCpuLoad", sunOs.getProcessCpuLoad());
+      stats.put("processCpuTimeMS", sunOs.getProcessCpuTime() / 1_000_000);
+    }
+    final MemoryUsage heap = ManagementFactory.getMemoryMXBean().getHeapMemoryUsage();
+    stats.put("heapUsedBytes", heap.getUsed());
+    stats.put("heapCommittedBytes", heap.getCommitted());
+    stats.put("heapMaxBytes", heap.getMax());
+    long gcCount = 0;
+    long gcTimeMS = 0;
+    for (final GarbageCollectorMXBean gc : ManagementFactory.getGarbageCollectorMXBeans()) {
+      gcCount += Math.max(gc.getCollectionCount(), 0);
+      gcTimeMS += Math.max(gc.getCollectionTime(), 0);
+    }
+    stats.put("gcCount", gcCount);
+    stats.put("gcTimeMS", gcTimeMS);
+    stats.put("threads", ManagementFactory.getThreadMXBean().getThreadCount());
+    return stats;
+  }
+
+  /**
+   * one line human readable summary of the statistics
+   *
+   * @return the summary
+   */
+  public static String summary() {
+    final Map<String, Object> stats = snapshot();
+    final Object cpuLoad = stats.get("processCpuLoad");
+    return String.format(
+        "client stats: cpu load %s; heap used %s of %s; gc count %s; gc time %s; threads %s",
+        cpuLoad == null ? "n/a" : Human.getHumanNumber((Double) cpuLoad * 100.0) + " %",
+        Human.getHumanBytes1024((Long) stats.get("heapUsedBytes")),
+        Human.getHumanBytes1024((Long) stats.get("heapMaxBytes")),
+        stats.get("gcCount"),
+        Human.getHumanDurationFromMillis((Long) stats.get("gcTimeMS")),
+        stats.get("threads"));
+  }
+
+  /**
+   * dump of every thread with its stack, useful to see where workers are spending their time
+   *
+   * @return the thread dump
+   */
+  public static String threadDump() {
+    final StringBuilder dump = new StringBuilder();
+    for (final ThreadInfo info : ManagementFactory.getThreadMXBean().dumpAllThreads(true, true)) {
+      dump.append(info);
+    }
+    return dump.toString();
+  }
+}
@@ -0,0 +1,368 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.core.JsonLocation;
+import com.fasterxml.jackson.core.JsonParser;
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.core.json.JsonReadFeature;
+import com.fasterxml.jackson.databind.DeserializationContext;
+import com.fasterxml.jackson.databind.JsonDeserializer;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.fasterxml.jackson.databind.deser.DeserializationProblemHandler;
+import com.fasterxml.jackson.databind.json.JsonMapper;
+import java.io.ByteArrayOutputStream;
+import java.io.File;
+import java.io.IOException;
+import java.io.InputStream;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.security.InvalidParameterException;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.LinkedHashSet;
+import java.util.List;
+import java.util.Map;
+import java.util.logging.Logger;
+
+/**
+ * ConfigReader parses stress.json as written by editors on any platform. A leading byte order mark
+ * is dropped, CRLF or CR line endings become LF and jsonc/json5 comments and trailing commas are
+ * allowed. The defaults block is applied to the queries that leave its settings out. When the
+ * json is invalid the error names the line, column and byte offset in the original file with the
+ * offending line, instead of only the parser message. A glob such as workloads/*.json merges every
+ * matching file into one workload. By default unknown fields and queries without a frequency or
+ * weight are warned about and the defaults applied, strict parsing refuses them so a typo such as
+ * "frequncy" fails a CI check instead of silently running the query at frequency 1.
+ */
+public class ConfigReader {
+  private static final Logger logger = Logger.getLogger(ConfigReader.class.getName());
+
+  private static final byte[] bom = {(byte) 0xEF, (byte) 0xBB, (byte) 0xBF};
+  // long lines are cut around the error so the snippet stays readable
+  private static final int snippetWidth = 100;
+  // workload files are annotated by hand so comments, trailing commas and the rest of json5 that
+  // jackson understands are accepted
+  private static final ObjectMapper mapper =
+      JsonMapper.builder()
+          .enable(JsonReadFeature.ALLOW_JAVA_COMMENTS)
+          .enable(JsonReadFeature.ALLOW_TRAILING_COMMA)
+          .enable(JsonReadFeature.ALLOW_SINGLE_QUOTES)
+          .enable(JsonReadFeature.ALLOW_UNQUOTED_FIELD_NAMES)
+          .enable(JsonReadFeature.ALLOW_BACKSLASH_ESCAPING_ANY_CHARACTER)
+          .enable(JsonReadFeature.ALLOW_LEADING_PLUS_SIGN_FOR_NUMBERS)
+          .enable(JsonReadFeature.ALLOW_LEADING_DECIMAL_POINT_FOR_NUMBERS)
+          .enable(JsonReadFeature.ALLOW_TRAILING_DECIMAL_POINT_FOR_NUMBERS)
+          .enable(JsonReadFeature.ALLOW_NON_NUMERIC_NUMBERS)
+          .build();
+
+  /** prevent instantiation */
+  private ConfigReader() {}
+
+  /**
+   * reads the stream to the end
+   *
+   * @param in the stream, not closed
+   * @return the bytes read
+   * @throws IOException when unable to read
+   */
+  public static byte[] readAll(final InputStream in) throws IOException {
+    final ByteArrayOutputStream out = new ByteArrayOutputStream();
+    final byte[] buffer = new byte[8192];
+    int read;
+    while ((read = in.read(buffer)) != -1) {
+      out.write(buffer, 0, read);
+    }
+    return out.toByteArray();
+  }
+
+  /**
+   * reads a stress.json from disk. When the path is a glob every matching file is parsed with its
+   * own defaults and the files are merged: queries, query groups and users are combined, the
+   * sessionInit statements are run once each. Two files defining a query group with the same name
+   * and different steps are refused.
+   *
+   * @param path a stress.json or a glob such as workloads/*.json
+   * @return the config
+   * @throws IOException when a file is missing, nothing matches or the json is invalid
+   */
+  public static StressConfig read(final File path) throws IOException {
+    return read(path, false);
+  }
+
+  /**
+   * reads a stress.json from disk or merges the files matching a glob, see {@link #read(File)}
+   *
+   * @param path a stress.json or a glob such as workloads/*.json
+   * @param strict refuse unknown fields and queries without a frequency or weight
+   * @return the config
+   * @throws IOException when a file is missing, nothing matches or the json is invalid
+   */
+  public static StressConfig read(final File path, final boolean strict) throws IOException {
+    if (!QueryLogFiles.isGlob(path.getPath())) {
+      return parse(path.toString(), Files.readAllBytes(path.toPath()), strict);
+    }
+    final List<File> files = QueryLogFiles.resolve(path);
+    if (files.isEmpty()) {
+      throw new IOException("no stress.json matches " + path);
+    }
+    logger.info(() -> String.format("merging %d stress.json files %s", files.size(), files));
+    final StressConfig merged = new StressConfig();
+    merged.setQueries(new ArrayList<>());
+    final Map<String, QueryGroup> queryGroups = new LinkedHashMap<>();
+    final Map<String, File> groupFiles = new LinkedHashMap<>();
+    final LinkedHashSet<String> sessionInit = new LinkedHashSet<>();
+    final List<UserRate> users = new ArrayList<>();
+    for (final File file : files) {
+      final StressConfig config = parse(file.toString(), Files.readAllBytes(file.toPath()), strict);
+      if (config.getQueries() != null) {
+        merged.getQueries().addAll(config.getQueries());
+      }
+      if (config.getQueryGroups() != null) {
+        for (final QueryGroup group : config.getQueryGroups()) {
+          final QueryGroup previous = queryGroups.putIfAbsent(group.getName(), group);
+          if (previous != null && !mapper.valueToTree(previous).equals(mapper.valueToTree(group))) {
+            throw new InvalidParameterException(
+                String.format(
+                    "query group %s is defined differently in %s and %s, rename one of them",
+                    group.getName(), groupFiles.get(group.getName()), file));
+          }
+          groupFiles.putIfAbsent(group.getName(), file);
+        }
+      }
+      if (config.getSessionInit() != null) {
+        sessionInit.addAll(config.getSessionInit());
+      }
+      if (config.getUsers() != null) {
+        users.addAll(config.getUsers());
+      }
+    }
+    // the defaults of each file were applied when it was parsed
+    merged.setQueryGroups(queryGroups.isEmpty() ? null : new ArrayList<>(queryGroups.values()));
+    merged.setSessionInit(sessionInit.isEmpty() ? null : new ArrayList<>(sessionInit));
+    merged.setUsers(users.isEmpty() ? null : users);
+    return merged;
+  }
+
+  /**
+   * parses a stress.json
+   *
+   * @param source name of the file used in error messages
+   * @param content the file as read from disk or stdin
+   * @return the config
+   * @throws IOException when the json is invalid, with the location and a snippet of the content
+   */
+  public static StressConfig parse(final String source, final byte[] content) throws IOException {
+    return parse(source, content, false);
+  }
+
+  /**
+   * parses a stress.json
+   *
+   * @param source name of the file used in error messages
+   * @param content the file as read from disk or stdin
+   * @param strict refuse unknown fields and queries without a frequency or weight, otherwise they
+   *     are logged as warnings
+   * @return the config
+   * @throws IOException when the json is invalid, with the location and a snippet of the content
+   * @throws InvalidParameterException when strict and a query has no frequency or weight
+   */
+  public static StressConfig parse(final String source, final byte[] content, final boolean strict)
+      throws IOException {
+    final int start = startsWithBom(content) ? bom.length : 0;
+    final byte[] normalized = normalize(content, start);
+    final StressConfig config;
+    try {
+      config =
+          strict
+              ? mapper.readValue(normalized, StressConfig.class)
+              : mapper
+                  .readerFor(StressConfig.class)
+                  .withHandler(new UnknownFieldWarning(source))
+                  .readValue(normalized);
+    } catch (JsonProcessingException e) {
+      throw new IOException(describe(source, e, content, normalized, start), e);
+    }
+    checkFrequencies(source, config, strict);
+    return applyDefaults(config);
+  }
+
+  /**
+   * a query without a frequency or weight runs at frequency 1, which is rarely what was meant when
+   * the field was misspelled or forgotten
+   */
+  private static void checkFrequencies(
+      final String source, final StressConfig config, final boolean strict) {
+    if (config.getQueries() == null
+        || (config.getDefaults() != null && config.getDefaults().getFrequency() != null)) {
+      return;
+    }
+    for (final QueryConfig q : config.getQueries()) {
+      if (q.getFrequency() != 0 || q.getWeight() != null) {
+        continue;
+      }
+      final String message =
+          String.format("%s: no frequency or weight for %s", source, StressExec.describe(q));
+      if (strict) {
+        throw new InvalidParameterException(message);
+      }
+      logger.warning(() -> message + ", running it with frequency 1");
+    }
+  }
+
+  /** logs the fields the config classes do not know and skips them */
+  private static final class UnknownFieldWarning extends DeserializationProblemHandler {
+    private final String source;
+
+    UnknownFieldWarning(final String source) {
+      this.source = source;
+    }
+
+    @Override
+    public boolean handleUnknownProperty(
+        final DeserializationContext ctxt,
+        final JsonParser p,
+        final JsonDeserializer<?> deserializer,
+        final Object beanOrClass,
+        final String propertyName)
+        throws IOException {
+      final JsonLocation location = p.getCurrentLocation();
+      final String path = p.getParsingContext().pathAsPointer().toString();
+      logger.warning(
+          () ->
+              String.format(
+                  "%s: unknown field %s at line %d, column %d ignored, --strict refuses it",
+                  source, path, location.getLineNr(), location.getColumnNr()));
+      p.skipChildren();
+      return true;
+    }
+  }
+
+  /**
+   * fills in the settings of the defaults block that queries and query groups leave out. The
+   * timeout goes to query groups rather than the queries referencing them so a group timeout is
+   * not overridden by the default.
+   */
+  private static StressConfig applyDefaults(final StressConfig config) {
+    final QueryDefaults defaults = config.getDefaults();
+    if (defaults == null) {
+      return config;
+    }
+    if (config.getQueries() != null) {
+      for (final QueryConfig q : config.getQueries()) {
+        if (q.getFrequency() == 0 && q.getWeight() == null && defaults.getFrequency() != null) {
+          q.setFrequency(defaults.getFrequency());
+        }
+        final boolean plainQuery = q.getQueryGroup() == null || q.getQueryGroup().isEmpty();
+        if (q.getTimeout() == null && plainQuery) {
+          q.setTimeout(defaults.getTimeout());
+        }
+        if (q.getSqlContext() == null) {
+          q.setSqlContext(defaults.getSqlContext());
+        }
+        if (defaults.getParameters() != null) {
+          final Map<String, List<Object>> parameters =
+              new LinkedHashMap<>(defaults.getParameters());
+          if (q.getParameters() != null) {
+            parameters.putAll(q.getParameters());
+          }
+          q.setParameters(parameters);
+        }
+      }
+    }
+    if (config.getQueryGroups() != null) {
+      for (final QueryGroup group : config.getQueryGroups()) {
+        if (group.getTimeout() == null) {
+          group.setTimeout(defaults.getTimeout());
+        }
+      }
+    }
+    return config;
+  }
+
+  private static boolean startsWithBom(final byte[] content) {
+    return content.length >= bom.length
+        && content[0] == bom[0]
+        && content[1] == bom[1]
+        && content[2] == bom[2];
+  }
+
+  private static byte[] normalize(final byte[] content, final int start) {
+    final ByteArrayOutputStream out = new ByteArrayOutputStream(content.length);
+    for (int i = start; i < content.length; i++) {
+      if (content[i] == '\r') {
+        out.write('\n');
+        if (i + 1 < content.length && content[i + 1] == '\n') {
+          i++;
+        }
+      } else {
+        out.write(content[i]);
+      }
+    }
+    return out.toByteArray();
+  }
+
+  private static String describe(
+      final String source,
+      final JsonProcessingException e,
+      final byte[] content,
+      final byte[] normalized,
+      final int start) {
+    final JsonLocation location = e.getLocation();
+    if (location == null || location.getLineNr() < 1) {
+      return String.format("unable to parse %s: %s", source, e.getOriginalMessage());
+    }
+    final int line = location.getLineNr();
+    final int column = Math.max(location.getColumnNr(), 1);
+    final StringBuilder message =
+        new StringBuilder(
+            String.format(
+                "unable to parse %s at line %d, column %d (byte offset %d): %s",
+                source,
+                line,
+                column,
+                originalOffset(content, start, location.getByteOffset()),
+                e.getOriginalMessage()));
+    final String[] lines = new String(normalized, StandardCharsets.UTF_8).split("\n", -1);
+    if (line <= lines.length) {
+      final String text = lines[line - 1].replace('\t', ' ');
+      final int from = Math.max(0, Math.min(column - 1, text.length()) - snippetWidth / 2);
+      final int to = Math.min(text.length(), from + snippetWidth);
+      message.append(System.lineSeparator()).append(String.format("%6d | ", line));
+      message.append(text, from, to).append(System.lineSeparator()).append("       | ");
+      for (int i = from; i < column - 1; i++) {
+        message.append(' ');
+      }
+      message.append('^');
+    }
+    return message.toString();
+  }
+
+  /** maps an offset in the normalized content back to the file, counting the dropped bytes */
+  private static long originalOffset(final byte[] content, final int start, final long offset) {
+    if (offset < 0) {
+      return offset;
+    }
+    int i = start;
+    long emitted = 0;
+    while (i < content.length && emitted < offset) {
+      if (content[i] == '\r' && i + 1 < content.length && content[i + 1] == '\n') {
+        i++;
+      }
+      i++;
+      emitted++;
+    }
+    return i;
+  }
+}
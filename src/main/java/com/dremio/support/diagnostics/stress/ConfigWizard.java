@@ -0,0 +1,185 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import java.io.BufferedReader;
+import java.io.File;
+import java.io.IOException;
+import java.io.PrintStream;
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.LinkedHashMap;
+import java.util.LinkedHashSet;
+import java.util.List;
+import java.util.Map;
+import java.util.Set;
+import java.util.regex.Matcher;
+import java.util.regex.Pattern;
+
+/**
+ * ConfigWizard scaffolds a stress.json from prompts or from a list of queries. Parameters are
+ * detected from the :name tokens of the queries and the result is validated the same way a run
+ * validates it, so the file works on the first try.
+ */
+public class ConfigWizard {
+
+  // :name tokens, :uniq is generated by the run and is not a parameter
+  private static final Pattern parameterToken = Pattern.compile("(?<![:\\w]):([A-Za-z_]\\w*)");
+
+  private final List<QueryConfig> queries = new ArrayList<>();
+
+  /**
+   * names of the parameters used by the query, in order of appearance
+   *
+   * @param sql the query text
+   * @return parameter names
+   */
+  public static Set<String> parameterNames(final String sql) {
+    final Set<String> names = new LinkedHashSet<>();
+    final Matcher matcher = parameterToken.matcher(sql);
+    while (matcher.find()) {
+      if (!"uniq".equals(matcher.group(1))) {
+        names.add(matcher.group(1));
+      }
+    }
+    return names;
+  }
+
+  /**
+   * converts a value typed by the user to the json type it most likely is, so numbers and booleans
+   * are rendered without quotes
+   *
+   * @param text the value as typed
+   * @return a Long, Double, Boolean or the trimmed text
+   */
+  public static Object parseValue(final String text) {
+    final String value = text.trim();
+    if ("true".equalsIgnoreCase(value) || "false".equalsIgnoreCase(value)) {
+      return Boolean.valueOf(value);
+    }
+    try {
+      return Long.valueOf(value);
+    } catch (NumberFormatException e) {
+      // not an integer
+    }
+    try {
+      return Double.valueOf(value);
+    } catch (NumberFormatException e) {
+      return value;
+    }
+  }
+
+  /**
+   * adds a query
+   *
+   * @param sql the query text
+   * @param frequency relative weight of the query
+   * @param parameters values per parameter name, may be empty
+   */
+  public void addQuery(
+      final String sql, final int frequency, final Map<String, List<Object>> parameters) {
+    final QueryConfig query = new QueryConfig();
+    query.setQuery(sql);
+    query.setFrequency(frequency);
+    if (!parameters.isEmpty()) {
+      query.setParameters(parameters);
+    }
+    addQuery(query);
+  }
+
+  /**
+   * adds a query that is already configured, such as one converted from a job history export
+   *
+   * @param query the query
+   */
+  public void addQuery(final QueryConfig query) {
+    queries.add(query);
+  }
+
+  /**
+   * prompts for queries, frequencies and parameter values until an empty query is entered
+   *
+   * @param in where the answers are read from
+   * @param out where the prompts are printed
+   * @throws IOException when unable to read the answers
+   */
+  public void prompt(final BufferedReader in, final PrintStream out) throws IOException {
+    out.println("enter the queries of the workload, an empty query finishes");
+    while (true) {
+      out.printf("query %d: ", queries.size() + 1);
+      final String sql = in.readLine();
+      if (sql == null || sql.trim().isEmpty()) {
+        return;
+      }
+      int frequency = 0;
+      while (frequency < 1) {
+        out.print("  frequency, relative to the other queries [1]: ");
+        final String answer = in.readLine();
+        try {
+          frequency =
+              answer == null || answer.trim().isEmpty() ? 1 : Integer.parseInt(answer.trim());
+        } catch (NumberFormatException e) {
+          frequency = 0;
+        }
+        if (frequency < 1) {
+          out.println("  the frequency must be a whole number of at least 1");
+        }
+      }
+      final Map<String, List<Object>> parameters = new LinkedHashMap<>();
+      for (final String name : parameterNames(sql)) {
+        List<Object> values = Collections.emptyList();
+        while (values.isEmpty()) {
+          out.printf("  values for :%s, comma separated: ", name);
+          final String answer = in.readLine();
+          if (answer == null) {
+            throw new IOException("input ended before the values of :" + name + " were entered");
+          }
+          values = new ArrayList<>();
+          for (final String value : answer.split(",")) {
+            if (!value.trim().isEmpty()) {
+              values.add(parseValue(value));
+            }
+          }
+          if (values.isEmpty()) {
+            out.println("  at least one value is needed");
+          }
+        }
+        parameters.put(name, values);
+      }
+      addQuery(sql.trim(), frequency, parameters);
+    }
+  }
+
+  /**
+   * validates and writes the stress.json
+   *
+   * @param file where to write the config
+   * @throws IOException when unable to write the file
+   * @throws java.security.InvalidParameterException when the config is not valid
+   */
+  public void write(final File file) throws IOException {
+    if (queries.isEmpty()) {
+      throw new IOException("no queries were entered");
+    }
+    StressExec.validateParameters(queries, Collections.emptyMap());
+    final StressConfig config = new StressConfig();
+    config.setQueries(queries);
+    final ObjectMapper mapper = new ObjectMapper();
+    // leave out the options that were not set so the file only shows what was entered
+    mapper.setSerializationInclusion(JsonInclude.Include.NON_DEFAULT);
+    mapper.writerWithDefaultPrettyPrinter().writeValue(file, config);
+  }
+}
@@ -14,9 +14,167 @@
 package com.dremio.support.diagnostics.stress;
 
 import java.io.IOException;
+import java.util.Collections;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Properties;
 
 public class ConnectDremioApi implements ConnectApi {
 
+  // rows per page when reading job results over HTTP or per fetch over JDBC, 0 does not read them
+  private final int resultsPageSize;
+  // ask for gzip compressed responses over HTTP
+  private final boolean gzip;
+  // sent with every HTTP request, such as the headers an auth proxy or gateway needs
+  private final Map<String, String> headers;
+  // how HTTP requests authenticate
+  private final HttpAuth httpAuth;
+  // adds a kerberos token to every HTTP request, null unless httpAuth is SPNEGO
+  private final Spnego spnego;
+  // signs in to Azure AD once for every connection, null unless httpAuth is AZURE_AD
+  private final AzureDeviceLogin azureLogin;
+  // qualifies users for LDAP and active directory logins, null keeps them as given
+  private final String ldapDomain;
+  // passed to the flight JDBC driver, such as the trust store
+  private final Properties jdbcProperties;
+  // shares HTTP logins between the connections, null logs in once per connection
+  private final TokenCache tokenCache;
+
+  public ConnectDremioApi() {
+    this(0);
+  }
+
+  /** @param resultsPageSize rows per HTTP page or JDBC fetch when reading results, 0 disables it */
+  public ConnectDremioApi(final int resultsPageSize) {
+    this(resultsPageSize, false);
+  }
+
+  /**
+   * @param resultsPageSize rows per HTTP page or JDBC fetch when reading results, 0 disables it
+   * @param gzip ask for gzip compressed responses over HTTP
+   */
+  public ConnectDremioApi(final int resultsPageSize, final boolean gzip) {
+    this(resultsPageSize, gzip, Collections.emptyMap(), HttpAuth.LOGIN, null, null, null);
+  }
+
+  /**
+   * @param resultsPageSize rows per HTTP page or JDBC fetch when reading results, 0 disables it
+   * @param gzip ask for gzip compressed responses over HTTP
+   * @param headers sent with every HTTP request
+   * @param httpAuth how HTTP requests authenticate
+   * @param spnego adds a kerberos token to every HTTP request, required when httpAuth is SPNEGO
+   * @param azureLogin provides the Azure AD token, required when httpAuth is AZURE_AD
+   * @param ldapDomain qualifies users without a domain for LDAP and active directory logins, null
+   *     keeps them as given
+   */
+  public ConnectDremioApi(
+      final int resultsPageSize,
+      final boolean gzip,
+      final Map<String, String> headers,
+      final HttpAuth httpAuth,
+      final Spnego spnego,
+      final AzureDeviceLogin azureLogin,
+      final String ldapDomain) {
+    this(
+        resultsPageSize,
+        gzip,
+        headers,
+        httpAuth,
+        spnego,
+        azureLogin,
+        ldapDomain,
+        new Properties());
+  }
+
+  /**
+   * @param resultsPageSize rows per HTTP page or JDBC fetch when reading results, 0 disables it
+   * @param gzip ask for gzip compressed responses over HTTP
+   * @param headers sent with every HTTP request
+   * @param httpAuth how HTTP requests authenticate
+   * @param spnego adds a kerberos token to every HTTP request, required when httpAuth is SPNEGO
+   * @param azureLogin provides the Azure AD token, required when httpAuth is AZURE_AD
+   * @param ldapDomain qualifies users without a domain for LDAP and active directory logins, null
+   *     keeps them as given
+   * @param jdbcProperties passed to the flight JDBC driver, the ones in the url win
+   */
+  public ConnectDremioApi(
+      final int resultsPageSize,
+      final boolean gzip,
+      final Map<String, String> headers,
+      final HttpAuth httpAuth,
+      final Spnego spnego,
+      final AzureDeviceLogin azureLogin,
+      final String ldapDomain,
+      final Properties jdbcProperties) {
+    this(
+        resultsPageSize,
+        gzip,
+        headers,
+        httpAuth,
+        spnego,
+        azureLogin,
+        ldapDomain,
+        jdbcProperties,
+        null);
+  }
+
+  /**
+   * @param resultsPageSize rows per HTTP page or JDBC fetch when reading results, 0 disables it
+   * @param gzip ask for gzip compressed responses over HTTP
+   * @param headers sent with every HTTP request
+   * @param httpAuth how HTTP requests authenticate
+   * @param spnego adds a kerberos token to every HTTP request, required when httpAuth is SPNEGO
+   * @param azureLogin provides the Azure AD token, required when httpAuth is AZURE_AD
+   * @param ldapDomain qualifies users without a domain for LDAP and active directory logins, null
+   *     keeps them as given
+   * @param jdbcProperties passed to the flight JDBC driver, the ones in the url win
+   * @param tokenCache shares HTTP logins between the connections, null logs in once per connection
+   */
+  public ConnectDremioApi(
+      final int resultsPageSize,
+      final boolean gzip,
+      final Map<String, String> headers,
+      final HttpAuth httpAuth,
+      final Spnego spnego,
+      final AzureDeviceLogin azureLogin,
+      final String ldapDomain,
+      final Properties jdbcProperties,
+      final TokenCache tokenCache) {
+    this.resultsPageSize = resultsPageSize;
+    this.gzip = gzip;
+    this.headers = headers;
+    this.httpAuth = httpAuth;
+    this.spnego = spnego;
+    this.azureLogin = azureLogin;
+    this.ldapDomain = ldapDomain;
+    this.jdbcProperties = jdbcProperties;
+    this.tokenCache = tokenCache;
+  }
+
+  /**
+   * parses headers given as Name: value
+   *
+   * @param headers the headers, null when none were given
+   * @return header values by name in the order given
+   * @throws IllegalArgumentException when a header has no name or no colon, the value is left out
+   *     of the message as it may be a secret
+   */
+  public static Map<String, String> parseHeaders(final List<String> headers) {
+    final Map<String, String> parsed = new LinkedHashMap<>();
+    if (headers == null) {
+      return parsed;
+    }
+    for (final String header : headers) {
+      final int colon = header.indexOf(':');
+      if (colon <= 0 || header.substring(0, colon).trim().isEmpty()) {
+        throw new IllegalArgumentException("headers must be given as 'Name: value'");
+      }
+      parsed.put(header.substring(0, colon).trim(), header.substring(colon + 1).trim());
+    }
+    return parsed;
+  }
+
   @Override
   public DremioApi connect(
       String username,
@@ -26,11 +184,21 @@ public class ConnectDremioApi implements ConnectApi {
       Protocol protocol,
       boolean ignoreSSL)
       throws IOException {
-    final UsernamePasswordAuth auth = new UsernamePasswordAuth(username, password);
+    final UsernamePasswordAuth auth =
+        new UsernamePasswordAuth(UsernamePasswordAuth.qualify(username, ldapDomain), password);
     if (protocol.equals(Protocol.HTTP)) {
-      HttpApiCall apiCall = new HttpApiCall(ignoreSSL);
-      return new DremioV3Api(apiCall, auth, host, timeoutSeconds);
+      HttpApiCall apiCall = new HttpApiCall(ignoreSSL, gzip, spnego);
+      return new DremioV3Api(
+          apiCall,
+          auth,
+          host,
+          timeoutSeconds,
+          resultsPageSize,
+          headers,
+          httpAuth,
+          azureLogin,
+          tokenCache);
     }
-    return new DremioArrowFlightJDBCDriver(host);
+    return new DremioArrowFlightJDBCDriver(host, timeoutSeconds, jdbcProperties, resultsPageSize);
   }
 }
@@ -0,0 +1,106 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.security.InvalidParameterException;
+
+/**
+ * ConnectionUrl checks the urls given on the command line before connecting, so typos and shell
+ * quoting mistakes fail right away with a readable message instead of a driver stack trace. The
+ * messages only ever contain the redacted url.
+ */
+public final class ConnectionUrl {
+
+  private static final String[] jdbcPrefixes = {"jdbc:arrow-flight-sql://", "jdbc:arrow-flight://"};
+  private static final String[] httpPrefixes = {"http://", "https://"};
+
+  private ConnectionUrl() {}
+
+  /**
+   * validates a single url, without the |weight of a coordinator
+   *
+   * @param url the url as given on the command line
+   * @param protocol protocol the url is used with
+   * @throws InvalidParameterException when the url cannot be connected to
+   */
+  public static void validate(final String url, final Protocol protocol) {
+    if (url == null || url.trim().isEmpty()) {
+      throw new InvalidParameterException("the url is empty");
+    }
+    final String redacted = Redact.url(url);
+    for (final char c : url.toCharArray()) {
+      if (c == '\n' || c == '\r' || c == '\t') {
+        throw new InvalidParameterException(
+            String.format(
+                "url '%s' contains a line break or tab, usually from a line continuation inside"
+                    + " the quotes, write the url on a single line",
+                redacted.replaceAll("[\\r\\n\\t]", "\\\\n")));
+      }
+    }
+    final boolean jdbc = protocol == Protocol.JDBC;
+    final String[] prefixes = jdbc ? jdbcPrefixes : httpPrefixes;
+    String rest = null;
+    for (final String prefix : prefixes) {
+      if (url.regionMatches(true, 0, prefix, 0, prefix.length())) {
+        rest = url.substring(prefix.length());
+      }
+    }
+    if (rest == null) {
+      throw new InvalidParameterException(
+          String.format(
+              "%s url '%s' must start with %s%s",
+              protocol,
+              redacted,
+              prefixes[0],
+              url.startsWith("\"") || url.startsWith("'")
+                  ? ", remove the quotes that ended up inside the value"
+                  : ""));
+    }
+    int end = rest.length();
+    for (final char c : new char[] {'/', '?', ';'}) {
+      final int index = rest.indexOf(c);
+      if (index >= 0 && index < end) {
+        end = index;
+      }
+    }
+    // user:password@ is allowed in http urls
+    final String authority = rest.substring(rest.lastIndexOf('@', end) + 1, end);
+    final int colon = authority.lastIndexOf(':');
+    final String host = colon < 0 ? authority : authority.substring(0, colon);
+    if (host.isEmpty()) {
+      throw new InvalidParameterException(String.format("url '%s' is missing the host", redacted));
+    }
+    if (colon < 0) {
+      if (jdbc) {
+        throw new InvalidParameterException(
+            String.format(
+                "url '%s' is missing the port, the arrow flight port of dremio is usually 32010",
+                redacted));
+      }
+      return;
+    }
+    final String port = authority.substring(colon + 1);
+    final int number;
+    try {
+      number = Integer.parseInt(port);
+    } catch (NumberFormatException e) {
+      throw new InvalidParameterException(
+          String.format("port of url '%s' must be a number but was '%s'", redacted, port));
+    }
+    if (number < 1 || number > 65535) {
+      throw new InvalidParameterException(
+          String.format("port of url '%s' must be between 1 and 65535", redacted));
+    }
+  }
+}
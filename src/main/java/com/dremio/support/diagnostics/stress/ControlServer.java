@@ -0,0 +1,152 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.sun.net.httpserver.HttpExchange;
+import com.sun.net.httpserver.HttpServer;
+import java.io.IOException;
+import java.io.OutputStream;
+import java.net.InetAddress;
+import java.net.InetSocketAddress;
+import java.nio.charset.StandardCharsets;
+import java.security.InvalidParameterException;
+import java.util.logging.Logger;
+
+/**
+ * ControlServer exposes a small http api so operators can control a running stress job.
+ *
+ * <ul>
+ *   <li>POST /pause - stop submitting queries
+ *   <li>POST /resume - resume submitting queries
+ *   <li>POST /stop - end the run and print the summary
+ *   <li>POST /annotate - add the request body as an annotation to the timeline of the run
+ *   <li>GET /status - current statistics as json
+ * </ul>
+ */
+public class ControlServer {
+
+  private static final Logger logger = Logger.getLogger(ControlServer.class.getName());
+
+  private final HttpServer server;
+  private final StressControl control;
+
+  /**
+   * @param address host:port to listen on
+   * @param control the run to control
+   * @throws IOException when unable to bind to the address
+   */
+  public ControlServer(final String address, final StressControl control) throws IOException {
+    this.control = control;
+    this.server = HttpServer.create(parseAddress(address), 0);
+    this.server.createContext("/pause", x -> handleAction(x, true));
+    this.server.createContext("/resume", x -> handleAction(x, false));
+    this.server.createContext("/stop", this::handleStop);
+    this.server.createContext("/annotate", this::handleAnnotate);
+    this.server.createContext("/status", this::handleStatus);
+  }
+
+  /**
+   * parses the host:port of the control, diagnostics and web endpoints. They have no
+   * authentication, so :port listens on the loopback interface and exposing them takes an explicit
+   * host such as 0.0.0.0
+   *
+   * @param address host:port or :port
+   * @return the address to listen on
+   */
+  static InetSocketAddress parseAddress(final String address) {
+    final int index = address.lastIndexOf(':');
+    if (index < 0) {
+      throw new InvalidParameterException(
+          String.format("address '%s' must be in the form host:port", address));
+    }
+    final String host = address.substring(0, index);
+    final int port = Integer.parseInt(address.substring(index + 1));
+    if (host.isEmpty()) {
+      return new InetSocketAddress(InetAddress.getLoopbackAddress(), port);
+    }
+    final InetSocketAddress parsed = new InetSocketAddress(host, port);
+    if (parsed.getAddress() != null && !parsed.getAddress().isLoopbackAddress()) {
+      logger.warning(
+          () ->
+              String.format(
+                  "%s is reachable from other machines and has no authentication, anyone reaching"
+                      + " it can stop the run",
+                  address));
+    }
+    return parsed;
+  }
+
+  /** starts listening in the background */
+  public void start() {
+    server.start();
+    logger.info(() -> String.format("control api listening on %s", server.getAddress()));
+  }
+
+  /** stops listening */
+  public void stop() {
+    server.stop(0);
+  }
+
+  private void handleAction(final HttpExchange exchange, final boolean pause) throws IOException {
+    if (!"POST".equals(exchange.getRequestMethod())) {
+      respond(exchange, 405, "{\"error\":\"POST required\"}");
+      return;
+    }
+    if (pause) {
+      control.pause();
+    } else {
+      control.resume();
+    }
+    handleStatus(exchange);
+  }
+
+  private void handleStop(final HttpExchange exchange) throws IOException {
+    if (!"POST".equals(exchange.getRequestMethod())) {
+      respond(exchange, 405, "{\"error\":\"POST required\"}");
+      return;
+    }
+    control.stop();
+    handleStatus(exchange);
+  }
+
+  private void handleAnnotate(final HttpExchange exchange) throws IOException {
+    if (!"POST".equals(exchange.getRequestMethod())) {
+      respond(exchange, 405, "{\"error\":\"POST required\"}");
+      return;
+    }
+    final String message =
+        new String(ConfigReader.readAll(exchange.getRequestBody()), StandardCharsets.UTF_8).trim();
+    if (message.isEmpty()) {
+      respond(exchange, 400, "{\"error\":\"the body must contain the annotation\"}");
+      return;
+    }
+    control.annotate(message);
+    handleStatus(exchange);
+  }
+
+  private void handleStatus(final HttpExchange exchange) throws IOException {
+    respond(exchange, 200, new ObjectMapper().writeValueAsString(control.status()));
+  }
+
+  private void respond(final HttpExchange exchange, final int code, final String body)
+      throws IOException {
+    final byte[] bytes = body.getBytes(StandardCharsets.UTF_8);
+    exchange.getResponseHeaders().add("Content-Type", "application/json");
+    exchange.sendResponseHeaders(code, bytes.length);
+    try (OutputStream stream = exchange.getResponseBody()) {
+      stream.write(bytes);
+    }
+  }
+}
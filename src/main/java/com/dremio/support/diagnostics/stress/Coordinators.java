@@ -0,0 +1,109 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.security.InvalidParameterException;
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.List;
+
+/**
+ * Coordinators spreads connections and statements over several coordinator urls like a load
+ * balancer in front of a client pool would. The urls are comma separated, each can end in |weight
+ * to receive a larger share, for example http://c1:9047|3,http://c2:9047. Picks use smooth
+ * weighted round robin so equal weights are plain round robin.
+ */
+public final class Coordinators {
+  private final List<String> urls;
+  private final int[] weights;
+  private final int[] current;
+  private final int totalWeight;
+
+  private Coordinators(final List<String> urls, final int[] weights) {
+    this.urls = Collections.unmodifiableList(urls);
+    this.weights = weights;
+    this.current = new int[weights.length];
+    int total = 0;
+    for (final int weight : weights) {
+      total += weight;
+    }
+    this.totalWeight = total;
+  }
+
+  /**
+   * parses the --url value
+   *
+   * @param value one url or a comma separated list of urls with optional |weight suffixes
+   * @return the coordinators
+   * @throws InvalidParameterException when a weight is not a positive number or no url is given
+   */
+  public static Coordinators parse(final String value) {
+    final List<String> urls = new ArrayList<>();
+    final List<Integer> weights = new ArrayList<>();
+    if (value != null) {
+      for (final String entry : value.split(",")) {
+        final String trimmed = entry.trim();
+        if (trimmed.isEmpty()) {
+          continue;
+        }
+        final int separator = trimmed.lastIndexOf('|');
+        if (separator < 0) {
+          urls.add(trimmed);
+          weights.add(1);
+          continue;
+        }
+        final String weight = trimmed.substring(separator + 1).trim();
+        try {
+          weights.add(Integer.parseInt(weight));
+        } catch (NumberFormatException e) {
+          throw new InvalidParameterException(
+              String.format("weight of %s must be a number but was '%s'", trimmed, weight));
+        }
+        if (weights.get(weights.size() - 1) < 1) {
+          throw new InvalidParameterException("weight must be at least 1 for " + trimmed);
+        }
+        urls.add(trimmed.substring(0, separator).trim());
+      }
+    }
+    if (urls.isEmpty()) {
+      throw new InvalidParameterException("no coordinator url given");
+    }
+    final int[] weightArray = new int[weights.size()];
+    for (int i = 0; i < weightArray.length; i++) {
+      weightArray[i] = weights.get(i);
+    }
+    return new Coordinators(urls, weightArray);
+  }
+
+  /** @return the coordinator urls in the order given */
+  public List<String> urls() {
+    return urls;
+  }
+
+  /** @return the coordinator that gets the next connection or statement */
+  public synchronized String next() {
+    if (urls.size() == 1) {
+      return urls.get(0);
+    }
+    int best = 0;
+    for (int i = 0; i < current.length; i++) {
+      current[i] += weights[i];
+      if (current[i] > current[best]) {
+        best = i;
+      }
+    }
+    current[best] -= totalWeight;
+    return urls.get(best);
+  }
+}
@@ -0,0 +1,59 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.IOException;
+
+/**
+ * CredentialProvider looks up the password of the user when the run starts, so scheduled runs do
+ * not need it on the command line, in a cron entry or in a config file.
+ */
+public interface CredentialProvider {
+
+  /**
+   * @param user the user the password is for, null when no user was given
+   * @return the password
+   * @throws IOException when the password cannot be read
+   */
+  String password(String user) throws IOException;
+
+  /**
+   * parses a password source
+   *
+   * @param source env:NAME, file:PATH, keychain:SERVICE or vault:PATH#FIELD
+   * @return the provider of the source
+   * @throws IllegalArgumentException when the source is none of them
+   */
+  static CredentialProvider parse(final String source) {
+    final int colon = source.indexOf(':');
+    final String value = colon < 0 ? "" : source.substring(colon + 1);
+    if (value.isEmpty()) {
+      throw new IllegalArgumentException(
+          "must be env:NAME, file:PATH, keychain:SERVICE or vault:PATH#FIELD");
+    }
+    switch (source.substring(0, colon)) {
+      case "env":
+        return new EnvCredentialProvider(value);
+      case "file":
+        return new FileCredentialProvider(value);
+      case "keychain":
+        return new KeychainCredentialProvider(value);
+      case "vault":
+        return new VaultCredentialProvider(value);
+      default:
+        throw new IllegalArgumentException(
+            "must be env:NAME, file:PATH, keychain:SERVICE or vault:PATH#FIELD");
+    }
+  }
+}
@@ -0,0 +1,78 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.sun.net.httpserver.HttpExchange;
+import com.sun.net.httpserver.HttpServer;
+import java.io.IOException;
+import java.io.OutputStream;
+import java.nio.charset.StandardCharsets;
+import java.util.logging.Logger;
+
+/**
+ * DiagnosticsServer exposes the state of the stress process itself over http, so a slow or
+ * saturated client can be told apart from a slow cluster.
+ *
+ * <ul>
+ *   <li>GET /debug/jvm - cpu, heap and gc statistics as json
+ *   <li>GET /debug/threads - thread dump of every thread
+ * </ul>
+ */
+public class DiagnosticsServer {
+
+  private static final Logger logger = Logger.getLogger(DiagnosticsServer.class.getName());
+
+  private final HttpServer server;
+
+  /**
+   * @param address host:port to listen on
+   * @throws IOException when unable to bind to the address
+   */
+  public DiagnosticsServer(final String address) throws IOException {
+    this.server = HttpServer.create(ControlServer.parseAddress(address), 0);
+    this.server.createContext("/debug/jvm", this::handleJvm);
+    this.server.createContext("/debug/threads", this::handleThreads);
+  }
+
+  /** starts listening in the background */
+  public void start() {
+    server.start();
+    logger.info(() -> String.format("diagnostics listening on %s", server.getAddress()));
+  }
+
+  /** stops listening */
+  public void stop() {
+    server.stop(0);
+  }
+
+  private void handleJvm(final HttpExchange exchange) throws IOException {
+    final String body = new ObjectMapper().writeValueAsString(ClientStats.snapshot());
+    respond(exchange, "application/json", body);
+  }
+
+  private void handleThreads(final HttpExchange exchange) throws IOException {
+    respond(exchange, "text/plain", ClientStats.threadDump());
+  }
+
+  private void respond(final HttpExchange exchange, final String contentType, final String body)
+      throws IOException {
+    final byte[] bytes = body.getBytes(StandardCharsets.UTF_8);
+    exchange.getResponseHeaders().add("Content-Type", contentType);
+    exchange.sendResponseHeaders(200, bytes.length);
+    try (OutputStream stream = exchange.getResponseBody()) {
+      stream.write(bytes);
+    }
+  }
+}
@@ -0,0 +1,389 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.io.IOException;
+import java.io.PrintStream;
+import java.lang.management.ManagementFactory;
+import java.net.ConnectException;
+import java.net.InetSocketAddress;
+import java.net.Socket;
+import java.net.SocketTimeoutException;
+import java.net.URI;
+import java.net.UnknownHostException;
+import java.security.CodeSource;
+import java.sql.Driver;
+import java.sql.DriverManager;
+import java.sql.SQLException;
+import java.util.List;
+import java.util.logging.Level;
+import java.util.logging.Logger;
+
+/**
+ * Doctor checks what a run needs before it starts: the java runtime, the JDBC drivers, that the
+ * coordinators can be reached, that the user can log in, the clock of this machine against the
+ * coordinator and that the output files can be written. Every failed check prints what to change,
+ * so "it does not start" can be solved without reading a stack trace.
+ */
+public class Doctor {
+
+  private static final Logger logger = Logger.getLogger(Doctor.class.getName());
+  // skew above which the times of the results no longer line up with the profiles and server logs
+  private static final long maxSkewMS = 5000;
+  private static final String flightDriver = "org.apache.arrow.driver.jdbc.ArrowFlightJdbcDriver";
+  private static final String sqliteDriver = "org.sqlite.JDBC";
+
+  /** connects the way the run would */
+  public interface Connector {
+    /**
+     * @return the connected api
+     * @throws IOException when unable to connect
+     */
+    DremioApi connect() throws IOException;
+  }
+
+  private final Protocol protocol;
+  private final String url;
+  private final int timeoutSeconds;
+  private final Connector connector;
+  private final List<File> outputs;
+  private final boolean resultsDb;
+  private int failures;
+  private int warnings;
+
+  /**
+   * @param protocol protocol of the run
+   * @param url HTTP url or JDBC connection string, several coordinators separated with commas
+   * @param timeoutSeconds how long to wait for a coordinator to answer
+   * @param connector connects and logs in with the options of the run
+   * @param outputs files and directories the run writes, null entries are skipped
+   * @param resultsDb whether results are stored in sqlite, which needs its JDBC driver
+   */
+  public Doctor(
+      final Protocol protocol,
+      final String url,
+      final int timeoutSeconds,
+      final Connector connector,
+      final List<File> outputs,
+      final boolean resultsDb) {
+    this.protocol = protocol;
+    this.url = url;
+    this.timeoutSeconds = timeoutSeconds;
+    this.connector = connector;
+    this.outputs = outputs;
+    this.resultsDb = resultsDb;
+  }
+
+  /**
+   * runs every check and prints the result and remediation of each
+   *
+   * @param out where to print the checks
+   * @return 0 when nothing failed, 1 otherwise
+   */
+  public int run(final PrintStream out) {
+    java(out);
+    drivers(out);
+    if (url == null || url.isEmpty()) {
+      fail(out, "url", "no url given", "pass -l with the HTTP url or JDBC connection string");
+    } else if (protocol == Protocol.HTTP && url.startsWith("jdbc:")) {
+      fail(out, "url", "a JDBC connection string with --protocol HTTP", "pass --protocol JDBC");
+    } else if (protocol == Protocol.JDBC && !url.startsWith("jdbc:")) {
+      fail(
+          out,
+          "url",
+          "an HTTP url with --protocol JDBC",
+          "pass --protocol HTTP, or a jdbc:arrow-flight-sql://host:32010 connection string");
+    } else if (reachable(out)) {
+      final DremioApi api = login(out);
+      if (api != null) {
+        clock(out, api);
+        api.close();
+      }
+    }
+    outputs(out);
+    out.printf("%d failed, %d warnings%n", failures, warnings);
+    return failures == 0 ? 0 : 1;
+  }
+
+  private void java(final PrintStream out) {
+    final String version = System.getProperty("java.specification.version");
+    ok(out, "java", version + " from " + System.getProperty("java.vendor"));
+    // arrow reads the memory of direct buffers, which java 16 and later no longer allow by default
+    if (protocol == Protocol.JDBC
+        && !version.startsWith("1.")
+        && Integer.parseInt(version) >= 16
+        && !String.valueOf(ManagementFactory.getRuntimeMXBean().getInputArguments())
+            .contains("java.nio")) {
+      warn(
+          out,
+          "java",
+          "the flight driver fails with 'Failed to initialize MemoryUtil' on java 16 and later",
+          "start with java --add-opens=java.base/java.nio=ALL-UNNAMED -jar dremio-stress.jar,"
+              + " or set it in JDK_JAVA_OPTIONS");
+    }
+  }
+
+  private void drivers(final PrintStream out) {
+    // dremio-stress connects over HTTP or flight, there is no ODBC driver or driver manager
+    if (protocol == Protocol.JDBC) {
+      driver(
+          out,
+          "flight driver",
+          flightDriver,
+          "jdbc:arrow-flight-sql://localhost:32010",
+          "the Arrow Flight JDBC driver jar is missing, run the jar-with-dependencies build or,"
+              + " when building from source, keep lib/flight-sql-jdbc-driver-10.0.0.jar so maven"
+              + " installs it");
+    }
+    if (resultsDb) {
+      driver(
+          out,
+          "sqlite driver",
+          sqliteDriver,
+          "jdbc:sqlite::memory:",
+          "run the jar-with-dependencies build, the plain jar does not bundle the drivers");
+    }
+  }
+
+  private void driver(
+      final PrintStream out,
+      final String check,
+      final String className,
+      final String sample,
+      final String missing) {
+    final Class<?> driverClass;
+    try {
+      driverClass = Class.forName(className);
+    } catch (ClassNotFoundException e) {
+      fail(out, check, String.format("%s is not on the class path", className), missing);
+      return;
+    }
+    final CodeSource source = driverClass.getProtectionDomain().getCodeSource();
+    final String jar = source == null ? "the class path" : String.valueOf(source.getLocation());
+    try {
+      final Driver driver = DriverManager.getDriver(sample);
+      ok(
+          out,
+          check,
+          String.format(
+              "%s %d.%d from %s",
+              driver.getClass().getName(),
+              driver.getMajorVersion(),
+              driver.getMinorVersion(),
+              jar));
+    } catch (SQLException e) {
+      fail(
+          out,
+          check,
+          String.format(
+              "%s from %s does not accept %s: %s", className, jar, sample, e.getMessage()),
+          "remove other versions of the driver from the class path");
+    }
+  }
+
+  private boolean reachable(final PrintStream out) {
+    boolean reachable = true;
+    for (final String coordinator : Coordinators.parse(url).urls()) {
+      final String redacted = Redact.url(coordinator);
+      final InetSocketAddress address;
+      try {
+        address = address(coordinator);
+      } catch (IllegalArgumentException e) {
+        fail(
+            out,
+            "connectivity",
+            String.format("unable to read %s: %s", redacted, e.getMessage()),
+            "use http(s)://host:9047 for HTTP or jdbc:arrow-flight-sql://host:32010 for JDBC");
+        reachable = false;
+        continue;
+      }
+      try (Socket socket = new Socket()) {
+        socket.connect(address, timeoutSeconds * 1000);
+        ok(out, "connectivity", redacted + " accepts connections");
+      } catch (UnknownHostException e) {
+        fail(
+            out,
+            "connectivity",
+            String.format("unable to resolve %s", address.getHostString()),
+            "check the host name, inside a container use the service name or host.docker.internal"
+                + " instead of localhost");
+        reachable = false;
+      } catch (ConnectException e) {
+        fail(
+            out,
+            "connectivity",
+            String.format("%s refused the connection: %s", redacted, e.getMessage()),
+            "check that dremio runs and the port, 9047 serves HTTP and 32010 serves flight");
+        reachable = false;
+      } catch (SocketTimeoutException e) {
+        fail(
+            out,
+            "connectivity",
+            String.format("%s did not answer within %d seconds", redacted, timeoutSeconds),
+            "check firewalls, security groups and the HTTPS_PROXY of this machine");
+        reachable = false;
+      } catch (IOException e) {
+        fail(
+            out,
+            "connectivity",
+            String.format("unable to connect to %s: %s", redacted, e.getMessage()),
+            "check the url and the network between this machine and dremio");
+        reachable = false;
+      }
+    }
+    return reachable;
+  }
+
+  /**
+   * host and port of a coordinator
+   *
+   * @param coordinator HTTP url or JDBC connection string
+   * @return the address to connect to
+   */
+  static InetSocketAddress address(final String coordinator) {
+    final URI uri =
+        URI.create(coordinator.startsWith("jdbc:") ? coordinator.substring(5) : coordinator);
+    if (uri.getHost() == null) {
+      throw new IllegalArgumentException("there is no host");
+    }
+    int port = uri.getPort();
+    if (port == -1) {
+      if ("https".equals(uri.getScheme())) {
+        port = 443;
+      } else if ("http".equals(uri.getScheme())) {
+        port = 80;
+      } else {
+        port = 32010;
+      }
+    }
+    // resolves the host now, an unknown host stays unresolved and fails to connect
+    return new InetSocketAddress(uri.getHost(), port);
+  }
+
+  private DremioApi login(final PrintStream out) {
+    try {
+      final DremioApi api = connector.connect();
+      ok(out, "auth", "logged in to " + Redact.url(api.getUrl()));
+      return api;
+    } catch (IOException | RuntimeException e) {
+      logger.log(Level.FINE, "login failed", e);
+      final String message = String.valueOf(e.getMessage());
+      final String fix;
+      if (message.contains("PKIX") || message.contains("SSL") || message.contains("certificate")) {
+        fix =
+            "trust the certificate of dremio with --jdbc-trust-store for JDBC, or pass"
+                + " --http-skip-ssl-verification for HTTP";
+      } else if (message.contains("401")
+          || message.contains("403")
+          || message.toLowerCase().contains("auth")) {
+        fix =
+            "check -u and -p or --password-source, and --ldap-domain when dremio logs in against"
+                + " LDAP";
+      } else {
+        fix = "rerun with -vv for the full error";
+      }
+      fail(out, "auth", "unable to log in: " + message, fix);
+      return null;
+    }
+  }
+
+  private void clock(final PrintStream out, final DremioApi api) {
+    try {
+      final long before = System.currentTimeMillis();
+      final DremioApiResponse response =
+          api.runSQL("SELECT UNIX_TIMESTAMP() AS now", SqlContext.parse(null));
+      final long after = System.currentTimeMillis();
+      if (!response.isSuccessful()) {
+        warn(
+            out,
+            "clock",
+            "unable to read the clock of dremio: " + response.getErrorMessage(),
+            "grant the user permission to run queries");
+        return;
+      }
+      final long serverMS =
+          Long.parseLong(String.valueOf(api.fetchFirstValue(response)).trim()) * 1000;
+      // the server answers somewhere between the two readings and only has second precision
+      final long skewMS = serverMS - (before + after) / 2;
+      final long toleranceMS = (after - before) / 2 + 1000;
+      if (Math.abs(skewMS) <= maxSkewMS + toleranceMS) {
+        ok(out, "clock", String.format("%d ms from dremio", skewMS));
+      } else {
+        warn(
+            out,
+            "clock",
+            String.format(
+                "this machine is %s %s dremio, result times will not match the profiles",
+                Human.getHumanDurationFromMillis(Math.abs(skewMS)),
+                skewMS > 0 ? "behind" : "ahead of"),
+            "sync this machine with NTP (timedatectl set-ntp true, w32tm /resync), agents started"
+                + " with --start-at need it too");
+      }
+    } catch (IOException | RuntimeException e) {
+      warn(
+          out,
+          "clock",
+          "unable to read the clock of dremio: " + e.getMessage(),
+          "rerun with -vv for the full error");
+    }
+  }
+
+  private void outputs(final PrintStream out) {
+    for (final File output : outputs) {
+      if (output == null) {
+        continue;
+      }
+      final File absolute = output.getAbsoluteFile();
+      // a file that does not exist yet is created in the closest directory that does
+      File existing = absolute;
+      while (existing != null && !existing.exists()) {
+        existing = existing.getParentFile();
+      }
+      if (existing == null) {
+        fail(out, "outputs", absolute + " has no existing parent", "use another path");
+      } else if (existing.equals(absolute) && absolute.isFile() && !absolute.canWrite()) {
+        fail(
+            out,
+            "outputs",
+            absolute + " is not writable",
+            "change its permissions or point the option at another file");
+      } else if (existing.isDirectory() && !existing.canWrite()) {
+        fail(
+            out,
+            "outputs",
+            String.format("%s is not writable, unable to create %s", existing, absolute),
+            "in a container mount a writable volume there, or point the option at one");
+      } else {
+        ok(out, "outputs", absolute + " is writable");
+      }
+    }
+  }
+
+  private void ok(final PrintStream out, final String check, final String detail) {
+    out.printf("ok    %s: %s%n", check, detail);
+  }
+
+  private void warn(
+      final PrintStream out, final String check, final String detail, final String fix) {
+    warnings++;
+    out.printf("WARN  %s: %s%n      fix: %s%n", check, detail, fix);
+  }
+
+  private void fail(
+      final PrintStream out, final String check, final String detail, final String fix) {
+    failures++;
+    out.printf("FAIL  %s: %s%n      fix: %s%n", check, detail, fix);
+  }
+}
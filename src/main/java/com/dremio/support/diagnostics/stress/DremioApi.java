@@ -13,8 +13,10 @@
  */
 package com.dremio.support.diagnostics.stress;
 
+import java.io.File;
 import java.io.IOException;
-import java.util.Collection;
+import java.util.List;
+import java.util.Map;
 
 public interface DremioApi {
 
@@ -22,12 +24,114 @@ public interface DremioApi {
    * runs a sql statement against the rest API
    *
    * @param sql sql string to submit to dremio
-   * @param context conext list to use with the query
+   * @param context context path to run the query in
    * @return the result of the job
    * @throws IOException occurs when the underlying apiCall does, typically a problem with handling
    *     of the body
    */
-  DremioApiResponse runSQL(String sql, Collection<String> table) throws IOException;
+  DremioApiResponse runSQL(String sql, SqlContext context) throws IOException;
+
+  /**
+   * runs a sql statement giving up after the given timeout instead of the one of the connection
+   *
+   * @param sql sql string to submit to dremio
+   * @param context context path to run the query in
+   * @param timeoutMS how long to wait for the statement, null uses the timeout of the connection
+   * @return the result of the job
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  DremioApiResponse runSQL(String sql, SqlContext context, Long timeoutMS) throws IOException;
+
+  /**
+   * runs a sql statement on a Dremio Cloud engine
+   *
+   * @param sql sql string to submit to dremio
+   * @param context context path to run the query in
+   * @param timeoutMS how long to wait for the statement, null uses the timeout of the connection
+   * @param engine name of the engine, null leaves the choice to the routing rules
+   * @return the result of the job
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  DremioApiResponse runSQL(String sql, SqlContext context, Long timeoutMS, String engine)
+      throws IOException;
+
+  /**
+   * runs a sql statement as a prepared statement binding the parameters to its ? placeholders
+   *
+   * @param sql sql string with ? placeholders to submit to dremio
+   * @param parameters values bound to the placeholders in order
+   * @param context context path to run the query in
+   * @return the result of the job
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  DremioApiResponse runPreparedSQL(String sql, List<Object> parameters, SqlContext context)
+      throws IOException;
+
+  /**
+   * runs a prepared statement giving up after the given timeout instead of the one of the
+   * connection
+   *
+   * @param sql sql string with ? placeholders to submit to dremio
+   * @param parameters values bound to the placeholders in order
+   * @param context context path to run the query in
+   * @param timeoutMS how long to wait for the statement, null uses the timeout of the connection
+   * @return the result of the job
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  DremioApiResponse runPreparedSQL(
+      String sql, List<Object> parameters, SqlContext context, Long timeoutMS) throws IOException;
+
+  /**
+   * calls the catalog rest api
+   *
+   * @param operation the catalog call to make
+   * @param path path of the entity, not used when listing the catalog
+   * @param format format of the dataset when promoting, for example {"type": "Parquet"}
+   * @return the result of the call, failed when the api returned an error
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  DremioApiResponse runCatalog(
+      CatalogOperation operation, List<String> path, Map<String, Object> format)
+      throws IOException;
+
+  /**
+   * reads the first column of the first row of a successful statement, used to capture values
+   * into variables for later steps of a query group
+   *
+   * @param response response of the statement
+   * @return the value or null when there were no rows
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  Object fetchFirstValue(DremioApiResponse response) throws IOException;
+
+  /**
+   * cancels every statement currently running through this api, used when the run deadline is hit
+   * so long running queries do not keep running on dremio after the run ends
+   *
+   * @return number of statements cancelled
+   */
+  int cancelInFlight();
+
+  /**
+   * downloads the profile of a job, kept with the artifacts of the run for failed jobs
+   *
+   * @param jobId job to download the profile of
+   * @param file where to write the profile zip
+   * @return true when written, false when the api has no profile download or the job is unknown
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  boolean downloadProfile(String jobId, File file) throws IOException;
+
+  /**
+   * checks the connection can still run statements, for example that its login has not expired,
+   * called periodically during the run
+   *
+   * @return true when the connection is healthy
+   */
+  boolean healthCheck();
+
+  /** releases the connection, statements can no longer be run afterwards */
+  void close();
 
   /**
    * The http URL for the dremio server
@@ -13,12 +13,18 @@
  */
 package com.dremio.support.diagnostics.stress;
 
+import java.util.Map;
 import java.util.Objects;
 
 /** api call response */
 public class DremioApiResponse {
   private String errorMessage;
   private boolean created;
+  private String jobId;
+  private Object firstValue;
+  private Map<String, Long> phases;
+  private Boolean accelerated;
+  private Long firstRowMS;
 
   /**
    * sets the error message on the response
@@ -56,16 +62,90 @@ public class DremioApiResponse {
     return errorMessage;
   }
 
+  /**
+   * id of the job that ran the statement, only available over HTTP
+   *
+   * @return job id or null
+   */
+  public String getJobId() {
+    return jobId;
+  }
+
+  public void setJobId(final String jobId) {
+    this.jobId = jobId;
+  }
+
+  /**
+   * first column of the first row of the result, only populated by engines that read results as
+   * part of executing the statement
+   *
+   * @return the value or null
+   */
+  public Object getFirstValue() {
+    return firstValue;
+  }
+
+  public void setFirstValue(final Object firstValue) {
+    this.firstValue = firstValue;
+  }
+
+  /**
+   * time in milliseconds the job spent in each phase (planning, queued, execution), only available
+   * over HTTP where the job api reports the phase timestamps
+   *
+   * @return phase name to milliseconds or null
+   */
+  public Map<String, Long> getPhases() {
+    return phases;
+  }
+
+  public void setPhases(final Map<String, Long> phases) {
+    this.phases = phases;
+  }
+
+  /**
+   * whether a reflection was chosen to accelerate the job, only available over HTTP
+   *
+   * @return true when accelerated, null when unknown
+   */
+  public Boolean getAccelerated() {
+    return accelerated;
+  }
+
+  public void setAccelerated(final Boolean accelerated) {
+    this.accelerated = accelerated;
+  }
+
+  /**
+   * milliseconds from submitting the statement to receiving the first row, only known when the
+   * engine reads the results: the first page over HTTP, the first row over JDBC
+   *
+   * @return milliseconds or null when the results were not read
+   */
+  public Long getFirstRowMS() {
+    return firstRowMS;
+  }
+
+  public void setFirstRowMS(final Long firstRowMS) {
+    this.firstRowMS = firstRowMS;
+  }
+
   @Override
   public boolean equals(Object o) {
     if (this == o) return true;
     if (!(o instanceof DremioApiResponse)) return false;
     DremioApiResponse that = (DremioApiResponse) o;
-    return created == that.created && Objects.equals(errorMessage, that.errorMessage);
+    return created == that.created
+        && Objects.equals(errorMessage, that.errorMessage)
+        && Objects.equals(jobId, that.jobId)
+        && Objects.equals(firstValue, that.firstValue)
+        && Objects.equals(phases, that.phases)
+        && Objects.equals(accelerated, that.accelerated)
+        && Objects.equals(firstRowMS, that.firstRowMS);
   }
 
   @Override
   public int hashCode() {
-    return Objects.hash(errorMessage, created);
+    return Objects.hash(errorMessage, created, jobId, firstValue, phases, accelerated, firstRowMS);
   }
 }
@@ -13,81 +13,510 @@
  */
 package com.dremio.support.diagnostics.stress;
 
+import java.io.File;
 import java.io.IOException;
+import java.io.UnsupportedEncodingException;
+import java.net.URLDecoder;
 import java.sql.Connection;
 import java.sql.DriverManager;
+import java.sql.PreparedStatement;
+import java.sql.ResultSet;
 import java.sql.SQLException;
-import java.util.Collection;
+import java.sql.Statement;
+import java.time.Duration;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Map;
+import java.util.Properties;
+import java.util.Set;
+import java.util.concurrent.ConcurrentHashMap;
+import java.util.concurrent.Executors;
+import java.util.concurrent.ScheduledExecutorService;
+import java.util.concurrent.ScheduledFuture;
+import java.util.concurrent.TimeUnit;
+import java.util.concurrent.atomic.AtomicBoolean;
 import java.util.logging.Logger;
 
 public class DremioArrowFlightJDBCDriver implements DremioApi {
 
   private static final Logger logger =
       Logger.getLogger(DremioArrowFlightJDBCDriver.class.getName());
+  // cancels statements at their deadline, as a wedged server can keep the driver waiting forever
+  private static final ScheduledExecutorService deadlines =
+      Executors.newSingleThreadScheduledExecutor(
+          r -> {
+            final Thread thread = new Thread(r, "jdbc-deadlines");
+            thread.setDaemon(true);
+            return thread;
+          });
   private final Connection connection;
+  // timeout of statements without one of their own, null waits for the statement
+  private final Integer timeoutSeconds;
+  // rows per fetch when reading every row of the results, 0 reads only the first row
+  private final int fetchSize;
+  // the connection has one context shared by every worker, statements in the current context run
+  // concurrently and a switch waits until they are done so no statement runs in the wrong context
   private final Object currentContextLock = new Object();
-  private String currentContext = "";
+  // context of the schema property of the url, statements without a context are run in it
+  private final SqlContext defaultContext;
+  private SqlContext currentContext;
+  // statements running in the current context
+  private int runningInContext;
+  // context a worker is waiting to switch to, statements of the current context wait behind it
+  private SqlContext nextContext;
+  // statements currently executing, so they can be cancelled at the deadline
+  private final Set<Statement> inFlight = ConcurrentHashMap.newKeySet();
 
   public DremioArrowFlightJDBCDriver(String url) {
+    this(url, null);
+  }
+
+  /**
+   * @param url jdbc url of the flight endpoint
+   * @param timeoutSeconds timeout of statements without one of their own, null waits for them
+   */
+  public DremioArrowFlightJDBCDriver(String url, Integer timeoutSeconds) {
+    this(url, timeoutSeconds, new Properties());
+  }
+
+  /**
+   * @param url jdbc url of the flight endpoint
+   * @param timeoutSeconds timeout of statements without one of their own, null waits for them
+   * @param properties driver properties such as the trust store, the ones in the url win
+   */
+  public DremioArrowFlightJDBCDriver(String url, Integer timeoutSeconds, Properties properties) {
+    this(url, timeoutSeconds, properties, 0);
+  }
+
+  /**
+   * @param url jdbc url of the flight endpoint
+   * @param timeoutSeconds timeout of statements without one of their own, null waits for them
+   * @param properties driver properties such as the trust store, the ones in the url win
+   * @param fetchSize rows per fetch when reading every row of the results like an export, 0 reads
+   *     only the first row
+   */
+  public DremioArrowFlightJDBCDriver(
+      String url, Integer timeoutSeconds, Properties properties, int fetchSize) {
+    this.timeoutSeconds = timeoutSeconds;
+    this.fetchSize = fetchSize;
+    this.defaultContext = defaultContext(url, properties);
+    this.currentContext = defaultContext;
     try {
       Class.forName("org.apache.arrow.driver.jdbc.ArrowFlightJdbcDriver");
     } catch (ClassNotFoundException e) {
       throw new RuntimeException(e);
     }
     try {
-      connection = DriverManager.getConnection(url);
+      connection = DriverManager.getConnection(url, properties);
       // use con here
     } catch (SQLException e) {
-      throw new RuntimeException(e);
+      // the driver message can echo the url, keep the credentials out of the logs
+      throw new RuntimeException(
+          String.format(
+              "unable to connect to %s: %s", Redact.url(url), Redact.url(e.getMessage())));
+    }
+  }
+
+  /**
+   * the context a connection starts in, from the schema property of the url or of the properties
+   *
+   * @param url jdbc url of the flight endpoint
+   * @param properties driver properties, the ones in the url win
+   * @return the context, empty when no schema is given
+   */
+  public static SqlContext defaultContext(final String url, final Properties properties) {
+    final int query = url.indexOf('?');
+    if (query != -1) {
+      for (final String pair : url.substring(query + 1).split("&")) {
+        final int equals = pair.indexOf('=');
+        if (equals != -1 && "schema".equalsIgnoreCase(pair.substring(0, equals))) {
+          try {
+            return SqlContext.parseSqlPath(URLDecoder.decode(pair.substring(equals + 1), "UTF-8"));
+          } catch (UnsupportedEncodingException e) {
+            throw new IllegalStateException(e);
+          }
+        }
+      }
     }
+    return properties == null
+        ? SqlContext.empty()
+        : SqlContext.parseSqlPath(properties.getProperty("schema"));
   }
 
   /**
    * runs a sql statement over jdbc
    *
    * @param sql sql string to submit to dremio
-   * @param table
+   * @param context context path to run the query in, changed with a USE statement when it differs
+   *     from the current context of the connection
    * @return the result of the job
    * @throws IOException occurs when the underlying apiCall does, typically a problem with handling
    *     of the body
    */
   @Override
-  public DremioApiResponse runSQL(String sql, Collection<String> table) throws IOException {
-    final String context;
-    if (table == null) {
-      context = "";
-    } else {
-      context = String.join(".", table);
+  public DremioApiResponse runSQL(String sql, SqlContext context) throws IOException {
+    return runSQL(sql, context, null);
+  }
+
+  /**
+   * runs a sql statement over jdbc with a query timeout, the engine of a jdbc connection is picked
+   * by the routing rules so it is not used
+   *
+   * @param sql sql string to submit to dremio
+   * @param context context path to run the query in
+   * @param timeoutMS query timeout, null uses the timeout of the api
+   * @param engine not used
+   * @return the result of the job, failed when the timeout was hit
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  @Override
+  public DremioApiResponse runSQL(String sql, SqlContext context, Long timeoutMS, String engine)
+      throws IOException {
+    return runSQL(sql, context, timeoutMS);
+  }
+
+  /**
+   * runs a sql statement over jdbc with a query timeout
+   *
+   * @param sql sql string to submit to dremio
+   * @param context context path to run the query in
+   * @param timeoutMS query timeout, null uses the timeout of the api
+   * @return the result of the job, failed when the timeout was hit
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  @Override
+  public DremioApiResponse runSQL(String sql, SqlContext context, Long timeoutMS)
+      throws IOException {
+    enterContext(context);
+    try (Statement statement = connection.createStatement()) {
+      final Deadline deadline = startDeadline(statement, timeoutMS);
+      final Instant submitted = Instant.now();
+      final boolean hasResults;
+      try {
+        hasResults = statement.execute(sql);
+      } catch (SQLException e) {
+        if (deadline.hit()) {
+          return timeoutHit();
+        }
+        throw e;
+      } finally {
+        deadline.stop();
+      }
+      if (hasResults) {
+        return readResults(statement, submitted);
+      }
+      throw new RuntimeException("unhandled exception");
+    } catch (SQLException e) {
+      throw new RuntimeException(e);
+    } finally {
+      exitContext();
+    }
+  }
+
+  /**
+   * runs a sql statement over jdbc as a prepared statement
+   *
+   * @param sql sql string with ? placeholders to submit to dremio
+   * @param parameters values bound to the placeholders in order
+   * @param context context path to run the query in
+   * @return the result of the job
+   */
+  @Override
+  public DremioApiResponse runPreparedSQL(String sql, List<Object> parameters, SqlContext context) {
+    return runPreparedSQL(sql, parameters, context, null);
+  }
+
+  /**
+   * runs a sql statement over jdbc as a prepared statement with a query timeout
+   *
+   * @param sql sql string with ? placeholders to submit to dremio
+   * @param parameters values bound to the placeholders in order
+   * @param context context path to run the query in
+   * @param timeoutMS query timeout, null uses the timeout of the api
+   * @return the result of the job, failed when the timeout was hit
+   */
+  @Override
+  public DremioApiResponse runPreparedSQL(
+      String sql, List<Object> parameters, SqlContext context, Long timeoutMS) {
+    enterContext(context);
+    try (PreparedStatement statement = connection.prepareStatement(sql)) {
+      for (int i = 0; i < parameters.size(); i++) {
+        statement.setObject(i + 1, parameters.get(i));
+      }
+      final Deadline deadline = startDeadline(statement, timeoutMS);
+      final Instant submitted = Instant.now();
+      try {
+        statement.execute();
+      } catch (SQLException e) {
+        if (deadline.hit()) {
+          return timeoutHit();
+        }
+        throw e;
+      } finally {
+        deadline.stop();
+      }
+      return readResults(statement, submitted);
+    } catch (SQLException e) {
+      throw new RuntimeException(e);
+    } finally {
+      exitContext();
+    }
+  }
+
+  /**
+   * the catalog is only reachable through the rest api
+   *
+   * @param operation not used
+   * @param path not used
+   * @param format not used
+   * @return a failed response
+   */
+  @Override
+  public DremioApiResponse runCatalog(
+      CatalogOperation operation, List<String> path, Map<String, Object> format) {
+    final DremioApiResponse failed = new DremioApiResponse();
+    failed.setSuccessful(false);
+    failed.setErrorMessage("catalog operations are not supported over JDBC, use HTTP");
+    return failed;
+  }
+
+  /** cancels a statement from another thread when it runs past its timeout */
+  private final class Deadline {
+    private final Statement statement;
+    private final AtomicBoolean hit = new AtomicBoolean(false);
+    private final ScheduledFuture<?> cancel;
+
+    private Deadline(final Statement statement, final Long timeoutMS) {
+      this.statement = statement;
+      this.cancel =
+          timeoutMS == null
+              ? null
+              : deadlines.schedule(this::cancel, timeoutMS, TimeUnit.MILLISECONDS);
+    }
+
+    private void cancel() {
+      hit.set(true);
+      try {
+        statement.cancel();
+      } catch (SQLException e) {
+        logger.fine(() -> String.format("unable to cancel statement %s", e.getMessage()));
+      }
+    }
+
+    private boolean hit() {
+      return hit.get();
     }
+
+    private void stop() {
+      if (cancel != null) {
+        cancel.cancel(false);
+      }
+      inFlight.remove(statement);
+    }
+  }
+
+  /**
+   * tracks the statement and enforces its timeout. The query timeout of the driver is set as well,
+   * but it is only honored when the server answers, so the statement is also cancelled client side.
+   *
+   * @param statement statement about to execute
+   * @param timeoutMS timeout of the statement, null uses the timeout of the api
+   * @return the deadline to stop once the statement returns
+   * @throws SQLException when the driver rejects the query timeout
+   */
+  private Deadline startDeadline(final Statement statement, final Long timeoutMS)
+      throws SQLException {
+    final Long effectiveMS =
+        timeoutMS != null ? timeoutMS : timeoutSeconds == null ? null : timeoutSeconds * 1000L;
+    if (effectiveMS != null) {
+      statement.setQueryTimeout((int) Math.max(1, (effectiveMS + 999) / 1000));
+    }
+    inFlight.add(statement);
+    return new Deadline(statement, effectiveMS);
+  }
+
+  private static DremioApiResponse timeoutHit() {
+    final DremioApiResponse failed = new DremioApiResponse();
+    failed.setSuccessful(false);
+    failed.setErrorMessage("timeout hit");
+    return failed;
+  }
+
+  /**
+   * reads the first row of the results, and every other row when a fetch size is set, timing the
+   * first row from the submission of the statement
+   *
+   * @param statement the executed statement
+   * @param submitted when the statement was submitted
+   * @return a successful response with the first value and the time to the first row
+   * @throws SQLException when the results cannot be read
+   */
+  private DremioApiResponse readResults(final Statement statement, final Instant submitted)
+      throws SQLException {
+    final DremioApiResponse response = new DremioApiResponse();
+    response.setSuccessful(true);
+    try (ResultSet resultSet = statement.getResultSet()) {
+      if (resultSet == null) {
+        return response;
+      }
+      if (fetchSize > 0) {
+        resultSet.setFetchSize(fetchSize);
+      }
+      final boolean hasRow = resultSet.next();
+      response.setFirstRowMS(Duration.between(submitted, Instant.now()).toMillis());
+      if (hasRow) {
+        response.setFirstValue(resultSet.getObject(1));
+      }
+      if (fetchSize > 0 && hasRow) {
+        long rows = 1;
+        while (resultSet.next()) {
+          rows++;
+        }
+        final long read = rows;
+        logger.fine(() -> String.format("read %d rows", read));
+      }
+    }
+    return response;
+  }
+
+  /**
+   * the first value is read while executing the statement
+   *
+   * @param response response of the statement
+   * @return the first column of the first row or null when there were no rows
+   */
+  @Override
+  public Object fetchFirstValue(DremioApiResponse response) {
+    return response == null ? null : response.getFirstValue();
+  }
+
+  /**
+   * cancels the statements that are still executing
+   *
+   * @return number of statements cancelled
+   */
+  @Override
+  public int cancelInFlight() {
+    int cancelled = 0;
+    for (final Statement statement : new ArrayList<>(inFlight)) {
+      try {
+        statement.cancel();
+        cancelled++;
+      } catch (SQLException e) {
+        logger.fine(() -> String.format("unable to cancel statement %s", e.getMessage()));
+      }
+    }
+    return cancelled;
+  }
+
+  /**
+   * profiles are not available over arrow flight
+   *
+   * @param jobId job to download the profile of
+   * @param file where the profile would be written
+   * @return always false
+   */
+  @Override
+  public boolean downloadProfile(final String jobId, final File file) {
+    return false;
+  }
+
+  /**
+   * asks the driver whether the connection is still valid
+   *
+   * @return true when the connection is valid
+   */
+  @Override
+  public boolean healthCheck() {
+    try {
+      return connection.isValid(timeoutSeconds == null ? 0 : timeoutSeconds);
+    } catch (SQLException e) {
+      logger.fine(() -> String.format("health check failed %s", e.getMessage()));
+      return false;
+    }
+  }
+
+  /** closes the jdbc connection */
+  @Override
+  public void close() {
+    try {
+      connection.close();
+    } catch (SQLException e) {
+      logger.fine(() -> String.format("unable to close connection %s", e.getMessage()));
+    }
+  }
+
+  /**
+   * waits until the statement can run in its context, switching the context of the connection with
+   * a USE statement once no statement of another context is running. Statements without a context
+   * run in the schema of the url. Without a schema there is no USE to return to the root, so they
+   * are refused once another context was used instead of running in it.
+   *
+   * @param context context of the statement about to run
+   */
+  private void enterContext(final SqlContext context) {
+    final SqlContext target = context == null || context.isEmpty() ? defaultContext : context;
     synchronized (currentContextLock) {
-      if (!currentContext.equals(context)) {
-        currentContext = context;
-        logger.info(() -> String.format("changing context %s", context));
-        try {
-          if (!connection.createStatement().execute("USE " + context)) {
-            throw new RuntimeException("failed using USE");
+      if (target.isEmpty() && !currentContext.isEmpty()) {
+        throw new IllegalStateException(
+            String.format(
+                "a statement without a sqlContext would run in %s used by an earlier statement,"
+                    + " give every query a sqlContext or set schema in the JDBC url",
+                currentContext));
+      }
+      try {
+        while (!canEnter(target)) {
+          if (nextContext == null) {
+            nextContext = target;
           }
-          final boolean success = connection.createStatement().execute(sql);
-          if (!success) {
-            throw new RuntimeException("unhandled exception executing sql");
+          currentContextLock.wait();
+        }
+      } catch (InterruptedException e) {
+        Thread.currentThread().interrupt();
+        releaseNextContext(target);
+        throw new RuntimeException("interrupted waiting to change context to " + target, e);
+      }
+      // cleared before switching so a failed USE does not block the statements waiting behind it
+      releaseNextContext(target);
+      if (!target.isEmpty() && !currentContext.equals(target)) {
+        logger.info(() -> String.format("changing context %s", target));
+        try (Statement statement = connection.createStatement()) {
+          if (!statement.execute("USE " + target.toSqlPath())) {
+            throw new RuntimeException("failed using USE");
           }
-          final DremioApiResponse response = new DremioApiResponse();
-          response.setSuccessful(true);
-          return response;
         } catch (SQLException ex) {
           throw new RuntimeException(ex);
         }
+        currentContext = target;
       }
+      runningInContext++;
     }
-    try {
-      if (connection.createStatement().execute(sql)) {
-        final DremioApiResponse response = new DremioApiResponse();
-        response.setSuccessful(true);
-        return response;
+  }
+
+  private void releaseNextContext(final SqlContext target) {
+    if (target.equals(nextContext)) {
+      nextContext = null;
+      currentContextLock.notifyAll();
+    }
+  }
+
+  private boolean canEnter(final SqlContext target) {
+    if (target.isEmpty()) {
+      return true;
+    }
+    if (currentContext.equals(target)) {
+      return nextContext == null;
+    }
+    return runningInContext == 0 && (nextContext == null || nextContext.equals(target));
+  }
+
+  private void exitContext() {
+    synchronized (currentContextLock) {
+      runningInContext--;
+      if (runningInContext == 0) {
+        currentContextLock.notifyAll();
       }
-      throw new RuntimeException("unhandled exception");
-    } catch (SQLException e) {
-      throw new RuntimeException(e);
     }
   }
 
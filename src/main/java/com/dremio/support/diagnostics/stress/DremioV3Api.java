@@ -14,12 +14,17 @@
 package com.dremio.support.diagnostics.stress;
 
 import com.fasterxml.jackson.databind.ObjectMapper;
+import java.io.File;
 import java.io.IOException;
 import java.net.URL;
+import java.net.URLEncoder;
 import java.security.InvalidParameterException;
+import java.time.Duration;
 import java.time.Instant;
+import java.time.format.DateTimeParseException;
 import java.time.temporal.ChronoUnit;
 import java.util.*;
+import java.util.concurrent.ConcurrentHashMap;
 import java.util.logging.Logger;
 
 /** DremioApi business logic for interacting with the dremio rest api */
@@ -37,6 +42,17 @@ public class DremioV3Api implements DremioApi {
 
   private final int timeoutSeconds;
 
+  // rows requested per page when reading the results of a job, 0 does not read results
+  private final int resultsPageSize;
+
+  // jobs submitted and not yet finished, so they can be cancelled at the deadline
+  private final Set<String> inFlightJobs = ConcurrentHashMap.newKeySet();
+
+  // shares the login with the other connections, null when this connection logged in on its own
+  private final TokenCache tokenCache;
+  private final TokenCache.Login login;
+  private final String tokenKey;
+
   /**
    * DremioApi provides the business logic for making API calls. The constructor will connect to the
    * auth api, so we can store the auth token for subsequent requests.
@@ -52,15 +68,187 @@ public class DremioV3Api implements DremioApi {
    */
   public DremioV3Api(ApiCall apiCall, UsernamePasswordAuth auth, String baseUrl, int timeoutSeconds)
       throws IOException {
+    this(apiCall, auth, baseUrl, timeoutSeconds, 0);
+  }
+
+  /**
+   * DremioApi that also pages through the results of every completed job, stressing the result
+   * serving path that BI tools exercise
+   *
+   * @param apiCall implementation that makes the http calls
+   * @param auth generates a valid auth header
+   * @param baseUrl base url for the api typically http/https hostname and port. Does not include
+   *     the ending /
+   * @param timeoutSeconds how long to try runSQL operations
+   * @param resultsPageSize rows requested per page, 0 does not read results
+   * @throws IOException throws when unable to read the response body or unable to attach a request
+   *     body
+   */
+  public DremioV3Api(
+      ApiCall apiCall,
+      UsernamePasswordAuth auth,
+      String baseUrl,
+      int timeoutSeconds,
+      int resultsPageSize)
+      throws IOException {
+    this(
+        apiCall,
+        auth,
+        baseUrl,
+        timeoutSeconds,
+        resultsPageSize,
+        Collections.emptyMap(),
+        HttpAuth.LOGIN);
+  }
+
+  /**
+   * DremioApi for coordinators behind an auth proxy or gateway
+   *
+   * @param apiCall implementation that makes the http calls
+   * @param auth generates a valid auth header
+   * @param baseUrl base url for the api typically http/https hostname and port. Does not include
+   *     the ending /
+   * @param timeoutSeconds how long to try runSQL operations
+   * @param resultsPageSize rows requested per page, 0 does not read results
+   * @param headers sent with every request including the login, they win over the headers of the
+   *     api such as Authorization
+   * @param httpAuth how requests authenticate, with SPNEGO the api call adds the kerberos token to
+   *     every request
+   * @throws IOException throws when unable to read the response body or unable to attach a request
+   *     body
+   */
+  public DremioV3Api(
+      ApiCall apiCall,
+      UsernamePasswordAuth auth,
+      String baseUrl,
+      int timeoutSeconds,
+      int resultsPageSize,
+      Map<String, String> headers,
+      HttpAuth httpAuth)
+      throws IOException {
+    this(apiCall, auth, baseUrl, timeoutSeconds, resultsPageSize, headers, httpAuth, null);
+  }
+
+  /**
+   * DremioApi for coordinators that trust Azure AD tokens
+   *
+   * @param apiCall implementation that makes the http calls
+   * @param auth generates a valid auth header
+   * @param baseUrl base url for the api typically http/https hostname and port. Does not include
+   *     the ending /
+   * @param timeoutSeconds how long to try runSQL operations
+   * @param resultsPageSize rows requested per page, 0 does not read results
+   * @param headers sent with every request including the login, they win over the headers of the
+   *     api such as Authorization
+   * @param httpAuth how requests authenticate, with SPNEGO the api call adds the kerberos token to
+   *     every request
+   * @param azureLogin provides the Azure AD token exchanged for a Dremio token, required with
+   *     AZURE_AD
+   * @throws IOException throws when unable to read the response body or unable to attach a request
+   *     body
+   */
+  public DremioV3Api(
+      ApiCall apiCall,
+      UsernamePasswordAuth auth,
+      String baseUrl,
+      int timeoutSeconds,
+      int resultsPageSize,
+      Map<String, String> headers,
+      HttpAuth httpAuth,
+      AzureDeviceLogin azureLogin)
+      throws IOException {
+    this(
+        apiCall,
+        auth,
+        baseUrl,
+        timeoutSeconds,
+        resultsPageSize,
+        headers,
+        httpAuth,
+        azureLogin,
+        null);
+  }
+
+  /**
+   * DremioApi sharing its login with the other connections of the run
+   *
+   * @param apiCall implementation that makes the http calls
+   * @param auth generates a valid auth header
+   * @param baseUrl base url for the api typically http/https hostname and port. Does not include
+   *     the ending /
+   * @param timeoutSeconds how long to try runSQL operations
+   * @param resultsPageSize rows requested per page, 0 does not read results
+   * @param headers sent with every request including the login, they win over the headers of the
+   *     api such as Authorization
+   * @param httpAuth how requests authenticate, with SPNEGO the api call adds the kerberos token to
+   *     every request
+   * @param azureLogin provides the Azure AD token exchanged for a Dremio token, required with
+   *     AZURE_AD
+   * @param tokenCache shares the token of a url and user between connections and refreshes it
+   *     ahead of its expiry, null logs in for this connection only
+   * @throws IOException throws when unable to read the response body or unable to attach a request
+   *     body
+   */
+  public DremioV3Api(
+      ApiCall apiCall,
+      UsernamePasswordAuth auth,
+      String baseUrl,
+      int timeoutSeconds,
+      int resultsPageSize,
+      Map<String, String> headers,
+      HttpAuth httpAuth,
+      AzureDeviceLogin azureLogin,
+      TokenCache tokenCache)
+      throws IOException {
     this.apiCall = apiCall;
     this.timeoutSeconds = timeoutSeconds;
-    Map<String, String> headers = new HashMap<>();
+    this.resultsPageSize = resultsPageSize;
+    Map<String, String> baseHeaders = new HashMap<>();
+    baseHeaders.put("Content-Type", "application/json");
+    if (httpAuth == HttpAuth.BASIC) {
+      // the proxy in front of dremio authenticates every request, there is no login
+      baseHeaders.put("Authorization", auth.toBasicHeader());
+      this.login = null;
+    } else if (httpAuth == HttpAuth.LOGIN) {
+      this.login = () -> login(apiCall, auth, baseUrl, headers);
+    } else if (httpAuth == HttpAuth.AZURE_AD) {
+      this.login = () -> exchangeToken(apiCall, azureLogin, baseUrl, headers);
+    } else {
+      this.login = null;
+    }
+    this.tokenCache = login == null ? null : tokenCache;
+    this.tokenKey = String.format("%s as %s", Redact.url(baseUrl), auth.getUsername());
+    if (this.tokenCache != null) {
+      // logs in now, or waits for the connection logging in, so connecting fails as before
+      this.tokenCache.authorization(tokenKey, login);
+    } else if (login != null) {
+      baseHeaders.put("Authorization", login.login().getAuthorization());
+    }
+    baseHeaders.putAll(headers);
+    this.baseHeaders = Collections.unmodifiableMap(baseHeaders);
+    this.baseUrl = baseUrl;
+  }
+
+  /**
+   * logs in with the v2 login api
+   *
+   * @return the Dremio token
+   * @throws IOException when the login call fails
+   */
+  private static TokenCache.Token login(
+      final ApiCall apiCall,
+      final UsernamePasswordAuth auth,
+      final String baseUrl,
+      final Map<String, String> headers)
+      throws IOException {
+    Map<String, String> loginHeaders = new HashMap<>();
     // working with json
-    headers.put("Content-Type", "application/json");
+    loginHeaders.put("Content-Type", "application/json");
+    loginHeaders.putAll(headers);
     // v2 login api
     URL url = new URL(baseUrl + "/apiv2/login");
     // auth string from username and password is the body
-    HttpApiResponse response = apiCall.submitPost(url, headers, auth.toString());
+    HttpApiResponse response = apiCall.submitPost(url, loginHeaders, auth.toString());
     // the response needs to contain the token we will use for subsequent requests
     if (response == null
         || response.getResponse() == null
@@ -69,12 +257,75 @@ public class DremioV3Api implements DremioApi {
           String.format("token was not contained in the response '%s'", response));
     }
     // now that we know the token is there add it
-    final String token = String.format("_dremio%s", response.getResponse().get("token"));
-    Map<String, String> baseHeaders = new HashMap<>();
-    baseHeaders.put("Authorization", token);
-    baseHeaders.put("Content-Type", "application/json");
-    this.baseHeaders = Collections.unmodifiableMap(baseHeaders);
-    this.baseUrl = baseUrl;
+    return new TokenCache.Token(
+        "_dremio" + response.getResponse().get("token"),
+        epochMS(response.getResponse(), "expires"));
+  }
+
+  /**
+   * exchanges the Azure AD token for a Dremio token
+   *
+   * @return the Dremio token
+   * @throws IOException when the exchange fails
+   */
+  private static TokenCache.Token exchangeToken(
+      final ApiCall apiCall,
+      final AzureDeviceLogin azureLogin,
+      final String baseUrl,
+      final Map<String, String> headers)
+      throws IOException {
+    Map<String, String> exchangeHeaders = new HashMap<>();
+    exchangeHeaders.put("Content-Type", "application/x-www-form-urlencoded");
+    exchangeHeaders.putAll(headers);
+    // oauth token exchange, dremio validates the azure ad token with its external token provider
+    URL url = new URL(baseUrl + "/oauth/token");
+    HttpApiResponse response =
+        apiCall.submitPost(
+            url,
+            exchangeHeaders,
+            AzureDeviceLogin.form(
+                "grant_type",
+                "urn:ietf:params:oauth:grant-type:token-exchange",
+                "subject_token",
+                azureLogin.token(),
+                "subject_token_type",
+                "urn:ietf:params:oauth:token-type:jwt",
+                "scope",
+                "dremio.all"));
+    if (response == null
+        || response.getResponse() == null
+        || !response.getResponse().containsKey("access_token")) {
+      throw new RuntimeException(
+          String.format("access_token was not contained in the response '%s'", response));
+    }
+    final Object expiresIn = response.getResponse().get("expires_in");
+    return new TokenCache.Token(
+        "Bearer " + response.getResponse().get("access_token"),
+        expiresIn instanceof Number
+            ? System.currentTimeMillis() + ((Number) expiresIn).longValue() * 1000
+            : null);
+  }
+
+  private static Long epochMS(final Map<String, Object> body, final String key) {
+    final Object value = body.get(key);
+    return value instanceof Number ? ((Number) value).longValue() : null;
+  }
+
+  /**
+   * the headers of a request, with the current token of the cache when the login is shared
+   *
+   * @return the headers
+   * @throws IOException when the shared token expired and logging in again fails
+   */
+  private Map<String, String> headers() throws IOException {
+    if (tokenCache == null) {
+      return baseHeaders;
+    }
+    final Map<String, String> headers = new HashMap<>();
+    headers.put("Authorization", tokenCache.authorization(tokenKey, login));
+    // headers given on the command line win over the token as they do without the cache
+    headers.putAll(baseHeaders);
+    return headers;
   }
 
   /**
@@ -94,7 +345,7 @@ public class DremioV3Api implements DremioApi {
     // v3 job api
     URL url = new URL(this.baseUrl + "/api/v3/job/" + jobId);
     // setup headers
-    HttpApiResponse response = apiCall.submitGet(url, this.baseHeaders);
+    HttpApiResponse response = apiCall.submitGet(url, headers());
     // jobState is the necessary key
     if (response == null) {
       throw new RuntimeException("no valid response");
@@ -122,19 +373,122 @@ public class DremioV3Api implements DremioApi {
     String status = jobState.toString();
     JobStatusResponse jobStatus = new JobStatusResponse();
     jobStatus.setStatus(status);
+    if ("COMPLETED".equals(status)) {
+      jobStatus.setPhases(phases(response.getResponse()));
+      jobStatus.setAccelerated(accelerated(response.getResponse()));
+    }
     return jobStatus;
   }
 
+  /**
+   * splits the job duration into phases using the timestamps of the job api. Planning runs until
+   * resource scheduling starts, queued is the time spent in resource scheduling and execution is
+   * the rest of the job
+   *
+   * @param job body of the job api
+   * @return phase name to milliseconds, phases missing a timestamp are left out
+   */
+  static Map<String, Long> phases(Map<String, Object> job) {
+    final Map<String, Long> phases = new LinkedHashMap<>();
+    final Instant started = timestamp(job, "startedAt");
+    final Instant schedulingStarted = timestamp(job, "resourceSchedulingStartedAt");
+    final Instant schedulingEnded = timestamp(job, "resourceSchedulingEndedAt");
+    final Instant ended = timestamp(job, "endedAt");
+    if (started != null && schedulingStarted != null) {
+      phases.put("planning", ChronoUnit.MILLIS.between(started, schedulingStarted));
+    }
+    if (schedulingStarted != null && schedulingEnded != null) {
+      phases.put("queued", ChronoUnit.MILLIS.between(schedulingStarted, schedulingEnded));
+    }
+    if (schedulingEnded != null && ended != null) {
+      phases.put("execution", ChronoUnit.MILLIS.between(schedulingEnded, ended));
+    }
+    return phases;
+  }
+
+  /**
+   * reads the acceleration details of the job api, a job is accelerated when one of the
+   * reflections considered for it was chosen
+   *
+   * @param job body of the job api
+   * @return true when a reflection was chosen, false otherwise
+   */
+  @SuppressWarnings("unchecked")
+  static boolean accelerated(Map<String, Object> job) {
+    final Object acceleration = job.get("acceleration");
+    if (!(acceleration instanceof Map)) {
+      return false;
+    }
+    final Object relationships =
+        ((Map<String, Object>) acceleration).get("reflectionRelationships");
+    if (!(relationships instanceof List)) {
+      return false;
+    }
+    for (final Object relationship : (List<Object>) relationships) {
+      if (relationship instanceof Map
+          && "CHOSEN".equals(((Map<String, Object>) relationship).get("relationship"))) {
+        return true;
+      }
+    }
+    return false;
+  }
+
+  private static Instant timestamp(Map<String, Object> job, String key) {
+    final Object value = job.get(key);
+    if (value == null) {
+      return null;
+    }
+    try {
+      return Instant.parse(value.toString());
+    } catch (DateTimeParseException ex) {
+      logger.fine(() -> String.format("unable to parse %s '%s'", key, value));
+      return null;
+    }
+  }
+
   /**
    * runs a sql statement against the rest API
    *
    * @param sql sql string to submit to dremio
+   * @param context context path to run the query in
    * @return the result of the job
    * @throws IOException occurs when the underlying apiCall does, typically a problem with handling
    *     of the body
    */
   @Override
-  public DremioApiResponse runSQL(String sql, Collection<String> contexts) throws IOException {
+  public DremioApiResponse runSQL(String sql, SqlContext context) throws IOException {
+    return runSQL(sql, context, null);
+  }
+
+  /**
+   * runs a sql statement against the rest API polling the job until the timeout
+   *
+   * @param sql sql string to submit to dremio
+   * @param context context path to run the query in
+   * @param timeoutMS how long to poll the job, null uses the timeout of the api
+   * @return the result of the job
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  @Override
+  public DremioApiResponse runSQL(String sql, SqlContext context, Long timeoutMS)
+      throws IOException {
+    return runSQL(sql, context, timeoutMS, null);
+  }
+
+  /**
+   * runs a sql statement against the rest API on a Dremio Cloud engine polling the job until the
+   * timeout
+   *
+   * @param sql sql string to submit to dremio
+   * @param context context path to run the query in
+   * @param timeoutMS how long to poll the job, null uses the timeout of the api
+   * @param engine name of the engine sent as engineName, null leaves it to the routing rules
+   * @return the result of the job
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  @Override
+  public DremioApiResponse runSQL(String sql, SqlContext context, Long timeoutMS, String engine)
+      throws IOException {
     try {
       if (sql == null || sql.trim().isEmpty()) {
         throw new InvalidParameterException("sql cannot be empty");
@@ -142,11 +496,15 @@ public class DremioV3Api implements DremioApi {
       URL url = new URL(baseUrl + "/api/v3/sql");
       Map<String, Object> params = new HashMap<>();
       params.put("sql", sql);
-      if (contexts != null && !contexts.isEmpty()) {
-        params.put("context", contexts.toArray(new String[0]));
+      if (context != null && !context.isEmpty()) {
+        params.put("context", context.getParts());
+      }
+      if (engine != null && !engine.isEmpty()) {
+        params.put("engineName", engine);
       }
       String json = new ObjectMapper().writeValueAsString(params);
-      HttpApiResponse response = apiCall.submitPost(url, this.baseHeaders, json);
+      final Instant submitted = Instant.now();
+      HttpApiResponse response = apiCall.submitPost(url, headers(), json);
       if (response == null) {
         throw new RuntimeException("missing response");
       }
@@ -157,39 +515,18 @@ public class DremioV3Api implements DremioApi {
         throw new RuntimeException("id");
       }
 
-      Instant timeout = Instant.now().plus(timeoutSeconds, ChronoUnit.SECONDS);
+      Instant timeout =
+          timeoutMS == null
+              ? Instant.now().plus(timeoutSeconds, ChronoUnit.SECONDS)
+              : Instant.now().plusMillis(timeoutMS);
       String jobId = String.valueOf(response.getResponse().get("id"));
-      while (!Instant.now().isAfter(timeout)) {
-        JobStatusResponse status = this.checkJobStatus(jobId);
-        if (status == null) {
-          throw new RuntimeException("unexpected job status critical error");
-        }
-        final String statusString = status.getStatus();
-        if ("COMPLETED".equals(statusString)) {
-          logger.info(() -> statusString);
-          DremioApiResponse success = new DremioApiResponse();
-          success.setSuccessful(true);
-          return success;
-        }
-        if ("FAILED".equals(statusString)
-            || "INVALID_STATE".equals(statusString)
-            || "CANCELLED".equals(statusString)) {
-          DremioApiResponse failure = new DremioApiResponse();
-          failure.setSuccessful(false);
-          failure.setErrorMessage(String.format("Response status is '%s'", status.getMessage()));
-          return failure;
-        }
-        try {
-          Thread.sleep(200);
-        } catch (InterruptedException e) {
-          throw new RuntimeException(e);
-        }
+      logger.fine(() -> String.format("submitted job %s", jobId));
+      inFlightJobs.add(jobId);
+      try {
+        return waitForJob(jobId, timeout, submitted);
+      } finally {
+        inFlightJobs.remove(jobId);
       }
-      // hit the timeout
-      DremioApiResponse failed = new DremioApiResponse();
-      failed.setSuccessful(false);
-      failed.setErrorMessage("timeout hit");
-      return failed;
     } catch (Exception ex) {
       DremioApiResponse failed = new DremioApiResponse();
       failed.setSuccessful(false);
@@ -198,6 +535,340 @@ public class DremioV3Api implements DremioApi {
     }
   }
 
+  /**
+   * polls the job status until the job reaches a final state or the timeout is hit
+   *
+   * @param jobId job to wait for
+   * @param timeout when to give up
+   * @param submitted when the statement was submitted, the time to the first page is measured
+   *     from it
+   * @return the result of the job
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  private DremioApiResponse waitForJob(
+      final String jobId, final Instant timeout, final Instant submitted) throws IOException {
+    while (!Instant.now().isAfter(timeout)) {
+      JobStatusResponse status = this.checkJobStatus(jobId);
+      if (status == null) {
+        throw new RuntimeException("unexpected job status critical error");
+      }
+      final String statusString = status.getStatus();
+      if ("ENGINE_START".equals(statusString)) {
+        logger.fine(() -> String.format("job %s is waiting for an engine to start", jobId));
+      }
+      if ("COMPLETED".equals(statusString)) {
+        logger.info(() -> statusString);
+        DremioApiResponse success = new DremioApiResponse();
+        if (resultsPageSize > 0) {
+          final long rows = fetchResults(jobId, submitted, success);
+          logger.fine(() -> String.format("read %d rows of job %s", rows, jobId));
+        }
+        success.setSuccessful(true);
+        success.setJobId(jobId);
+        success.setPhases(status.getPhases());
+        success.setAccelerated(status.getAccelerated());
+        return success;
+      }
+      if ("FAILED".equals(statusString)
+          || "INVALID_STATE".equals(statusString)
+          || "CANCELLED".equals(statusString)) {
+        DremioApiResponse failure = new DremioApiResponse();
+        failure.setSuccessful(false);
+        failure.setErrorMessage(String.format("Response status is '%s'", status.getMessage()));
+        failure.setJobId(jobId);
+        return failure;
+      }
+      try {
+        Thread.sleep(200);
+      } catch (InterruptedException e) {
+        throw new RuntimeException(e);
+      }
+    }
+    // hit the timeout
+    DremioApiResponse failed = new DremioApiResponse();
+    failed.setSuccessful(false);
+    failed.setErrorMessage("timeout hit");
+    failed.setJobId(jobId);
+    return failed;
+  }
+
+  /**
+   * reads every row of a completed job one page at a time, like a BI tool scrolling a result
+   *
+   * @param jobId the completed job
+   * @param submitted when the statement was submitted
+   * @param response gets the time from submitting to the first page
+   * @return number of rows read
+   * @throws IOException when a page cannot be read
+   */
+  private long fetchResults(
+      final String jobId, final Instant submitted, final DremioApiResponse response)
+      throws IOException {
+    long offset = 0;
+    long rowCount = 1;
+    while (offset < rowCount) {
+      final URL url =
+          new URL(
+              String.format(
+                  "%s/api/v3/job/%s/results?offset=%d&limit=%d",
+                  baseUrl, jobId, offset, resultsPageSize));
+      final HttpApiResponse page = apiCall.submitGet(url, headers());
+      if (page == null || page.getResponse() == null) {
+        throw new IOException(
+            String.format("no valid results page for job %s at offset %d", jobId, offset));
+      }
+      if (response.getFirstRowMS() == null) {
+        response.setFirstRowMS(Duration.between(submitted, Instant.now()).toMillis());
+      }
+      final Object total = page.getResponse().get("rowCount");
+      rowCount = total instanceof Number ? ((Number) total).longValue() : 0;
+      final Object rows = page.getResponse().get("rows");
+      if (!(rows instanceof List) || ((List<?>) rows).isEmpty()) {
+        break;
+      }
+      offset += ((List<?>) rows).size();
+    }
+    return offset;
+  }
+
+  /** the rest api keeps no connection open, the token is left to expire */
+  @Override
+  public void close() {}
+
+  /**
+   * downloads the profile zip through the support api used by the download profile button of the
+   * jobs page
+   *
+   * @param jobId job to download the profile of
+   * @param file where to write the profile zip
+   * @return true when written
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  @Override
+  public boolean downloadProfile(final String jobId, final File file) throws IOException {
+    URL url = new URL(this.baseUrl + "/apiv2/support/" + jobId + "/download");
+    return apiCall.submitDownload(url, headers(), file);
+  }
+
+  /**
+   * lists the root of the catalog, which needs a valid login
+   *
+   * @return true when the catalog answered successfully
+   */
+  @Override
+  public boolean healthCheck() {
+    try {
+      final Map<String, String> headers = headers();
+      final HttpApiResponse response =
+          apiCall.submitGet(new URL(baseUrl + "/api/v3/catalog"), headers);
+      if (response.getResponseCode() == 401 && tokenCache != null) {
+        // the shared token was revoked, the next request logs in again for every connection
+        tokenCache.invalidate(tokenKey, headers.get("Authorization"));
+      }
+      return response.getResponseCode() >= 200 && response.getResponseCode() < 300;
+    } catch (IOException | RuntimeException e) {
+      logger.fine(() -> String.format("health check of %s failed %s", baseUrl, e.getMessage()));
+      return false;
+    }
+  }
+
+  /**
+   * cancels the jobs that are still running through the v3 job cancel api
+   *
+   * @return number of jobs a cancel was sent for
+   */
+  @Override
+  public int cancelInFlight() {
+    int cancelled = 0;
+    for (String jobId : new ArrayList<>(inFlightJobs)) {
+      try {
+        URL url = new URL(this.baseUrl + "/api/v3/job/" + jobId + "/cancel");
+        apiCall.submitPost(url, headers(), null);
+        cancelled++;
+      } catch (IOException ex) {
+        // the cancel api does not always return a json body, the job may still be cancelled
+        logger.fine(() -> String.format("cancel of job %s returned %s", jobId, ex.getMessage()));
+      }
+    }
+    return cancelled;
+  }
+
+  /**
+   * calls the catalog rest api. Promoting an entity that already is a dataset and unpromoting one
+   * that is not succeed without a call, so promote and unpromote can run concurrently on the same
+   * path.
+   *
+   * @param operation the catalog call to make
+   * @param path path of the entity, not used when listing the catalog
+   * @param format format of the dataset when promoting, defaults to parquet
+   * @return the result of the call, failed when the api returned an error
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  @Override
+  public DremioApiResponse runCatalog(
+      CatalogOperation operation, List<String> path, Map<String, Object> format)
+      throws IOException {
+    if (operation == CatalogOperation.LIST) {
+      return catalogResponse(
+          "list catalog", apiCall.submitGet(new URL(baseUrl + "/api/v3/catalog"), headers()));
+    }
+    final String description = operation.name().toLowerCase() + " " + String.join(".", path);
+    final HttpApiResponse entity = apiCall.submitGet(byPathUrl(path), headers());
+    if (operation == CatalogOperation.GET || entity == null || entity.getResponse() == null) {
+      return catalogResponse(description, entity);
+    }
+    final boolean dataset = "dataset".equals(entity.getResponse().get("entityType"));
+    final URL entityUrl = entityUrl(String.valueOf(entity.getResponse().get("id")));
+    if (operation == CatalogOperation.PROMOTE) {
+      if (dataset) {
+        return catalogResponse(description, entity);
+      }
+      final Map<String, Object> body = new HashMap<>();
+      body.put("entityType", "dataset");
+      body.put("type", "PHYSICAL_DATASET");
+      body.put("path", path);
+      body.put("format", format == null ? Collections.singletonMap("type", "Parquet") : format);
+      return catalogResponse(
+          description,
+          apiCall.submitPost(entityUrl, headers(), new ObjectMapper().writeValueAsString(body)));
+    }
+    if (!dataset) {
+      return catalogResponse(description, entity);
+    }
+    return catalogResponse(description, apiCall.submitDelete(entityUrl, headers()));
+  }
+
+  /**
+   * reads a catalog entity
+   *
+   * @param path path of the entity
+   * @return the entity or null when it does not exist
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  public Map<String, Object> getCatalogEntity(final List<String> path) throws IOException {
+    final HttpApiResponse response = apiCall.submitGet(byPathUrl(path), headers());
+    return response == null ? null : response.getResponse();
+  }
+
+  /**
+   * creates a catalog entity such as a space or source
+   *
+   * @param entity the entity as the catalog api expects it
+   * @return the id of the new entity
+   * @throws IOException when the entity could not be created
+   */
+  public String createCatalogEntity(final Map<String, Object> entity) throws IOException {
+    final HttpApiResponse response =
+        apiCall.submitPost(
+            new URL(baseUrl + "/api/v3/catalog"),
+            headers(),
+            new ObjectMapper().writeValueAsString(entity));
+    if (response == null || response.getResponse() == null) {
+      throw new IOException(
+          String.format(
+              "unable to create %s %s: %s",
+              entity.get("entityType"), entity.get("name"), response));
+    }
+    return String.valueOf(response.getResponse().get("id"));
+  }
+
+  /**
+   * deletes a catalog entity
+   *
+   * @param id id of the entity
+   * @throws IOException when the entity could not be deleted
+   */
+  public void deleteCatalogEntity(final String id) throws IOException {
+    final HttpApiResponse response = apiCall.submitDelete(entityUrl(id), headers());
+    if (response == null || response.getResponse() == null) {
+      throw new IOException(String.format("unable to delete %s: %s", id, response));
+    }
+  }
+
+  private URL byPathUrl(final List<String> path) throws IOException {
+    final List<String> segments = new ArrayList<>();
+    for (final String part : path) {
+      segments.add(URLEncoder.encode(part, "UTF-8").replace("+", "%20"));
+    }
+    return new URL(baseUrl + "/api/v3/catalog/by-path/" + String.join("/", segments));
+  }
+
+  private URL entityUrl(final String id) throws IOException {
+    return new URL(baseUrl + "/api/v3/catalog/" + URLEncoder.encode(id, "UTF-8"));
+  }
+
+  private static DremioApiResponse catalogResponse(
+      final String description, final HttpApiResponse response) {
+    final DremioApiResponse result = new DremioApiResponse();
+    if (response != null && response.getResponse() != null) {
+      result.setSuccessful(true);
+      return result;
+    }
+    result.setSuccessful(false);
+    result.setErrorMessage(
+        response == null
+            ? description + " returned no response"
+            : String.format(
+                "%s returned %d %s",
+                description, response.getResponseCode(), response.getMessage()));
+    return result;
+  }
+
+  /**
+   * reads the first value from the job results api
+   *
+   * @param response response of a completed job
+   * @return the first column of the first row or null when there are no rows
+   * @throws IOException occurs when the underlying apiCall does
+   */
+  @Override
+  @SuppressWarnings("unchecked")
+  public Object fetchFirstValue(DremioApiResponse response) throws IOException {
+    if (response == null || response.getJobId() == null) {
+      return null;
+    }
+    URL url = new URL(this.baseUrl + "/api/v3/job/" + response.getJobId() + "/results?limit=1");
+    HttpApiResponse results = apiCall.submitGet(url, headers());
+    if (results == null || results.getResponse() == null) {
+      throw new RuntimeException("no valid results response " + results);
+    }
+    List<Map<String, Object>> rows = (List<Map<String, Object>>) results.getResponse().get("rows");
+    List<Map<String, Object>> schema =
+        (List<Map<String, Object>>) results.getResponse().get("schema");
+    if (rows == null || rows.isEmpty() || schema == null || schema.isEmpty()) {
+      return null;
+    }
+    return rows.get(0).get(String.valueOf(schema.get(0).get("name")));
+  }
+
+  /**
+   * the rest api has no prepared statements
+   *
+   * @return always a failed response
+   */
+  @Override
+  public DremioApiResponse runPreparedSQL(String sql, List<Object> parameters, SqlContext context) {
+    return runPreparedSQL(sql, parameters, context, null);
+  }
+
+  /**
+   * prepared statements are not available over the rest api
+   *
+   * @param sql not used
+   * @param parameters not used
+   * @param context not used
+   * @param timeoutMS not used
+   * @return a failed response
+   */
+  @Override
+  public DremioApiResponse runPreparedSQL(
+      String sql, List<Object> parameters, SqlContext context, Long timeoutMS) {
+    DremioApiResponse failed = new DremioApiResponse();
+    failed.setSuccessful(false);
+    failed.setErrorMessage("prepared statements are not supported over HTTP");
+    return failed;
+  }
+
   /** @return return the url used to access Dremio */
   @Override
   public String getUrl() {
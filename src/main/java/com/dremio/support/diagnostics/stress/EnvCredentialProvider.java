@@ -0,0 +1,36 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.IOException;
+
+/** EnvCredentialProvider reads the password from an environment variable */
+public class EnvCredentialProvider implements CredentialProvider {
+
+  private final String name;
+
+  /** @param name the environment variable */
+  public EnvCredentialProvider(final String name) {
+    this.name = name;
+  }
+
+  @Override
+  public String password(final String user) throws IOException {
+    final String password = System.getenv(name);
+    if (password == null) {
+      throw new IOException(String.format("environment variable %s is not set", name));
+    }
+    return password;
+  }
+}
@@ -0,0 +1,63 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.Paths;
+import java.nio.file.attribute.PosixFilePermission;
+import java.util.List;
+import java.util.Set;
+import java.util.logging.Logger;
+
+/**
+ * FileCredentialProvider reads the password from the first line of a file, such as a mounted
+ * kubernetes or docker secret
+ */
+public class FileCredentialProvider implements CredentialProvider {
+
+  private static final Logger logger = Logger.getLogger(FileCredentialProvider.class.getName());
+  private final Path path;
+
+  /** @param path the file with the password on its first line */
+  public FileCredentialProvider(final String path) {
+    this.path = Paths.get(path);
+  }
+
+  @Override
+  public String password(final String user) throws IOException {
+    warnWhenShared();
+    final List<String> lines = Files.readAllLines(path, StandardCharsets.UTF_8);
+    if (lines.isEmpty() || lines.get(0).isEmpty()) {
+      throw new IOException(String.format("password file %s is empty", path));
+    }
+    return lines.get(0);
+  }
+
+  // a password file others can read is as good as one on the command line
+  private void warnWhenShared() {
+    try {
+      final Set<PosixFilePermission> permissions = Files.getPosixFilePermissions(path);
+      if (permissions.contains(PosixFilePermission.GROUP_READ)
+          || permissions.contains(PosixFilePermission.OTHERS_READ)) {
+        logger.warning(
+            () -> String.format("password file %s is readable by other users, chmod 600 it", path));
+      }
+    } catch (UnsupportedOperationException | IOException e) {
+      // not a posix file system, such as windows, or the read below reports the problem
+    }
+  }
+}
@@ -0,0 +1,163 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.util.Arrays;
+import java.util.Collections;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * HtmlReport renders the results of a run as a single self contained html page, for sharing a run
+ * with people who will not read the results json.
+ */
+public class HtmlReport {
+
+  private static final List<String> summaryFields =
+      Arrays.asList(
+          "runId", "config", "url", "start", "end", "submitted", "successful", "failures",
+          "skipped", "avgLatencyMS");
+  private static final List<String> queryFields =
+      Arrays.asList(
+          "name", "successful", "failures", "skipped", "meanMS", "p50MS", "p95MS", "p99MS",
+          "firstRowP50MS", "firstRowP95MS", "accelerated");
+  private static final List<String> probeFields =
+      Arrays.asList("successful", "failures", "meanMS", "p50MS", "p95MS", "p99MS", "maxMS");
+  private static final List<String> eventFields = Arrays.asList("time", "type", "message");
+
+  private HtmlReport() {}
+
+  /**
+   * writes the report
+   *
+   * @param results the results as written to the results file
+   * @param file html file to write
+   * @throws IOException when unable to write the file
+   */
+  public static void write(final Map<String, Object> results, final File file)
+      throws IOException {
+    write(results, file, null);
+  }
+
+  /**
+   * writes the report
+   *
+   * @param results the results as written to the results file
+   * @param file html file to write
+   * @param css style rules added after the default ones, null for none
+   * @throws IOException when unable to write the file
+   */
+  public static void write(final Map<String, Object> results, final File file, final String css)
+      throws IOException {
+    Files.write(file.toPath(), render(results, css).getBytes(StandardCharsets.UTF_8));
+  }
+
+  /**
+   * renders the report
+   *
+   * @param results the results as written to the results file
+   * @return the html page
+   */
+  public static String render(final Map<String, Object> results) {
+    return render(results, null);
+  }
+
+  /**
+   * renders the report
+   *
+   * @param results the results as written to the results file
+   * @param css style rules added after the default ones so they override them, null for none
+   * @return the html page
+   */
+  @SuppressWarnings("unchecked")
+  public static String render(final Map<String, Object> results, final String css) {
+    final StringBuilder html = new StringBuilder();
+    html.append("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+        .append("<title>dremio-stress run ")
+        .append(escape(results.get("runId")))
+        .append("</title>\n<style>\n")
+        .append("body { font-family: sans-serif; margin: 2em; }\n")
+        .append("table { border-collapse: collapse; margin-bottom: 2em; }\n")
+        .append("th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }\n")
+        .append("th { background: #eee; }\n")
+        .append(css == null ? "" : css + "\n")
+        .append("</style>\n</head>\n<body>\n")
+        .append("<h1>dremio-stress run ")
+        .append(escape(results.get("runId")))
+        .append("</h1>\n<table>\n");
+    for (final String field : summaryFields) {
+      html.append("<tr><th>")
+          .append(field)
+          .append("</th><td>")
+          .append(escape(results.get(field)))
+          .append("</td></tr>\n");
+    }
+    html.append("</table>\n");
+    final Object violations = results.get("slaViolations");
+    if (violations instanceof List && !((List<Object>) violations).isEmpty()) {
+      html.append("<h2>SLA violations</h2>\n<ul>\n");
+      for (final Object violation : (List<Object>) violations) {
+        html.append("<li>").append(escape(violation)).append("</li>\n");
+      }
+      html.append("</ul>\n");
+    }
+    appendTable(html, "Queries", queryFields, results.get("queries"));
+    if (results.get("probe") != null) {
+      appendTable(
+          html,
+          "Responsiveness probe",
+          probeFields,
+          Collections.singletonList(results.get("probe")));
+    }
+    appendTable(html, "Timeline", eventFields, results.get("events"));
+    html.append("</body>\n</html>\n");
+    return html.toString();
+  }
+
+  @SuppressWarnings("unchecked")
+  private static void appendTable(
+      final StringBuilder html, final String title, final List<String> fields, final Object rows) {
+    if (!(rows instanceof List) || ((List<Object>) rows).isEmpty()) {
+      return;
+    }
+    html.append("<h2>").append(title).append("</h2>\n<table>\n<tr>");
+    for (final String field : fields) {
+      html.append("<th>").append(field).append("</th>");
+    }
+    html.append("</tr>\n");
+    for (final Map<String, Object> row : (List<Map<String, Object>>) rows) {
+      html.append("<tr>");
+      for (final String field : fields) {
+        html.append("<td>").append(escape(row.get(field))).append("</td>");
+      }
+      html.append("</tr>\n");
+    }
+    html.append("</table>\n");
+  }
+
+  private static String escape(final Object value) {
+    if (value == null) {
+      return "";
+    }
+    return String.valueOf(value)
+        .replace("&", "&amp;")
+        .replace("<", "&lt;")
+        .replace(">", "&gt;")
+        .replace("\"", "&quot;");
+  }
+}
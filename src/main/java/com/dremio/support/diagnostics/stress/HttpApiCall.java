@@ -19,10 +19,14 @@ import java.io.*;
 import java.net.HttpURLConnection;
 import java.net.URL;
 import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.StandardCopyOption;
 import java.security.SecureRandom;
 import java.security.cert.CertificateException;
 import java.security.cert.X509Certificate;
+import java.util.Collections;
 import java.util.Map;
+import java.util.zip.GZIPInputStream;
 import javax.net.ssl.HttpsURLConnection;
 import javax.net.ssl.SSLContext;
 import javax.net.ssl.X509TrustManager;
@@ -30,7 +34,31 @@ import javax.net.ssl.X509TrustManager;
 /** HttpApiCall is the wrapper for HttpUrlConnection logic */
 public class HttpApiCall implements ApiCall {
 
+  // ask for gzip compressed responses, large job results then cost less bandwidth but more cpu
+  private final boolean gzip;
+  // adds a kerberos token to every request, null when kerberos is not used
+  private final Spnego spnego;
+
   public HttpApiCall(final boolean ignoreSSL) {
+    this(ignoreSSL, false);
+  }
+
+  /**
+   * @param ignoreSSL trust every certificate and host name
+   * @param gzip ask for gzip compressed responses
+   */
+  public HttpApiCall(final boolean ignoreSSL, final boolean gzip) {
+    this(ignoreSSL, gzip, null);
+  }
+
+  /**
+   * @param ignoreSSL trust every certificate and host name
+   * @param gzip ask for gzip compressed responses
+   * @param spnego adds a kerberos token to every request, null does not
+   */
+  public HttpApiCall(final boolean ignoreSSL, final boolean gzip, final Spnego spnego) {
+    this.gzip = gzip;
+    this.spnego = spnego;
     if (ignoreSSL) {
       HttpsURLConnection.setDefaultHostnameVerifier((hostname, session) -> true);
       try {
@@ -70,12 +98,16 @@ public class HttpApiCall implements ApiCall {
     for (Map.Entry<String, String> kvp : headers.entrySet()) {
       connection.setRequestProperty(kvp.getKey(), kvp.getValue());
     }
+    if (gzip) {
+      connection.setRequestProperty("Accept-Encoding", "gzip");
+    }
+    negotiate(connection, url);
 
     if (connection.getResponseCode() > 199 && connection.getResponseCode() < 400) {
       StringBuilder content = new StringBuilder();
       try (BufferedReader reader =
           new BufferedReader(
-              new InputStreamReader(connection.getInputStream(), StandardCharsets.UTF_8))) {
+              new InputStreamReader(responseStream(connection), StandardCharsets.UTF_8))) {
         String strCurrentLine;
         while ((strCurrentLine = reader.readLine()) != null) {
           content.append(strCurrentLine);
@@ -114,6 +146,10 @@ public class HttpApiCall implements ApiCall {
     for (Map.Entry<String, String> kvp : headers.entrySet()) {
       connection.setRequestProperty(kvp.getKey(), kvp.getValue());
     }
+    if (gzip) {
+      connection.setRequestProperty("Accept-Encoding", "gzip");
+    }
+    negotiate(connection, url);
     if (body != null) {
       connection.setDoOutput(true);
       try (OutputStream stream = connection.getOutputStream()) {
@@ -130,7 +166,7 @@ public class HttpApiCall implements ApiCall {
       StringBuilder content = new StringBuilder();
       try (BufferedReader reader =
           new BufferedReader(
-              new InputStreamReader(connection.getInputStream(), StandardCharsets.UTF_8))) {
+              new InputStreamReader(responseStream(connection), StandardCharsets.UTF_8))) {
         String strCurrentLine;
         while ((strCurrentLine = reader.readLine()) != null) {
           content.append(strCurrentLine);
@@ -159,4 +195,73 @@ public class HttpApiCall implements ApiCall {
       return response;
     }
   }
+
+  @Override
+  public HttpApiResponse submitDelete(final URL url, final Map<String, String> headers)
+      throws IOException {
+    HttpURLConnection connection = (HttpURLConnection) url.openConnection();
+    connection.setDoInput(true);
+    connection.setRequestMethod("DELETE");
+    for (Map.Entry<String, String> kvp : headers.entrySet()) {
+      connection.setRequestProperty(kvp.getKey(), kvp.getValue());
+    }
+    if (gzip) {
+      connection.setRequestProperty("Accept-Encoding", "gzip");
+    }
+    negotiate(connection, url);
+    if (connection.getResponseCode() > 199 && connection.getResponseCode() < 400) {
+      // deletes answer with an empty body
+      final HttpApiResponse response = new HttpApiResponse();
+      response.setResponseCode(connection.getResponseCode());
+      response.setMessage(connection.getResponseMessage());
+      response.setResponse(Collections.emptyMap());
+      return response;
+    }
+    StringBuilder error = new StringBuilder();
+    InputStream errorCode = connection.getErrorStream();
+    try (BufferedReader br =
+        new BufferedReader(new InputStreamReader(errorCode, StandardCharsets.UTF_8))) {
+      String strCurrentLine;
+      while ((strCurrentLine = br.readLine()) != null) {
+        error.append(strCurrentLine);
+      }
+      HttpApiResponse response = new HttpApiResponse();
+      response.setResponseCode(connection.getResponseCode());
+      response.setMessage(connection.getResponseMessage() + " ----- " + error);
+      return response;
+    }
+  }
+
+  @Override
+  public boolean submitDownload(final URL url, final Map<String, String> headers, final File file)
+      throws IOException {
+    HttpURLConnection connection = (HttpURLConnection) url.openConnection();
+    connection.setDoInput(true);
+    connection.setRequestMethod("POST");
+    for (Map.Entry<String, String> kvp : headers.entrySet()) {
+      connection.setRequestProperty(kvp.getKey(), kvp.getValue());
+    }
+    negotiate(connection, url);
+    if (connection.getResponseCode() > 199 && connection.getResponseCode() < 400) {
+      try (InputStream body = responseStream(connection)) {
+        Files.copy(body, file.toPath(), StandardCopyOption.REPLACE_EXISTING);
+      }
+      return true;
+    }
+    return false;
+  }
+
+  // a kerberos token is only good for one request so every request gets a new one
+  private void negotiate(final HttpURLConnection connection, final URL url) throws IOException {
+    if (spnego != null) {
+      connection.setRequestProperty("Authorization", spnego.header(url));
+    }
+  }
+
+  private static InputStream responseStream(final HttpURLConnection connection) throws IOException {
+    if ("gzip".equalsIgnoreCase(connection.getContentEncoding())) {
+      return new GZIPInputStream(connection.getInputStream());
+    }
+    return connection.getInputStream();
+  }
 }
@@ -0,0 +1,26 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+/** how requests to the HTTP api authenticate */
+public enum HttpAuth {
+  /** log in with the user and password once and send the token with every request */
+  LOGIN,
+  /** send the user and password as basic auth on every request, for auth proxies */
+  BASIC,
+  /** send a kerberos token on every request, for coordinators that require kerberos */
+  SPNEGO,
+  /** sign in to Azure AD with a device code and exchange its token for a Dremio token */
+  AZURE_AD
+}
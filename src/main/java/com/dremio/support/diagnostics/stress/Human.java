@@ -14,6 +14,8 @@
 package com.dremio.support.diagnostics.stress;
 
 import java.text.NumberFormat;
+import java.util.regex.Matcher;
+import java.util.regex.Pattern;
 
 /**
  * Provides utility methods for making more easily understandable the output of big numbers
@@ -33,6 +35,9 @@ public class Human {
   /** a day measured in milliseconds */
   private static final long DAY = 24 * HOUR;
 
+  /** one number and unit of a duration such as 1h30m */
+  private static final Pattern durationPart = Pattern.compile("(\\d+(?:\\.\\d+)?)(ms|s|m|h|d)");
+
   private static final long kb = 1024L;
   private static final double kbd = 1024.0;
 
@@ -137,4 +142,47 @@ public class Human {
     }
     return String.format("%s bytes", bytes);
   }
+
+  /**
+   * parses a duration as written in a config, such as 500ms, 30s, 5m, 2h or 1h30m. A plain number
+   * is seconds.
+   *
+   * @param text the duration
+   * @return the duration in milliseconds
+   * @throws IllegalArgumentException when the text is not a duration
+   */
+  public static long parseDurationMillis(final String text) {
+    final String trimmed = text.trim().toLowerCase();
+    if (trimmed.matches("\\d+(\\.\\d+)?")) {
+      return Math.round(Double.parseDouble(trimmed) * SECOND);
+    }
+    final Matcher matcher = durationPart.matcher(trimmed);
+    long total = 0;
+    int end = 0;
+    while (matcher.find() && matcher.start() == end) {
+      final double amount = Double.parseDouble(matcher.group(1));
+      total += Math.round(amount * unitMillis(matcher.group(2)));
+      end = matcher.end();
+    }
+    if (end == 0 || end != trimmed.length()) {
+      throw new IllegalArgumentException(
+          String.format("invalid duration '%s', use for example 500ms, 30s, 5m or 1h30m", text));
+    }
+    return total;
+  }
+
+  private static long unitMillis(final String unit) {
+    switch (unit) {
+      case "ms":
+        return 1;
+      case "s":
+        return SECOND;
+      case "m":
+        return MINUTE;
+      case "h":
+        return HOUR;
+      default:
+        return DAY;
+    }
+  }
 }
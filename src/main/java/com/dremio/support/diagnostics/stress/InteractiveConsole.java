@@ -0,0 +1,171 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.BufferedReader;
+import java.io.IOException;
+import java.io.InputStream;
+import java.io.InputStreamReader;
+import java.io.PrintStream;
+import java.nio.charset.StandardCharsets;
+import java.security.InvalidParameterException;
+import java.util.Locale;
+import java.util.Map;
+import java.util.logging.Level;
+import java.util.logging.Logger;
+
+/**
+ * InteractiveConsole reads commands typed by the operator while the run is live, for exploratory
+ * capacity testing where the load is tuned step by step instead of restarting the run:
+ *
+ * <pre>
+ * set qps 50          cap the submissions per second, set qps 0 removes the cap
+ * set workers 32      resize the shared workers
+ * pause group etl     stop submitting the queries of a query group, resume group etl
+ * pause / resume      stop and resume submitting every query
+ * note text           add an annotation to the timeline
+ * stats               print the current statistics
+ * stop                end the run and print the summary
+ * </pre>
+ */
+public class InteractiveConsole {
+
+  private static final Logger logger = Logger.getLogger(InteractiveConsole.class.getName());
+
+  private final InputStream in;
+  private final PrintStream out;
+  private final StressControl control;
+
+  /**
+   * @param in where the commands are read from
+   * @param out where the answers are printed
+   * @param control the run to control
+   */
+  public InteractiveConsole(
+      final InputStream in, final PrintStream out, final StressControl control) {
+    this.in = in;
+    this.out = out;
+    this.control = control;
+  }
+
+  /** reads commands in the background until the input ends */
+  public void start() {
+    final Thread thread = new Thread(this::readCommands, "interactive-console");
+    // never keep the jvm alive just to read commands
+    thread.setDaemon(true);
+    thread.start();
+    out.println("interactive mode, type help for the commands");
+  }
+
+  private void readCommands() {
+    try (BufferedReader reader =
+        new BufferedReader(new InputStreamReader(in, StandardCharsets.UTF_8))) {
+      String line;
+      while ((line = reader.readLine()) != null) {
+        if (!line.trim().isEmpty()) {
+          out.println(execute(line.trim()));
+        }
+      }
+    } catch (IOException e) {
+      logger.log(Level.WARNING, "unable to read commands", e);
+    }
+  }
+
+  /**
+   * runs a single command
+   *
+   * @param line the command as typed
+   * @return the answer to print
+   */
+  String execute(final String line) {
+    final String[] words = line.split("\\s+");
+    final String command = words[0].toLowerCase(Locale.ROOT);
+    try {
+      switch (command) {
+        case "help":
+          return "commands: set qps <n>, set workers <n>, pause [group <name>], resume [group"
+              + " <name>], note <text>, stats, stop";
+        case "stats":
+        case "status":
+          return stats();
+        case "pause":
+          if (words.length == 3 && "group".equalsIgnoreCase(words[1])) {
+            control.pauseGroup(words[2]);
+            return "query group " + words[2] + " paused";
+          }
+          control.pause();
+          return "submission paused";
+        case "resume":
+          if (words.length == 3 && "group".equalsIgnoreCase(words[1])) {
+            control.resumeGroup(words[2]);
+            return "query group " + words[2] + " resumed";
+          }
+          control.resume();
+          return "submission resumed";
+        case "set":
+          return set(words);
+        case "note":
+          if (words.length < 2) {
+            return "usage: note <text>";
+          }
+          control.annotate(line.substring(words[0].length()).trim());
+          return "annotated";
+        case "stop":
+          control.stop();
+          return "stopping";
+        default:
+          return "unknown command " + words[0] + ", type help for the commands";
+      }
+    } catch (InvalidParameterException | UnsupportedOperationException e) {
+      return e.getMessage();
+    }
+  }
+
+  private String set(final String[] words) {
+    if (words.length != 3) {
+      return "usage: set qps <n> or set workers <n>";
+    }
+    try {
+      switch (words[1].toLowerCase(Locale.ROOT)) {
+        case "qps":
+          final double perSecond = Double.parseDouble(words[2]);
+          control.setRate(perSecond);
+          return perSecond > 0 ? "submission capped at " + words[2] + " per second" : "cap removed";
+        case "workers":
+          control.setWorkers(Integer.parseInt(words[2]));
+          return words[2] + " shared workers";
+        default:
+          return "usage: set qps <n> or set workers <n>";
+      }
+    } catch (NumberFormatException e) {
+      return "not a number: " + words[2];
+    }
+  }
+
+  private String stats() {
+    final Map<String, Object> status = control.status();
+    return String.format(
+        "submitted %s; successful %s; failures %s; queued %s; avg latency %s ms; paused %s;"
+            + " paused groups %s; qps cap %s; workers %s",
+        status.get("submitted"),
+        status.get("successful"),
+        status.get("failures"),
+        status.get("queued"),
+        status.get("avgLatencyMS"),
+        status.get("paused"),
+        status.get("pausedGroups"),
+        status.get("rateLimitPerSecond") == null ? "none" : status.get("rateLimitPerSecond"),
+        status.get("workers"));
+  }
+}
@@ -0,0 +1,506 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.MappingIterator;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import java.io.BufferedReader;
+import java.io.IOException;
+import java.io.InputStream;
+import java.io.InputStreamReader;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.Iterator;
+import java.util.LinkedHashMap;
+import java.util.LinkedHashSet;
+import java.util.List;
+import java.util.Map;
+import java.util.Random;
+import java.util.Set;
+import java.util.logging.Logger;
+import java.util.zip.GZIPInputStream;
+
+/**
+ * JobHistoryConverter turns a job history export into a workload. Queries that only differ in
+ * their literal values are grouped, the frequency of a group is the number of times it was seen
+ * and literals that changed between runs become parameters with the observed values.
+ *
+ * <p>Supported exports are a csv file with a header row, queries.json and json with one job per
+ * line, an array of jobs or the rows of a rest api query result. Fields are matched by name
+ * ignoring case and underscores, queryText, query or sql for the text and optionally context,
+ * username and outcome or status.
+ *
+ * <p>Large histories can be bounded two ways: exact mode only merges identical query texts instead
+ * of templating their literals, and sampling keeps a uniform random sample of the jobs (reservoir
+ * sampling over every file read) so the frequencies keep their proportions.
+ */
+public class JobHistoryConverter {
+
+  private static final Logger logger = Logger.getLogger(JobHistoryConverter.class.getName());
+
+  private static final List<String> queryFields = Arrays.asList("querytext", "query", "sql");
+  private static final List<String> contextFields = Arrays.asList("context", "querycontext");
+  private static final List<String> userFields = Arrays.asList("username", "user");
+  private static final List<String> outcomeFields =
+      Arrays.asList("outcome", "status", "state", "jobstatus");
+
+  private final int maxValues;
+  private final boolean exact;
+  private final int sample;
+  private final Random random;
+  private final Map<String, Template> templates = new LinkedHashMap<>();
+  // jobs kept when sampling, grouped once every file is read
+  private final List<QueryJsonRow> reservoir = new ArrayList<>();
+  private long seen;
+  private int included;
+  private int skipped;
+
+  /**
+   * @param maxValues the most distinct values kept per parameter, bounds the size of the config
+   */
+  public JobHistoryConverter(final int maxValues) {
+    this(maxValues, false, 0, new Random());
+  }
+
+  /**
+   * @param maxValues the most distinct values kept per parameter, bounds the size of the config
+   * @param exact only merge identical query texts instead of templating their literals into
+   *     parameters
+   * @param sample keep a uniform random sample of this many jobs, 0 keeps every job
+   * @param random source of the sample
+   */
+  public JobHistoryConverter(
+      final int maxValues, final boolean exact, final int sample, final Random random) {
+    this.maxValues = maxValues;
+    this.exact = exact;
+    this.sample = sample;
+    this.random = random;
+  }
+
+  /**
+   * reads an export, can be called for several files to combine them
+   *
+   * @param file csv or json export, optionally gzipped
+   * @throws IOException when unable to read or parse the file
+   */
+  public void read(final Path file) throws IOException {
+    final String name = file.getFileName().toString().toLowerCase();
+    try (InputStream raw = Files.newInputStream(file);
+        InputStream in = name.endsWith(".gz") ? new GZIPInputStream(raw) : raw;
+        BufferedReader reader =
+            new BufferedReader(new InputStreamReader(in, StandardCharsets.UTF_8))) {
+      if (name.endsWith(".csv") || name.endsWith(".csv.gz")) {
+        readCsv(reader);
+      } else if (name.endsWith(".json") || name.endsWith(".json.gz")) {
+        readJson(reader);
+      } else {
+        throw new IOException("unsupported export " + file + ", expected a .csv or .json file");
+      }
+    }
+    if (sample > 0) {
+      logger.info(
+          String.format(
+              "read %s, %d queries included, %d skipped, %d sampled so far",
+              file, included, skipped, reservoir.size()));
+    } else {
+      logger.info(
+          String.format(
+              "read %s, %d queries included, %d skipped, %d distinct queries so far",
+              file, included, skipped, templates.size()));
+    }
+  }
+
+  private void readCsv(final BufferedReader reader) throws IOException {
+    final List<List<String>> records = parseCsv(reader);
+    if (records.isEmpty()) {
+      return;
+    }
+    final Map<String, Integer> columns = new LinkedHashMap<>();
+    for (int i = 0; i < records.get(0).size(); i++) {
+      columns.put(normalize(records.get(0).get(i)), i);
+    }
+    final Integer query = find(columns, queryFields);
+    if (query == null) {
+      throw new IOException(
+          "no query column in the csv header, expected one of " + queryFields + ": " + columns);
+    }
+    final Integer context = find(columns, contextFields);
+    final Integer user = find(columns, userFields);
+    final Integer outcome = find(columns, outcomeFields);
+    for (final List<String> record : records.subList(1, records.size())) {
+      final QueryJsonRow row = new QueryJsonRow();
+      row.setQueryText(cell(record, query));
+      row.setContext(cell(record, context));
+      row.setUsername(cell(record, user));
+      row.setOutcome(cell(record, outcome));
+      add(row);
+    }
+  }
+
+  private void readJson(final BufferedReader reader) throws IOException {
+    final MappingIterator<JsonNode> nodes =
+        new ObjectMapper().readerFor(JsonNode.class).readValues(reader);
+    while (nodes.hasNext()) {
+      final JsonNode node = nodes.next();
+      if (node.isArray()) {
+        node.forEach(this::addJson);
+      } else if (node.has("rows") && node.get("rows").isArray()) {
+        node.get("rows").forEach(this::addJson);
+      } else {
+        addJson(node);
+      }
+    }
+  }
+
+  private void addJson(final JsonNode node) {
+    final Map<String, String> fields = new LinkedHashMap<>();
+    final Iterator<Map.Entry<String, JsonNode>> it = node.fields();
+    while (it.hasNext()) {
+      final Map.Entry<String, JsonNode> field = it.next();
+      if (!field.getValue().isNull()) {
+        fields.put(
+            normalize(field.getKey()),
+            field.getValue().isValueNode()
+                ? field.getValue().asText()
+                : field.getValue().toString());
+      }
+    }
+    final QueryJsonRow row = new QueryJsonRow();
+    row.setQueryText(first(fields, queryFields));
+    row.setContext(first(fields, contextFields));
+    row.setUsername(first(fields, userFields));
+    row.setOutcome(first(fields, outcomeFields));
+    add(row);
+  }
+
+  private void add(final QueryJsonRow row) {
+    if (StressExec.skipQuery(row)) {
+      skipped++;
+      return;
+    }
+    included++;
+    if (sample > 0) {
+      // every job seen so far had the same chance to be kept
+      seen++;
+      if (reservoir.size() < sample) {
+        reservoir.add(row);
+      } else {
+        final long slot = (long) (random.nextDouble() * seen);
+        if (slot < sample) {
+          reservoir.set((int) slot, row);
+        }
+      }
+      return;
+    }
+    group(row);
+  }
+
+  private void group(final QueryJsonRow row) {
+    final SqlContext context = SqlContext.parse(row.getContext());
+    final String sql = row.getQueryText().trim();
+    final Template parsed = exact ? Template.exact(sql, context) : Template.parse(sql, context);
+    final Template existing = templates.get(parsed.key());
+    if (existing == null) {
+      parsed.observe(parsed.literals, maxValues);
+      templates.put(parsed.key(), parsed);
+    } else {
+      existing.observe(parsed.literals, maxValues);
+    }
+  }
+
+  /**
+   * the workload, most frequent queries first
+   *
+   * @param minCount queries seen fewer times are left out
+   * @param top keep only this many queries, 0 keeps all of them
+   * @return the queries with frequencies and parameters
+   */
+  public List<QueryConfig> queries(final int minCount, final int top) {
+    if (!reservoir.isEmpty()) {
+      for (final QueryJsonRow row : reservoir) {
+        group(row);
+      }
+      reservoir.clear();
+    }
+    final List<Template> sorted = new ArrayList<>(templates.values());
+    sorted.sort((a, b) -> Integer.compare(b.count, a.count));
+    final List<QueryConfig> queries = new ArrayList<>();
+    for (final Template template : sorted) {
+      if (template.count < minCount || (top > 0 && queries.size() >= top)) {
+        break;
+      }
+      queries.add(template.toQueryConfig());
+    }
+    return queries;
+  }
+
+  /**
+   * splits csv into records, quoted fields may contain commas, doubled quotes and line breaks
+   *
+   * @param reader the csv
+   * @return the records including the header
+   * @throws IOException when unable to read
+   */
+  static List<List<String>> parseCsv(final BufferedReader reader) throws IOException {
+    final List<List<String>> records = new ArrayList<>();
+    List<String> record = new ArrayList<>();
+    final StringBuilder field = new StringBuilder();
+    boolean quoted = false;
+    boolean any = false;
+    int c = reader.read();
+    if (c == '\uFEFF') {
+      c = reader.read();
+    }
+    for (; c != -1; c = reader.read()) {
+      any = true;
+      if (quoted) {
+        if (c == '"') {
+          reader.mark(1);
+          if (reader.read() == '"') {
+            field.append('"');
+          } else {
+            reader.reset();
+            quoted = false;
+          }
+        } else {
+          field.append((char) c);
+        }
+      } else if (c == '"') {
+        quoted = true;
+      } else if (c == ',') {
+        record.add(field.toString());
+        field.setLength(0);
+      } else if (c == '\n') {
+        record.add(field.toString());
+        field.setLength(0);
+        records.add(record);
+        record = new ArrayList<>();
+        any = false;
+      } else if (c != '\r') {
+        field.append((char) c);
+      }
+    }
+    if (any) {
+      record.add(field.toString());
+      records.add(record);
+    }
+    return records;
+  }
+
+  private static String normalize(final String name) {
+    return name.trim().toLowerCase().replace("_", "");
+  }
+
+  private static Integer find(final Map<String, Integer> columns, final List<String> names) {
+    for (final String name : names) {
+      if (columns.containsKey(name)) {
+        return columns.get(name);
+      }
+    }
+    return null;
+  }
+
+  private static String first(final Map<String, String> fields, final List<String> names) {
+    for (final String name : names) {
+      if (fields.containsKey(name)) {
+        return fields.get(name);
+      }
+    }
+    return null;
+  }
+
+  private static String cell(final List<String> record, final Integer column) {
+    if (column == null || column >= record.size() || record.get(column).isEmpty()) {
+      return null;
+    }
+    return record.get(column);
+  }
+
+  /** a query with its literals cut out, the text between the literals is what gets grouped */
+  static class Template {
+    private final List<String> segments = new ArrayList<>();
+    private final List<Literal> literals = new ArrayList<>();
+    private final SqlContext context;
+    private final List<Set<Object>> values = new ArrayList<>();
+    private int count;
+
+    private Template(final SqlContext context) {
+      this.context = context;
+    }
+
+    /**
+     * the query as one segment without parameters, so only identical texts are grouped
+     *
+     * @param sql the query text
+     * @param context the context the query ran in
+     * @return the template
+     */
+    static Template exact(final String sql, final SqlContext context) {
+      final Template template = new Template(context);
+      template.segments.add(sql);
+      return template;
+    }
+
+    /**
+     * finds the string and number literals of a query, skipping comments and quoted identifiers
+     *
+     * @param sql the query text
+     * @param context the context the query ran in
+     * @return the template
+     */
+    static Template parse(final String sql, final SqlContext context) {
+      final Template template = new Template(context);
+      final StringBuilder segment = new StringBuilder();
+      int i = 0;
+      while (i < sql.length()) {
+        final char c = sql.charAt(i);
+        final int end;
+        if (sql.startsWith("--", i)) {
+          final int newline = sql.indexOf('\n', i);
+          end = newline < 0 ? sql.length() : newline;
+        } else if (sql.startsWith("/*", i)) {
+          final int close = sql.indexOf("*/", i + 2);
+          end = close < 0 ? sql.length() : close + 2;
+        } else if (c == '"') {
+          end = closingQuote(sql, i, '"');
+        } else if (c == '\'') {
+          end = closingQuote(sql, i, '\'');
+          final String raw = sql.substring(i, end);
+          final String inner = raw.length() > 1 ? raw.substring(1, raw.length() - 1) : "";
+          template.cut(segment, new Literal(raw, inner.replace("''", "'"), true));
+          i = end;
+          continue;
+        } else if (Character.isDigit(c) && (i == 0 || !isWordOrDot(sql.charAt(i - 1)))) {
+          end = numberEnd(sql, i);
+          if (end == sql.length() || !isWordOrDot(sql.charAt(end))) {
+            final String raw = sql.substring(i, end);
+            template.cut(segment, new Literal(raw, ConfigWizard.parseValue(raw), false));
+            i = end;
+            continue;
+          }
+        } else {
+          end = i + 1;
+        }
+        segment.append(sql, i, end);
+        i = end;
+      }
+      template.segments.add(segment.toString());
+      return template;
+    }
+
+    private void cut(final StringBuilder segment, final Literal literal) {
+      segments.add(segment.toString());
+      segment.setLength(0);
+      literals.add(literal);
+    }
+
+    private static int closingQuote(final String sql, final int start, final char quote) {
+      int i = start + 1;
+      while (i < sql.length()) {
+        if (sql.charAt(i) == quote) {
+          if (i + 1 < sql.length() && sql.charAt(i + 1) == quote) {
+            i += 2;
+            continue;
+          }
+          return i + 1;
+        }
+        i++;
+      }
+      return sql.length();
+    }
+
+    private static int numberEnd(final String sql, final int start) {
+      int i = start;
+      while (i < sql.length() && Character.isDigit(sql.charAt(i))) {
+        i++;
+      }
+      if (i + 1 < sql.length() && sql.charAt(i) == '.' && Character.isDigit(sql.charAt(i + 1))) {
+        i++;
+        while (i < sql.length() && Character.isDigit(sql.charAt(i))) {
+          i++;
+        }
+      }
+      return i;
+    }
+
+    private static boolean isWordOrDot(final char c) {
+      return Character.isLetterOrDigit(c) || c == '_' || c == '.';
+    }
+
+    private String key() {
+      final StringBuilder key = new StringBuilder(context.getParts().toString());
+      for (int i = 0; i < segments.size(); i++) {
+        // 'x' and x are different queries even when the text around them is the same
+        key.append(i == 0 ? "" : literals.get(i - 1).quoted ? "\u0000'" : "\u0000")
+            .append(segments.get(i));
+      }
+      return key.toString();
+    }
+
+    private void observe(final List<Literal> observed, final int maxValues) {
+      count++;
+      for (int i = 0; i < observed.size(); i++) {
+        if (values.size() <= i) {
+          values.add(new LinkedHashSet<>());
+        }
+        if (values.get(i).size() < maxValues) {
+          values.get(i).add(observed.get(i).value);
+        }
+      }
+    }
+
+    private QueryConfig toQueryConfig() {
+      final StringBuilder sql = new StringBuilder(segments.get(0));
+      final Map<String, List<Object>> parameters = new LinkedHashMap<>();
+      for (int i = 0; i < literals.size(); i++) {
+        final Literal literal = literals.get(i);
+        if (values.get(i).size() <= 1) {
+          // the same value every time, keep the query readable
+          sql.append(literal.raw);
+        } else {
+          final String name = "p" + (parameters.size() + 1);
+          parameters.put(name, new ArrayList<>(values.get(i)));
+          // the run only replaces parameters that are separate words
+          sql.append(literal.quoted ? " ':" + name + "' " : " :" + name + " ");
+        }
+        sql.append(segments.get(i + 1));
+      }
+      final QueryConfig query = new QueryConfig();
+      query.setQuery(sql.toString().trim());
+      query.setFrequency(count);
+      query.setSqlContext(context.getParts());
+      if (!parameters.isEmpty()) {
+        query.setParameters(parameters);
+      }
+      return query;
+    }
+  }
+
+  /** a literal as written in the query and the value it stands for */
+  private static class Literal {
+    private final String raw;
+    private final Object value;
+    private final boolean quoted;
+
+    private Literal(final String raw, final Object value, final boolean quoted) {
+      this.raw = raw;
+      this.value = value;
+      this.quoted = quoted;
+    }
+  }
+}
@@ -0,0 +1,112 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.BufferedWriter;
+import java.io.File;
+import java.io.IOException;
+import java.io.OutputStreamWriter;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.logging.Logger;
+
+/**
+ * JobManifest writes a csv line for every statement of the run as it completes, so the jobs of a
+ * run can be found again in the dremio job history and profiles pulled after the fact. Job ids are
+ * only known over HTTP, over JDBC the job_id column is empty.
+ */
+public class JobManifest implements AutoCloseable {
+
+  private static final Logger logger = Logger.getLogger(JobManifest.class.getName());
+
+  private final File file;
+  private final BufferedWriter writer;
+  private final List<String> failedJobIds = new ArrayList<>();
+
+  /**
+   * @param file csv file to write, replaced when it exists
+   * @throws IOException when unable to create the file
+   */
+  public JobManifest(final File file) throws IOException {
+    this.file = file;
+    this.writer =
+        new BufferedWriter(
+            new OutputStreamWriter(Files.newOutputStream(file.toPath()), StandardCharsets.UTF_8));
+    writer.write("start,label,job_id,successful,duration_ms,url");
+    writer.newLine();
+    writer.flush();
+  }
+
+  public File getFile() {
+    return file;
+  }
+
+  /**
+   * appends a statement, flushed right away so the manifest is complete when the run is killed
+   *
+   * @param start when the statement was submitted
+   * @param label query group name or query text
+   * @param jobId job id, null when unknown
+   * @param successful whether the job succeeded
+   * @param durationMS time the statement took
+   * @param url url of the coordinator the statement ran on
+   */
+  public synchronized void record(
+      final Instant start,
+      final String label,
+      final String jobId,
+      final boolean successful,
+      final long durationMS,
+      final String url) {
+    if (!successful && jobId != null) {
+      failedJobIds.add(jobId);
+    }
+    try {
+      writer.write(
+          String.join(
+              ",",
+              start.toString(),
+              quote(label),
+              jobId == null ? "" : jobId,
+              String.valueOf(successful),
+              String.valueOf(durationMS),
+              quote(Redact.url(url))));
+      writer.newLine();
+      writer.flush();
+    } catch (IOException e) {
+      // losing a line of the manifest must not fail the statement
+      logger.fine(() -> String.format("unable to write to %s: %s", file, e.getMessage()));
+    }
+  }
+
+  /** @return ids of the failed jobs in the order they completed */
+  public synchronized List<String> failedJobIds() {
+    return new ArrayList<>(failedJobIds);
+  }
+
+  @Override
+  public synchronized void close() throws IOException {
+    writer.close();
+  }
+
+  private static String quote(final String value) {
+    if (value == null) {
+      return "";
+    }
+    return "\"" + value.replace("\"", "\"\"") + "\"";
+  }
+}
@@ -13,6 +13,8 @@
  */
 package com.dremio.support.diagnostics.stress;
 
+import java.util.Map;
+
 /** response from checking the job status */
 public class JobStatusResponse {
 
@@ -32,6 +34,31 @@ public class JobStatusResponse {
     this.status = status;
   }
 
+  /**
+   * time in milliseconds the job spent in each phase, only populated once the job api reports the
+   * phase timestamps
+   *
+   * @return phase name to milliseconds or null
+   */
+  public Map<String, Long> getPhases() {
+    return phases;
+  }
+
+  public void setPhases(Map<String, Long> phases) {
+    this.phases = phases;
+  }
+
+  /** @return true when a reflection was chosen for the job, null when unknown */
+  public Boolean getAccelerated() {
+    return accelerated;
+  }
+
+  public void setAccelerated(Boolean accelerated) {
+    this.accelerated = accelerated;
+  }
+
   private String message;
   private String status;
+  private Map<String, Long> phases;
+  private Boolean accelerated;
 }
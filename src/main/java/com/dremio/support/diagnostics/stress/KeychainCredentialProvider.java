@@ -0,0 +1,116 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.ByteArrayOutputStream;
+import java.io.IOException;
+import java.io.InputStream;
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.List;
+import java.util.concurrent.TimeUnit;
+
+/**
+ * KeychainCredentialProvider reads the password from the keychain of the OS: the login keychain on
+ * macOS with security, the secret service (GNOME keyring, KWallet) on Linux with secret-tool. The
+ * entry is looked up by service and, when a user is given, by account.
+ */
+public class KeychainCredentialProvider implements CredentialProvider {
+
+  private static final long TIMEOUT_SECONDS = 30;
+  private final String service;
+
+  /** @param service the service the password is stored under */
+  public KeychainCredentialProvider(final String service) {
+    this.service = service;
+  }
+
+  @Override
+  public String password(final String user) throws IOException {
+    final String os = System.getProperty("os.name", "").toLowerCase();
+    final List<String> command = new ArrayList<>();
+    if (os.contains("mac")) {
+      command.addAll(Arrays.asList("security", "find-generic-password", "-w", "-s", service));
+      if (user != null) {
+        command.addAll(Arrays.asList("-a", user));
+      }
+    } else if (os.contains("linux")) {
+      command.addAll(Arrays.asList("secret-tool", "lookup", "service", service));
+      if (user != null) {
+        command.addAll(Arrays.asList("user", user));
+      }
+    } else {
+      throw new IOException("keychain: is supported on macOS and on Linux with secret-tool");
+    }
+    final Process process =
+        new ProcessBuilder(command).redirectError(ProcessBuilder.Redirect.INHERIT).start();
+    final ByteArrayOutputStream stdout = new ByteArrayOutputStream();
+    // read on a thread of its own so a tool waiting on an unlock prompt cannot outlast the timeout
+    final Thread reader =
+        new Thread(
+            () -> {
+              try (InputStream stream = process.getInputStream()) {
+                copy(stream, stdout);
+              } catch (IOException e) {
+                // the process was destroyed, its exit value reports the failure
+              }
+            },
+            "keychain-reader");
+    reader.setDaemon(true);
+    reader.start();
+    try {
+      if (!process.waitFor(TIMEOUT_SECONDS, TimeUnit.SECONDS)) {
+        process.destroyForcibly();
+        throw new IOException(String.format("%s timed out", command.get(0)));
+      }
+      // a child left running by the tool can keep the output open
+      reader.join(TimeUnit.SECONDS.toMillis(TIMEOUT_SECONDS));
+    } catch (InterruptedException e) {
+      process.destroyForcibly();
+      Thread.currentThread().interrupt();
+      throw new IOException("interrupted reading the keychain", e);
+    }
+    if (reader.isAlive()) {
+      throw new IOException(String.format("%s did not close its output", command.get(0)));
+    }
+    final String output = text(stdout);
+    // the password is left out, the output may be part of it
+    if (process.exitValue() != 0 || output.isEmpty()) {
+      throw new IOException(
+          String.format(
+              "no password for service %s%s in the keychain, %s exited with %d",
+              service,
+              user != null ? " and user " + user : "",
+              command.get(0),
+              process.exitValue()));
+    }
+    return output;
+  }
+
+  private static void copy(final InputStream stream, final ByteArrayOutputStream bytes)
+      throws IOException {
+    final byte[] buffer = new byte[1024];
+    int read;
+    while ((read = stream.read(buffer)) != -1) {
+      bytes.write(buffer, 0, read);
+    }
+  }
+
+  private static String text(final ByteArrayOutputStream bytes) {
+    final String output = new String(bytes.toByteArray(), StandardCharsets.UTF_8);
+    // both tools end the password with a line break
+    return output.endsWith("\n") ? output.substring(0, output.length() - 1) : output;
+  }
+}
@@ -0,0 +1,84 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.Collection;
+import java.util.List;
+import java.util.Set;
+import java.util.TreeSet;
+
+/**
+ * LabelFilter runs a subset of a stress.json, so one canonical workload can serve many test
+ * variations without editing it. A query carries the labels of its own and those of the query
+ * group it references. Labels are compared ignoring case.
+ */
+public class LabelFilter {
+
+  private final Set<String> only;
+  private final Set<String> exclude;
+
+  /**
+   * @param only run only the queries with at least one of these labels, empty runs every query
+   * @param exclude leave out the queries with any of these labels, applied after only
+   */
+  public LabelFilter(final Collection<String> only, final Collection<String> exclude) {
+    this.only = new TreeSet<>(String.CASE_INSENSITIVE_ORDER);
+    this.only.addAll(only);
+    this.exclude = new TreeSet<>(String.CASE_INSENSITIVE_ORDER);
+    this.exclude.addAll(exclude);
+  }
+
+  /** @return true when no filter is set */
+  public boolean isEmpty() {
+    return only.isEmpty() && exclude.isEmpty();
+  }
+
+  /**
+   * @param query a query of the stress.json
+   * @param group the query group the query references, null when it references none
+   * @return true when the query is part of the subset to run
+   */
+  public boolean accepts(final QueryConfig query, final QueryGroup group) {
+    final Set<String> labels = labels(query, group);
+    if (!only.isEmpty() && labels.stream().noneMatch(only::contains)) {
+      return false;
+    }
+    return labels.stream().noneMatch(exclude::contains);
+  }
+
+  /**
+   * @param query a query of the stress.json
+   * @param group the query group the query references, null when it references none
+   * @return the labels of the query and its group, ignoring case
+   */
+  static Set<String> labels(final QueryConfig query, final QueryGroup group) {
+    final Set<String> labels = new TreeSet<>(String.CASE_INSENSITIVE_ORDER);
+    addAll(labels, query.getLabels());
+    if (group != null) {
+      addAll(labels, group.getLabels());
+    }
+    return labels;
+  }
+
+  private static void addAll(final Set<String> labels, final List<String> values) {
+    if (values != null) {
+      labels.addAll(values);
+    }
+  }
+
+  @Override
+  public String toString() {
+    return String.format("only=%s exclude=%s", only, exclude);
+  }
+}
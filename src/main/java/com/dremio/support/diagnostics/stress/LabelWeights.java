@@ -0,0 +1,111 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.security.InvalidParameterException;
+import java.util.Collection;
+import java.util.HashMap;
+import java.util.LinkedHashMap;
+import java.util.Map;
+import java.util.Set;
+import java.util.TreeSet;
+
+/**
+ * LabelWeights replaces the frequency or weight of the stress.json queries carrying a label with a
+ * weight given on the command line, for quick what-if mixes without a new config. When a query
+ * carries several of the labels the one given last wins.
+ */
+public class LabelWeights {
+
+  private final Map<String, Double> weights = new LinkedHashMap<>();
+
+  /**
+   * @param assignments label=weight, such as dashboards=10
+   * @throws InvalidParameterException when an assignment has no label or the weight is not a
+   *     positive number
+   */
+  public LabelWeights(final Collection<String> assignments) {
+    for (final String assignment : assignments) {
+      final int equals = assignment.indexOf('=');
+      if (equals < 1) {
+        throw new InvalidParameterException(
+            String.format("invalid weight '%s', expected label=weight", assignment));
+      }
+      final double weight;
+      try {
+        weight = Double.parseDouble(assignment.substring(equals + 1).trim());
+      } catch (NumberFormatException e) {
+        throw new InvalidParameterException(
+            String.format("invalid weight '%s', expected label=weight", assignment));
+      }
+      if (!(weight > 0) || Double.isInfinite(weight)) {
+        throw new InvalidParameterException(
+            String.format(
+                "weight of %s must be a positive number, leave the label out with --exclude",
+                assignment));
+      }
+      final String label = assignment.substring(0, equals).trim();
+      // given again the label moves to the end so it wins over the labels given before
+      weights.remove(label);
+      weights.put(label, weight);
+    }
+  }
+
+  /** @return true when no weight is overridden */
+  public boolean isEmpty() {
+    return weights.isEmpty();
+  }
+
+  /**
+   * sets the weight of every query carrying one of the labels
+   *
+   * @param config the parsed stress.json
+   * @throws InvalidParameterException when no query carries one of the labels, most likely a typo
+   */
+  public void apply(final StressConfig config) {
+    final Map<String, QueryGroup> groups = new HashMap<>();
+    if (config.getQueryGroups() != null) {
+      for (final QueryGroup group : config.getQueryGroups()) {
+        groups.put(group.getName(), group);
+      }
+    }
+    final Set<String> unused = new TreeSet<>(String.CASE_INSENSITIVE_ORDER);
+    unused.addAll(weights.keySet());
+    if (config.getQueries() != null) {
+      for (final QueryConfig q : config.getQueries()) {
+        final Set<String> labels = LabelFilter.labels(q, groups.get(q.getQueryGroup()));
+        Double weight = null;
+        for (final Map.Entry<String, Double> entry : weights.entrySet()) {
+          if (labels.contains(entry.getKey())) {
+            weight = entry.getValue();
+            unused.remove(entry.getKey());
+          }
+        }
+        if (weight != null) {
+          q.setFrequency(0);
+          q.setWeight(weight);
+        }
+      }
+    }
+    if (!unused.isEmpty()) {
+      throw new InvalidParameterException(
+          String.format("no query of the config has the labels %s", unused));
+    }
+  }
+
+  @Override
+  public String toString() {
+    return weights.toString();
+  }
+}
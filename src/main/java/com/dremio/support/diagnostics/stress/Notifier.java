@@ -0,0 +1,95 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import java.io.IOException;
+import java.io.OutputStream;
+import java.net.HttpURLConnection;
+import java.net.URL;
+import java.nio.charset.StandardCharsets;
+import java.util.Collections;
+import java.util.logging.Logger;
+
+/**
+ * Notifier posts the start, summary and abort of a run to a Slack or Teams incoming webhook, both
+ * accept a json body with a text field. Failing to notify is logged and never fails the run.
+ */
+public class Notifier {
+
+  private static final Logger logger = Logger.getLogger(Notifier.class.getName());
+  private static final int timeoutMS = 10 * 1000;
+
+  private final String webhook;
+
+  /** @param webhook incoming webhook url, when null or empty nothing is sent */
+  public Notifier(final String webhook) {
+    this.webhook = webhook == null || webhook.isEmpty() ? null : webhook;
+  }
+
+  /**
+   * the run started
+   *
+   * @param description what is being run and against which cluster
+   */
+  public void started(final String description) {
+    send("stress run started: " + description);
+  }
+
+  /**
+   * the run finished
+   *
+   * @param summary the stress summary
+   */
+  public void completed(final String summary) {
+    send("stress run completed\n" + summary);
+  }
+
+  /**
+   * the run ended early
+   *
+   * @param reason why the run was aborted
+   */
+  public void aborted(final String reason) {
+    send("stress run aborted: " + reason);
+  }
+
+  private void send(final String text) {
+    if (webhook == null) {
+      return;
+    }
+    try {
+      final byte[] body =
+          new ObjectMapper()
+              .writeValueAsString(Collections.singletonMap("text", text))
+              .getBytes(StandardCharsets.UTF_8);
+      final HttpURLConnection connection = (HttpURLConnection) new URL(webhook).openConnection();
+      connection.setConnectTimeout(timeoutMS);
+      connection.setReadTimeout(timeoutMS);
+      connection.setRequestMethod("POST");
+      connection.setRequestProperty("Content-Type", "application/json");
+      connection.setDoOutput(true);
+      try (OutputStream stream = connection.getOutputStream()) {
+        stream.write(body);
+      }
+      final int code = connection.getResponseCode();
+      if (code < 200 || code > 299) {
+        logger.warning(() -> String.format("notification webhook returned http %d", code));
+      }
+      connection.disconnect();
+    } catch (IOException e) {
+      logger.warning(() -> "unable to send notification " + e.getMessage());
+    }
+  }
+}
@@ -0,0 +1,126 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.databind.DeserializationFeature;
+import com.fasterxml.jackson.databind.JsonNode;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import java.io.IOException;
+import java.security.InvalidParameterException;
+import java.util.ArrayList;
+import java.util.Collection;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.Set;
+import java.util.TreeSet;
+
+/**
+ * ParameterOverrides replaces the values of stress.json parameters given on the command line, so a
+ * pipeline can pass its variables, such as the date range to query, without editing the config.
+ * Every query and query group step defining the parameter gets the new values.
+ */
+public class ParameterOverrides {
+
+  // 2024-01-01 must not be read as the number 2024
+  private static final ObjectMapper mapper =
+      new ObjectMapper().enable(DeserializationFeature.FAIL_ON_TRAILING_TOKENS);
+
+  private final Map<String, List<Object>> values = new LinkedHashMap<>();
+
+  /**
+   * @param assignments name=value, a name given several times gets the list of its values. Numbers
+   *     and booleans keep their type, anything else is a string.
+   * @throws InvalidParameterException when an assignment has no name
+   */
+  public ParameterOverrides(final Collection<String> assignments) {
+    for (final String assignment : assignments) {
+      final int equals = assignment.indexOf('=');
+      if (equals < 1) {
+        throw new InvalidParameterException(
+            String.format("invalid parameter '%s', expected name=value", assignment));
+      }
+      values
+          .computeIfAbsent(assignment.substring(0, equals).trim(), k -> new ArrayList<>())
+          .add(value(assignment.substring(equals + 1)));
+    }
+  }
+
+  /** @return true when no parameter is overridden */
+  public boolean isEmpty() {
+    return values.isEmpty();
+  }
+
+  /**
+   * replaces the values of the overridden parameters in every query and query group step
+   *
+   * @param config the parsed stress.json, with its defaults applied
+   * @throws InvalidParameterException when an overridden parameter is not defined by the config,
+   *     most likely a typo
+   */
+  public void apply(final StressConfig config) {
+    final Set<String> unused = new TreeSet<>(values.keySet());
+    if (config.getQueries() != null) {
+      for (final QueryConfig q : config.getQueries()) {
+        override(q.getParameters(), unused);
+      }
+    }
+    if (config.getQueryGroups() != null) {
+      for (final QueryGroup group : config.getQueryGroups()) {
+        if (group.getQueries() == null) {
+          continue;
+        }
+        for (final QueryGroupMember member : group.getQueries()) {
+          override(member.getParameters(), unused);
+        }
+      }
+    }
+    if (!unused.isEmpty()) {
+      throw new InvalidParameterException(
+          String.format("no query of the config has the parameters %s", unused));
+    }
+  }
+
+  private void override(final Map<String, List<Object>> parameters, final Set<String> unused) {
+    if (parameters == null) {
+      return;
+    }
+    for (final Map.Entry<String, List<Object>> value : values.entrySet()) {
+      if (parameters.containsKey(value.getKey())) {
+        parameters.put(value.getKey(), new ArrayList<>(value.getValue()));
+        unused.remove(value.getKey());
+      }
+    }
+  }
+
+  private static Object value(final String text) {
+    try {
+      final JsonNode node = mapper.readTree(text);
+      if (node != null && node.isNumber()) {
+        return node.numberValue();
+      }
+      if (node != null && node.isBoolean()) {
+        return node.booleanValue();
+      }
+    } catch (IOException e) {
+      // not a number or boolean
+    }
+    return text;
+  }
+
+  @Override
+  public String toString() {
+    return values.toString();
+  }
+}
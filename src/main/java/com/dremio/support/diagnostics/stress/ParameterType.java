@@ -0,0 +1,141 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.math.BigDecimal;
+import java.security.InvalidParameterException;
+import java.sql.Date;
+import java.sql.Timestamp;
+import java.util.Locale;
+
+/**
+ * ParameterType controls how a parameter value is rendered into sql. Values are escaped so a value
+ * containing a single quote still produces valid sql, RAW is the opt-in escape hatch for values
+ * such as table names that must be inserted verbatim.
+ */
+public enum ParameterType {
+  STRING,
+  NUMBER,
+  BOOLEAN,
+  DATE,
+  TIMESTAMP,
+  RAW;
+
+  /**
+   * looks up the type by name ignoring case, when no name is provided the type is inferred from
+   * the value
+   *
+   * @param name type name from the stress.json, may be null
+   * @param value value used to infer the type
+   * @return the parameter type
+   */
+  public static ParameterType of(final String name, final Object value) {
+    if (name == null || name.isEmpty()) {
+      if (value instanceof Number) {
+        return NUMBER;
+      }
+      if (value instanceof Boolean) {
+        return BOOLEAN;
+      }
+      return STRING;
+    }
+    try {
+      return ParameterType.valueOf(name.toUpperCase(Locale.ROOT));
+    } catch (IllegalArgumentException e) {
+      throw new InvalidParameterException(
+          String.format(
+              "unknown parameter type '%s', supported types are string, number, boolean, date,"
+                  + " timestamp and raw",
+              name));
+    }
+  }
+
+  /**
+   * checks the value can be rendered as this type, so broken parameters are reported at startup
+   * instead of failing mid run
+   *
+   * @param value value to check
+   * @throws InvalidParameterException when the value does not match the type
+   */
+  public void validate(final Object value) {
+    toBindValue(value);
+  }
+
+  /**
+   * renders the value as sql
+   *
+   * @param value the parameter value
+   * @param quoted true when the token was already wrapped in single quotes in the query text
+   * @return sql text to substitute for the token
+   */
+  public String toSql(final Object value, final boolean quoted) {
+    final Object bindValue = toBindValue(value);
+    final String text;
+    if (bindValue instanceof BigDecimal) {
+      text = ((BigDecimal) bindValue).toPlainString();
+    } else {
+      text = String.valueOf(bindValue);
+    }
+    if (this == RAW) {
+      return quoted ? "'" + text + "'" : text;
+    }
+    final String escaped = "'" + text.replace("'", "''") + "'";
+    if (quoted) {
+      return escaped;
+    }
+    switch (this) {
+      case NUMBER:
+      case BOOLEAN:
+        return text;
+      case DATE:
+        return "DATE " + escaped;
+      case TIMESTAMP:
+        return "TIMESTAMP " + escaped;
+      default:
+        return escaped;
+    }
+  }
+
+  /**
+   * converts the value to the java type bound to a prepared statement
+   *
+   * @param value the parameter value
+   * @return the value to bind
+   * @throws InvalidParameterException when the value does not match the type
+   */
+  public Object toBindValue(final Object value) {
+    final String text = String.valueOf(value);
+    try {
+      switch (this) {
+        case NUMBER:
+          return new BigDecimal(text);
+        case BOOLEAN:
+          if (!"true".equalsIgnoreCase(text) && !"false".equalsIgnoreCase(text)) {
+            throw new IllegalArgumentException("not a boolean");
+          }
+          return Boolean.valueOf(text);
+        case DATE:
+          return Date.valueOf(text);
+        case TIMESTAMP:
+          return Timestamp.valueOf(text);
+        default:
+          return text;
+      }
+    } catch (IllegalArgumentException e) {
+      throw new InvalidParameterException(
+          String.format(
+              "parameter value '%s' is not a valid %s", text, name().toLowerCase(Locale.ROOT)));
+    }
+  }
+}
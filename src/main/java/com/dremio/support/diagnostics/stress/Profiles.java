@@ -0,0 +1,79 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.io.IOException;
+import java.io.InputStream;
+import java.nio.file.Files;
+import java.nio.file.StandardCopyOption;
+import java.security.InvalidParameterException;
+import java.util.Arrays;
+import java.util.Collections;
+import java.util.List;
+import java.util.Locale;
+
+/**
+ * Profiles are the stress.json workloads bundled in the jar. They only query the Samples source
+ * so a run needs nothing but a cluster, use --provision when the cluster does not have the source
+ * yet.
+ */
+public final class Profiles {
+
+  /** light: a trickle of cheap lookups, useful as a smoke test */
+  public static final String LIGHT = "light";
+
+  /** dashboard: many small parameterized aggregations like a BI tool refreshing charts */
+  public static final String DASHBOARD = "dashboard";
+
+  /** etl-mixed: dashboard traffic next to large scans and CTAS into $scratch */
+  public static final String ETL_MIXED = "etl-mixed";
+
+  private static final List<String> NAMES =
+      Collections.unmodifiableList(Arrays.asList(LIGHT, DASHBOARD, ETL_MIXED));
+
+  private Profiles() {}
+
+  /** @return the names of the bundled profiles */
+  public static List<String> names() {
+    return NAMES;
+  }
+
+  /**
+   * copies the stress.json of the profile to a temp file so it can be run like any other config,
+   * the file is named after the profile so the run summary shows which profile was used
+   *
+   * @param name profile name, case is ignored
+   * @return the stress.json of the profile, deleted on exit
+   * @throws InvalidParameterException when there is no such profile
+   * @throws IOException when unable to write the temp file
+   */
+  public static File extract(final String name) throws IOException {
+    final String profile = name.trim().toLowerCase(Locale.ROOT);
+    if (!NAMES.contains(profile)) {
+      throw new InvalidParameterException(
+          String.format(
+              "unknown profile '%s', available profiles are %s", name, String.join(", ", NAMES)));
+    }
+    final File file = File.createTempFile("dremio-stress-" + profile + "-", ".json");
+    file.deleteOnExit();
+    try (InputStream in = Profiles.class.getResourceAsStream("/profiles/" + profile + ".json")) {
+      if (in == null) {
+        throw new IOException("profile " + profile + " is missing from the jar");
+      }
+      Files.copy(in, file.toPath(), StandardCopyOption.REPLACE_EXISTING);
+    }
+    return file;
+  }
+}
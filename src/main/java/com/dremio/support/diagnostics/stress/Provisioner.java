@@ -0,0 +1,92 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.IOException;
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.logging.Level;
+import java.util.logging.Logger;
+
+/**
+ * Provisioner creates the space and the Samples source the example configs use, so they work
+ * against a fresh cluster. Only what it created is removed afterwards, existing entities are left
+ * as they are.
+ */
+public class Provisioner {
+
+  private static final Logger logger = Logger.getLogger(Provisioner.class.getName());
+
+  private final DremioV3Api api;
+  // ids and names of the entities created, removed in reverse order
+  private final Map<String, String> created = new LinkedHashMap<>();
+
+  /** @param api rest api of the cluster to provision */
+  public Provisioner(final DremioV3Api api) {
+    this.api = api;
+  }
+
+  /**
+   * creates the space and the Samples source when they do not exist
+   *
+   * @param spaceName name of the space
+   * @throws IOException when an entity could not be created
+   */
+  public void setUp(final String spaceName) throws IOException {
+    final Map<String, Object> space = new LinkedHashMap<>();
+    space.put("entityType", "space");
+    space.put("name", spaceName);
+    ensure(space);
+    // the public bucket Dremio adds as its Samples source
+    final Map<String, Object> config = new LinkedHashMap<>();
+    config.put("credentialType", "NONE");
+    config.put("externalBucketList", Collections.singletonList("samples.dremio.com"));
+    config.put("secure", false);
+    config.put("propertyList", Collections.emptyList());
+    final Map<String, Object> samples = new LinkedHashMap<>();
+    samples.put("entityType", "source");
+    samples.put("name", "Samples");
+    samples.put("type", "S3");
+    samples.put("config", config);
+    ensure(samples);
+  }
+
+  private void ensure(final Map<String, Object> entity) throws IOException {
+    final String name = String.valueOf(entity.get("name"));
+    if (api.getCatalogEntity(Collections.singletonList(name)) != null) {
+      logger.info(() -> String.format("%s %s exists, leaving it", entity.get("entityType"), name));
+      return;
+    }
+    created.put(api.createCatalogEntity(entity), name);
+    System.out.printf("provisioned %s %s%n", entity.get("entityType"), name);
+  }
+
+  /** removes the entities created by setUp, failures are logged so every entity is attempted */
+  public void tearDown() {
+    final List<String> ids = new ArrayList<>(created.keySet());
+    Collections.reverse(ids);
+    for (final String id : ids) {
+      try {
+        api.deleteCatalogEntity(id);
+        System.out.printf("removed provisioned %s%n", created.get(id));
+      } catch (IOException e) {
+        logger.log(Level.WARNING, "unable to remove provisioned " + created.get(id), e);
+      }
+    }
+    created.clear();
+  }
+}
@@ -13,11 +13,24 @@
  */
 package com.dremio.support.diagnostics.stress;
 
-import java.util.Collection;
+import java.util.List;
+import java.util.Map;
 
 public class Query {
   private String queryText;
-  private Collection<String> context;
+  private String label;
+  private SqlContext context;
+  private boolean expectFailure;
+  private List<Object> bindParameters;
+  private String captureResult;
+  private Map<String, Object> capturedParameters;
+  private Long timeoutMS;
+  private CatalogOperation catalogOperation;
+  private List<String> catalogPath;
+  private Map<String, Object> catalogFormat;
+  private Long intendedStartMS;
+  private String engine;
+  private boolean fuzzed;
 
   public String getQueryText() {
     return queryText;
@@ -27,11 +40,154 @@ public class Query {
     this.queryText = queryText;
   }
 
-  public Collection<String> getContext() {
+  /** @return short description of the configured query, used to aggregate statistics */
+  public String getLabel() {
+    return label;
+  }
+
+  public void setLabel(String label) {
+    this.label = label;
+  }
+
+  public SqlContext getContext() {
     return context;
   }
 
-  public void setContext(Collection<String> context) {
+  public void setContext(SqlContext context) {
     this.context = context;
   }
+
+  public boolean isExpectFailure() {
+    return expectFailure;
+  }
+
+  public void setExpectFailure(boolean expectFailure) {
+    this.expectFailure = expectFailure;
+  }
+
+  /**
+   * values to bind to the ? placeholders of the query text, null when the query is not run as a
+   * prepared statement
+   *
+   * @return values in placeholder order
+   */
+  public List<Object> getBindParameters() {
+    return bindParameters;
+  }
+
+  public void setBindParameters(List<Object> bindParameters) {
+    this.bindParameters = bindParameters;
+  }
+
+  /** @return variable name to store the first result value in, or null */
+  public String getCaptureResult() {
+    return captureResult;
+  }
+
+  public void setCaptureResult(String captureResult) {
+    this.captureResult = captureResult;
+  }
+
+  /** @return parameter values chosen for this query that become variables once it succeeds */
+  public Map<String, Object> getCapturedParameters() {
+    return capturedParameters;
+  }
+
+  public void setCapturedParameters(Map<String, Object> capturedParameters) {
+    this.capturedParameters = capturedParameters;
+  }
+
+  /** @return how long to wait for the statement, null uses the timeout of the connection */
+  public Long getTimeoutMS() {
+    return timeoutMS;
+  }
+
+  public void setTimeoutMS(Long timeoutMS) {
+    this.timeoutMS = timeoutMS;
+  }
+
+  /** @return the catalog rest api call to make instead of running the query text, or null */
+  public CatalogOperation getCatalogOperation() {
+    return catalogOperation;
+  }
+
+  public void setCatalogOperation(CatalogOperation catalogOperation) {
+    this.catalogOperation = catalogOperation;
+  }
+
+  public List<String> getCatalogPath() {
+    return catalogPath;
+  }
+
+  public void setCatalogPath(List<String> catalogPath) {
+    this.catalogPath = catalogPath;
+  }
+
+  public Map<String, Object> getCatalogFormat() {
+    return catalogFormat;
+  }
+
+  public void setCatalogFormat(Map<String, Object> catalogFormat) {
+    this.catalogFormat = catalogFormat;
+  }
+
+  /**
+   * @return epoch millis the statement was due to start in an open loop run such as a paced replay,
+   *     null when it starts whenever a worker is free
+   */
+  public Long getIntendedStartMS() {
+    return intendedStartMS;
+  }
+
+  public void setIntendedStartMS(Long intendedStartMS) {
+    this.intendedStartMS = intendedStartMS;
+  }
+
+  /** @return Dremio Cloud engine to run the statement on, null for the routing rules */
+  public String getEngine() {
+    return engine;
+  }
+
+  public void setEngine(String engine) {
+    this.engine = engine;
+  }
+
+  /** @return true when --fuzz-rate mutated the query text, its failures are not real failures */
+  public boolean isFuzzed() {
+    return fuzzed;
+  }
+
+  public void setFuzzed(boolean fuzzed) {
+    this.fuzzed = fuzzed;
+  }
+
+  /**
+   * copies the query so it can be run again without sharing the variables substituted into the
+   * query text
+   *
+   * @return a copy of the query
+   */
+  public Query copy() {
+    final Query copy = new Query();
+    copy.setQueryText(queryText);
+    copy.setLabel(label);
+    copy.setContext(context);
+    copy.setExpectFailure(expectFailure);
+    copy.setBindParameters(bindParameters);
+    copy.setCaptureResult(captureResult);
+    copy.setCapturedParameters(capturedParameters);
+    copy.setTimeoutMS(timeoutMS);
+    copy.setCatalogOperation(catalogOperation);
+    copy.setCatalogPath(catalogPath);
+    copy.setCatalogFormat(catalogFormat);
+    copy.setIntendedStartMS(intendedStartMS);
+    copy.setEngine(engine);
+    copy.setFuzzed(fuzzed);
+    return copy;
+  }
+
+  @Override
+  public String toString() {
+    return "Query{queryText='" + queryText + "', context=" + context + '}';
+  }
 }
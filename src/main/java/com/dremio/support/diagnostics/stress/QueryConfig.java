@@ -13,6 +13,7 @@
  */
 package com.dremio.support.diagnostics.stress;
 
+import com.fasterxml.jackson.annotation.JsonIgnore;
 import java.util.List;
 import java.util.Map;
 
@@ -21,8 +22,26 @@ public class QueryConfig {
   private String query;
   private String queryGroup;
   private int frequency;
+  // float alternative to frequency, e.g. 0.5 next to 99.5 for half a percent of the workload
+  private Double weight;
   private Map<String, List<Object>> parameters;
+  private Map<String, String> parameterTypes;
   private List<String> sqlContext;
+  private boolean expectFailure;
+  private boolean cacheBuster;
+  private String timeout;
+  private String p95Max;
+  private String maxDuration;
+  private String startAfter;
+  private List<String> labels;
+  private String engine;
+  private Integer maxExecutions;
+  private String catalog;
+  private List<String> catalogPath;
+  private Map<String, Object> catalogFormat;
+  // epoch milliseconds the replayed query originally started at, not part of stress.json
+  @JsonIgnore private Long replayStartMS;
+  @JsonIgnore private Long replayFinishMS;
 
   public String getQuery() {
     return query;
@@ -48,6 +67,14 @@ public class QueryConfig {
     this.frequency = frequency;
   }
 
+  public Double getWeight() {
+    return weight;
+  }
+
+  public void setWeight(Double weight) {
+    this.weight = weight;
+  }
+
   public Map<String, List<Object>> getParameters() {
     return parameters;
   }
@@ -56,6 +83,20 @@ public class QueryConfig {
     this.parameters = parameters;
   }
 
+  /**
+   * optional type per parameter name (string, number, boolean, date, timestamp or raw), parameters
+   * without a type are inferred from their json value
+   *
+   * @return map of parameter name to type name
+   */
+  public Map<String, String> getParameterTypes() {
+    return parameterTypes;
+  }
+
+  public void setParameterTypes(Map<String, String> parameterTypes) {
+    this.parameterTypes = parameterTypes;
+  }
+
   public List<String> getSqlContext() {
     return sqlContext;
   }
@@ -63,4 +104,189 @@ public class QueryConfig {
   public void setSqlContext(List<String> sqlContext) {
     this.sqlContext = sqlContext;
   }
+
+  public boolean isExpectFailure() {
+    return expectFailure;
+  }
+
+  public void setExpectFailure(boolean expectFailure) {
+    this.expectFailure = expectFailure;
+  }
+
+  /**
+   * when true every statement gets a unique comment so the plan cache of dremio cannot match it
+   * and the run measures real planning and execution
+   *
+   * @return true to defeat the plan cache
+   */
+  public boolean isCacheBuster() {
+    return cacheBuster;
+  }
+
+  public void setCacheBuster(boolean cacheBuster) {
+    this.cacheBuster = cacheBuster;
+  }
+
+  /**
+   * how long to wait for the query before failing it, such as 30s or 2h. Overrides the timeout of
+   * the query group and the --http-timeout-seconds flag.
+   *
+   * @return the timeout or null to use the default
+   */
+  public String getTimeout() {
+    return timeout;
+  }
+
+  public void setTimeout(String timeout) {
+    this.timeout = timeout;
+  }
+
+  /**
+   * SLA on the 95th percentile latency of the query, such as 2s. The run fails when it is exceeded.
+   *
+   * @return the limit or null when there is no SLA
+   */
+  public String getP95Max() {
+    return p95Max;
+  }
+
+  public void setP95Max(String p95Max) {
+    this.p95Max = p95Max;
+  }
+
+  /**
+   * SLA on the slowest successful run of the query, such as 10s. The run fails when it is exceeded.
+   *
+   * @return the limit or null when there is no SLA
+   */
+  public String getMaxDuration() {
+    return maxDuration;
+  }
+
+  public void setMaxDuration(String maxDuration) {
+    this.maxDuration = maxDuration;
+  }
+
+  /**
+   * how long into the run the query starts, such as 5m, so heavy workloads begin once the steady
+   * load is established
+   *
+   * @return the delay or null to start with the run
+   */
+  public String getStartAfter() {
+    return startAfter;
+  }
+
+  public void setStartAfter(String startAfter) {
+    this.startAfter = startAfter;
+  }
+
+  /**
+   * labels to select the query with --only and --exclude, such as nightly or dashboards
+   *
+   * @return the labels or null when the query has none
+   */
+  public List<String> getLabels() {
+    return labels;
+  }
+
+  public void setLabels(List<String> labels) {
+    this.labels = labels;
+  }
+
+  /**
+   * Dremio Cloud engine to run the query on, sent with the sql over HTTP
+   *
+   * @return the engine name or null for the engine chosen by the routing rules
+   */
+  public String getEngine() {
+    return engine;
+  }
+
+  public void setEngine(String engine) {
+    this.engine = engine;
+  }
+
+  /**
+   * how many times the query runs over the whole run, such as a CTAS that should run exactly 10
+   * times while the other queries continue
+   *
+   * @return the limit or null to run for the duration of the run
+   */
+  public Integer getMaxExecutions() {
+    return maxExecutions;
+  }
+
+  public void setMaxExecutions(Integer maxExecutions) {
+    this.maxExecutions = maxExecutions;
+  }
+
+  /**
+   * catalog rest api call to make instead of running sql: list, get, promote or unpromote
+   *
+   * @return the operation name or null for sql
+   */
+  public String getCatalog() {
+    return catalog;
+  }
+
+  public void setCatalog(String catalog) {
+    this.catalog = catalog;
+  }
+
+  /**
+   * path of the entity for catalog calls, components may be :name parameters
+   *
+   * @return the path
+   */
+  public List<String> getCatalogPath() {
+    return catalogPath;
+  }
+
+  public void setCatalogPath(List<String> catalogPath) {
+    this.catalogPath = catalogPath;
+  }
+
+  /**
+   * format of the dataset when promoting, for example {"type": "Parquet"}
+   *
+   * @return the format or null for parquet
+   */
+  public Map<String, Object> getCatalogFormat() {
+    return catalogFormat;
+  }
+
+  public void setCatalogFormat(Map<String, Object> catalogFormat) {
+    this.catalogFormat = catalogFormat;
+  }
+
+  /**
+   * when the query originally started, used to replay queries.json at its original pace
+   *
+   * @return epoch milliseconds or null when unknown
+   */
+  @JsonIgnore
+  public Long getReplayStartMS() {
+    return replayStartMS;
+  }
+
+  @JsonIgnore
+  public void setReplayStartMS(Long replayStartMS) {
+    this.replayStartMS = replayStartMS;
+  }
+
+  /**
+   * when the query originally finished, used to compare the concurrency of a replay to the original
+   *
+   * @return epoch milliseconds or null when unknown
+   */
+  @JsonIgnore
+  public Long getReplayFinishMS() {
+    return replayFinishMS;
+  }
+
+  @JsonIgnore
+  public void setReplayFinishMS(Long replayFinishMS) {
+    this.replayFinishMS = replayFinishMS;
+  }
 }
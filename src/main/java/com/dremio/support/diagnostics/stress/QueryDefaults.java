@@ -0,0 +1,64 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.List;
+import java.util.Map;
+
+/**
+ * settings applied to every query of a stress.json that does not set them itself, so large
+ * configs do not repeat the same context, parameters and frequency on every query
+ */
+public class QueryDefaults {
+  private Integer frequency;
+  private String timeout;
+  private List<String> sqlContext;
+  private Map<String, List<Object>> parameters;
+
+  /** @return frequency of queries that do not set one */
+  public Integer getFrequency() {
+    return frequency;
+  }
+
+  public void setFrequency(Integer frequency) {
+    this.frequency = frequency;
+  }
+
+  /** @return timeout of queries and query groups that do not set one, such as 30s */
+  public String getTimeout() {
+    return timeout;
+  }
+
+  public void setTimeout(String timeout) {
+    this.timeout = timeout;
+  }
+
+  /** @return context of queries that do not set one */
+  public List<String> getSqlContext() {
+    return sqlContext;
+  }
+
+  public void setSqlContext(List<String> sqlContext) {
+    this.sqlContext = sqlContext;
+  }
+
+  /** @return parameter values used by every query unless the query has values of the same name */
+  public Map<String, List<Object>> getParameters() {
+    return parameters;
+  }
+
+  public void setParameters(Map<String, List<Object>> parameters) {
+    this.parameters = parameters;
+  }
+}
@@ -17,7 +17,14 @@ import java.util.List;
 
 public class QueryGroup {
   private String name;
-  private List<String> queries;
+  private List<QueryGroupMember> queries;
+  private int workers;
+  private String timeout;
+  private String startAfter;
+  private List<String> labels;
+  private String engine;
+  private List<String> dependsOn;
+  private boolean dedicatedConnection;
 
   public String getName() {
     return name;
@@ -27,11 +34,108 @@ public class QueryGroup {
     this.name = name;
   }
 
-  public List<String> getQueries() {
+  public List<QueryGroupMember> getQueries() {
     return queries;
   }
 
-  public void setQueries(List<String> queries) {
+  public void setQueries(List<QueryGroupMember> queries) {
     this.queries = queries;
   }
+
+  /**
+   * number of workers dedicated to this group, 0 means the group shares the common worker pool
+   *
+   * @return number of dedicated workers
+   */
+  public int getWorkers() {
+    return workers;
+  }
+
+  public void setWorkers(int workers) {
+    this.workers = workers;
+  }
+
+  /**
+   * how long to wait for each statement of the group before failing it, such as 2h. Overrides the
+   * --http-timeout-seconds flag.
+   *
+   * @return the timeout or null to use the default
+   */
+  public String getTimeout() {
+    return timeout;
+  }
+
+  public void setTimeout(String timeout) {
+    this.timeout = timeout;
+  }
+
+  /**
+   * how long into the run the queries referencing the group start, such as 5m. A query with a
+   * later startAfter of its own keeps it.
+   *
+   * @return the delay or null to start with the run
+   */
+  public String getStartAfter() {
+    return startAfter;
+  }
+
+  public void setStartAfter(String startAfter) {
+    this.startAfter = startAfter;
+  }
+
+  /**
+   * labels every query referencing the group carries, to select them with --only and --exclude
+   *
+   * @return the labels or null when the group has none
+   */
+  public List<String> getLabels() {
+    return labels;
+  }
+
+  public void setLabels(List<String> labels) {
+    this.labels = labels;
+  }
+
+  /**
+   * Dremio Cloud engine every step of the group runs on unless the query referencing the group
+   * sets one, such as a maintenance engine for CTAS and OPTIMIZE
+   *
+   * @return the engine name or null for the engine chosen by the routing rules
+   */
+  public String getEngine() {
+    return engine;
+  }
+
+  public void setEngine(String engine) {
+    this.engine = engine;
+  }
+
+  /**
+   * query groups that must have completed an iteration with every statement successful before the
+   * queries referencing this group run, such as the group creating the table this group reads
+   *
+   * @return names of the groups or null when the group runs from the start
+   */
+  public List<String> getDependsOn() {
+    return dependsOn;
+  }
+
+  public void setDependsOn(List<String> dependsOn) {
+    this.dependsOn = dependsOn;
+  }
+
+  /**
+   * run every iteration of the group on a connection of its own that is closed afterwards, for
+   * groups that change the session with USE or ALTER SESSION or keep temporary state, so it does
+   * not leak into the queries sharing the connection of the workers
+   *
+   * @return true when the group gets a dedicated connection
+   */
+  public boolean isDedicatedConnection() {
+    return dedicatedConnection;
+  }
+
+  public void setDedicatedConnection(boolean dedicatedConnection) {
+    this.dedicatedConnection = dedicatedConnection;
+  }
 }
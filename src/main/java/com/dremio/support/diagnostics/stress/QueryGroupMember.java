@@ -0,0 +1,111 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.annotation.JsonInclude;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * a single step of a query group. In the stress.json a member is either just the sql string or an
+ * object with the sql and parameters that override the parameters of the query referencing the
+ * group.
+ */
+@JsonInclude(JsonInclude.Include.NON_NULL)
+public class QueryGroupMember {
+  private String query;
+  private Map<String, List<Object>> parameters;
+  private Map<String, String> parameterTypes;
+  private String captureResult;
+  private List<String> captureParameters;
+  private boolean expectFailure;
+
+  public QueryGroupMember() {}
+
+  /**
+   * a member written as just the sql string. Members written as an object are bound field by field
+   * like the rest of the stress.json, so unknown fields are warned about or refused with --strict
+   *
+   * @param query the sql of the step
+   */
+  public QueryGroupMember(final String query) {
+    this.query = query;
+  }
+
+  public String getQuery() {
+    return query;
+  }
+
+  public void setQuery(String query) {
+    this.query = query;
+  }
+
+  public Map<String, List<Object>> getParameters() {
+    return parameters;
+  }
+
+  public void setParameters(Map<String, List<Object>> parameters) {
+    this.parameters = parameters;
+  }
+
+  public Map<String, String> getParameterTypes() {
+    return parameterTypes;
+  }
+
+  public void setParameterTypes(Map<String, String> parameterTypes) {
+    this.parameterTypes = parameterTypes;
+  }
+
+  /**
+   * name of the variable the first column of the first row of this step is stored in. Later steps
+   * of the same group iteration reference it as ${name}
+   *
+   * @return variable name or null
+   */
+  public String getCaptureResult() {
+    return captureResult;
+  }
+
+  public void setCaptureResult(String captureResult) {
+    this.captureResult = captureResult;
+  }
+
+  /**
+   * parameters whose randomly chosen value in this step is stored in a variable of the same name,
+   * so later steps of the same group iteration can reference it as ${name}
+   *
+   * @return parameter names or null
+   */
+  public List<String> getCaptureParameters() {
+    return captureParameters;
+  }
+
+  public void setCaptureParameters(List<String> captureParameters) {
+    this.captureParameters = captureParameters;
+  }
+
+  /**
+   * whether this step is expected to fail, such as a permission check in the middle of a group. A
+   * failure of the step then counts as a success and the iteration continues
+   *
+   * @return true when the step should fail
+   */
+  public boolean isExpectFailure() {
+    return expectFailure;
+  }
+
+  public void setExpectFailure(boolean expectFailure) {
+    this.expectFailure = expectFailure;
+  }
+}
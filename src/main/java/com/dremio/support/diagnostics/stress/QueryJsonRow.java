@@ -24,6 +24,11 @@ public class QueryJsonRow {
   private String context;
   private String username;
   private String queryId;
+  private String queueName;
+  private String queryType;
+  // epoch milliseconds
+  private Long start;
+  private Long finish;
 
   public String getQueryText() {
     return queryText;
@@ -64,4 +69,36 @@ public class QueryJsonRow {
   public void setQueryId(String queryId) {
     this.queryId = queryId;
   }
+
+  public String getQueueName() {
+    return queueName;
+  }
+
+  public void setQueueName(String queueName) {
+    this.queueName = queueName;
+  }
+
+  public String getQueryType() {
+    return queryType;
+  }
+
+  public void setQueryType(String queryType) {
+    this.queryType = queryType;
+  }
+
+  public Long getStart() {
+    return start;
+  }
+
+  public void setStart(Long start) {
+    this.start = start;
+  }
+
+  public Long getFinish() {
+    return finish;
+  }
+
+  public void setFinish(Long finish) {
+    this.finish = finish;
+  }
 }
@@ -0,0 +1,116 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.io.IOException;
+import java.nio.file.FileSystems;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.nio.file.PathMatcher;
+import java.nio.file.Paths;
+import java.util.Collections;
+import java.util.List;
+import java.util.stream.Collectors;
+import java.util.stream.Stream;
+
+/**
+ * QueryLogFiles finds the queries.json files to replay. Dremio rotates queries.json into gzipped
+ * archives such as archive/queries.2024-01-31.0.json.gz, other log shippers into queries.json.1 or
+ * queries.json.1.gz, so a day of logs is a directory or a glob rather than a single file.
+ */
+public final class QueryLogFiles {
+
+  private QueryLogFiles() {}
+
+  /**
+   * resolves the path given on the command line to the files to read
+   *
+   * @param path a file, a directory searched recursively or a glob such as logs/queries*.json*
+   * @return the files in path order, which for dremio archives is the order they were written
+   * @throws IOException when the path does not exist or cannot be listed
+   */
+  public static List<File> resolve(final File path) throws IOException {
+    final String pattern = path.getPath();
+    final int wildcard = firstWildcard(pattern);
+    if (wildcard >= 0) {
+      // the directories before the wildcard are walked, the rest of the pattern is matched
+      final int separator =
+          Math.max(
+              pattern.lastIndexOf('/', wildcard), pattern.lastIndexOf(File.separatorChar, wildcard));
+      final Path base = Paths.get(separator < 0 ? "." : pattern.substring(0, separator + 1));
+      final PathMatcher matcher =
+          FileSystems.getDefault().getPathMatcher("glob:" + pattern.substring(separator + 1));
+      if (!Files.isDirectory(base)) {
+        throw new IOException("folder " + base + " of " + pattern + " not found");
+      }
+      try (Stream<Path> files = Files.walk(base)) {
+        return files
+            .filter(Files::isRegularFile)
+            .filter(p -> matcher.matches(base.relativize(p)))
+            .sorted()
+            .map(Path::toFile)
+            .collect(Collectors.toList());
+      }
+    }
+    if (path.isDirectory()) {
+      try (Stream<Path> files = Files.walk(path.toPath())) {
+        return files
+            .filter(Files::isRegularFile)
+            .filter(p -> isQueryLog(p.getFileName().toString()))
+            .sorted()
+            .map(Path::toFile)
+            .collect(Collectors.toList());
+      }
+    }
+    if (!path.exists()) {
+      throw new IOException("file or folder " + path + " not found");
+    }
+    return Collections.singletonList(path);
+  }
+
+  /**
+   * @param name file name
+   * @return true for queries.json and its rotated and gzipped archives
+   */
+  public static boolean isQueryLog(final String name) {
+    return name.contains(".json");
+  }
+
+  /**
+   * @param name file name
+   * @return true when the file is gzipped
+   */
+  public static boolean isGzipped(final String name) {
+    return name.endsWith(".gz");
+  }
+
+  /**
+   * @param path path given on the command line
+   * @return true when the path has a wildcard and is resolved as a glob
+   */
+  public static boolean isGlob(final String path) {
+    return firstWildcard(path) >= 0;
+  }
+
+  private static int firstWildcard(final String pattern) {
+    for (int i = 0; i < pattern.length(); i++) {
+      final char c = pattern.charAt(i);
+      if (c == '*' || c == '?' || c == '[' || c == '{') {
+        return i;
+      }
+    }
+    return -1;
+  }
+}
@@ -0,0 +1,131 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.math.BigDecimal;
+import java.security.InvalidParameterException;
+import java.util.AbstractList;
+import java.util.Arrays;
+import java.util.List;
+
+/**
+ * QueryPool is the list queries are picked from, every query appears as many times as its share of
+ * the workload. An integer frequency is used as is. A float weight such as 0.5 is scaled by the
+ * smallest power of ten that keeps every share within 1%, so 0.5 next to 99.5 is 5 and 995 slots.
+ * The slots are not materialized, a pick is a binary search over the running totals, so a 0.001%
+ * query does not need millions of copies of the others.
+ */
+public class QueryPool extends AbstractList<QueryConfig> {
+
+  // finest scale tried before the weights are rounded to parts per million of the total
+  private static final long maxScale = 1_000_000L;
+  // a scaled weight is close enough when it is within this fraction of its rounded value
+  private static final double tolerance = 0.01;
+  private final List<QueryConfig> queries;
+  // ends[i] is the number of slots taken by the queries up to and including i
+  private final long[] ends;
+
+  /**
+   * @param queries configured queries in order
+   * @throws InvalidParameterException when a weight is not positive or set next to a frequency
+   */
+  public QueryPool(final List<QueryConfig> queries) {
+    this.queries = queries;
+    final double[] weights = new double[queries.size()];
+    boolean weighted = false;
+    for (int i = 0; i < queries.size(); i++) {
+      final QueryConfig q = queries.get(i);
+      if (q.getWeight() != null) {
+        if (q.getFrequency() != 0) {
+          throw new InvalidParameterException(
+              "set either frequency or weight, not both: " + StressExec.describe(q));
+        }
+        if (!(q.getWeight() > 0) || q.getWeight().isInfinite()) {
+          throw new InvalidParameterException(
+              "weight must be a positive number: " + StressExec.describe(q));
+        }
+        weighted = true;
+      }
+      weights[i] = weightOf(q);
+    }
+    final long[] slots =
+        weighted ? scale(weights) : Arrays.stream(weights).mapToLong(w -> (long) w).toArray();
+    this.ends = new long[slots.length];
+    long total = 0;
+    for (int i = 0; i < slots.length; i++) {
+      total += slots[i];
+      ends[i] = total;
+    }
+    if (total > Integer.MAX_VALUE) {
+      throw new InvalidParameterException(
+          String.format("the frequencies add up to %d, more than %d", total, Integer.MAX_VALUE));
+    }
+  }
+
+  /**
+   * @param q configured query
+   * @return the weight when set, otherwise the frequency with a minimum of 1
+   */
+  public static double weightOf(final QueryConfig q) {
+    if (q.getWeight() != null) {
+      return q.getWeight();
+    }
+    return Math.max(q.getFrequency(), 1);
+  }
+
+  /**
+   * @param weight weight or frequency of a query
+   * @return the weight without trailing zeros, so frequencies print as whole numbers
+   */
+  public static String format(final double weight) {
+    return BigDecimal.valueOf(weight).stripTrailingZeros().toPlainString();
+  }
+
+  private static long[] scale(final double[] weights) {
+    for (long scale = 1; scale <= maxScale; scale *= 10) {
+      final long[] slots = new long[weights.length];
+      boolean close = true;
+      for (int i = 0; i < weights.length && close; i++) {
+        final double scaled = weights[i] * scale;
+        slots[i] = Math.round(scaled);
+        close = slots[i] >= 1 && Math.abs(scaled - slots[i]) <= scaled * tolerance;
+      }
+      if (close) {
+        return slots;
+      }
+    }
+    // weights too far apart for a power of ten, round each to parts per million of the total
+    final double total = Arrays.stream(weights).sum();
+    final long[] slots = new long[weights.length];
+    for (int i = 0; i < weights.length; i++) {
+      slots[i] = Math.max(1, Math.round(weights[i] / total * maxScale));
+    }
+    return slots;
+  }
+
+  @Override
+  public QueryConfig get(final int index) {
+    if (index < 0 || index >= size()) {
+      throw new IndexOutOfBoundsException("index " + index + " size " + size());
+    }
+    // every query has at least one slot so the ends are strictly increasing
+    final int found = Arrays.binarySearch(ends, index + 1L);
+    return queries.get(found >= 0 ? found : -found - 1);
+  }
+
+  @Override
+  public int size() {
+    return ends.length == 0 ? 0 : (int) ends[ends.length - 1];
+  }
+}
@@ -0,0 +1,96 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.Arrays;
+import java.util.Collections;
+import java.util.HashSet;
+import java.util.List;
+import java.util.Locale;
+import java.util.Set;
+import java.util.regex.Matcher;
+import java.util.regex.Pattern;
+
+/**
+ * ReadOnlyGuard tells statements that only read apart from the ones that could change data or
+ * metadata, so a workload pointed at production by accident is refused before it runs. SELECT,
+ * WITH, SHOW, EXPLAIN and DESCRIBE read, USE and ALTER SESSION only change the session of the
+ * connection. Everything else, including statements it does not know, is treated as a write.
+ */
+public class ReadOnlyGuard {
+
+  private static final Set<String> reads =
+      new HashSet<>(Arrays.asList("SELECT", "WITH", "SHOW", "EXPLAIN", "DESCRIBE", "DESC", "USE"));
+  // comments and opening parentheses before the first keyword
+  private static final Pattern leading =
+      Pattern.compile("(?s)^(\\s+|--[^\\n]*(\\n|$)|/\\*.*?\\*/|\\()*");
+  private static final Pattern keywordPattern = Pattern.compile("^(\\w+)(\\s+(\\w+))?");
+
+  /** prevent instantiation */
+  private ReadOnlyGuard() {}
+
+  /**
+   * @param sql one or more statements
+   * @return true when every statement of the sql only reads or changes the session
+   */
+  public static boolean isReadOnly(final String sql) {
+    return firstWrite(sql) == null;
+  }
+
+  /**
+   * @param sql one or more statements
+   * @return the first statement that could write, null when there is none
+   */
+  public static String firstWrite(final String sql) {
+    if (sql == null) {
+      return null;
+    }
+    for (final String statement : StatementSplitter.split(sql)) {
+      if (leading.matcher(statement).replaceFirst("").isEmpty()) {
+        // nothing but comments
+        continue;
+      }
+      final List<String> words = keywords(statement);
+      if (words.isEmpty() || (!reads.contains(words.get(0)) && !isSessionChange(words))) {
+        return statement;
+      }
+    }
+    return null;
+  }
+
+  /**
+   * @param words the leading keywords of a statement
+   * @return true for ALTER SESSION
+   */
+  static boolean isSessionChange(final List<String> words) {
+    return words.size() > 1 && "ALTER".equals(words.get(0)) && "SESSION".equals(words.get(1));
+  }
+
+  /**
+   * @param statement a single statement
+   * @return the first two keywords in upper case skipping comments and opening parentheses, empty
+   *     when the statement does not start with a keyword
+   */
+  static List<String> keywords(final String statement) {
+    final String code = leading.matcher(statement).replaceFirst("");
+    final Matcher matcher = keywordPattern.matcher(code.toUpperCase(Locale.ROOT));
+    if (!matcher.find()) {
+      return Collections.emptyList();
+    }
+    if (matcher.group(3) == null) {
+      return Collections.singletonList(matcher.group(1));
+    }
+    return Arrays.asList(matcher.group(1), matcher.group(3));
+  }
+}
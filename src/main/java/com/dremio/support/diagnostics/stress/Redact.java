@@ -0,0 +1,58 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.regex.Pattern;
+
+/** Redact hides credentials in urls and connection strings before they are logged or written */
+public final class Redact {
+
+  private static final String MASK = "***";
+  // password=, pwd=, token=, user= and uid= properties of jdbc urls and connection strings
+  private static final Pattern secretProperty =
+      Pattern.compile("(?i)((?:password|pwd|token|user|uid)\\s*=\\s*)(\\{[^}]*\\}|[^&;\\s]*)");
+  // user:password@ and user@ in urls
+  private static final Pattern userInfo = Pattern.compile("(://)[^/@\\s]+@");
+
+  private Redact() {}
+
+  /**
+   * masks the credentials of a url or connection string, the rest stays readable
+   *
+   * @param url http url, jdbc url or connection string, may be null
+   * @return the url without credentials
+   */
+  public static String url(final String url) {
+    if (url == null) {
+      return null;
+    }
+    final String properties = secretProperty.matcher(url).replaceAll("$1" + MASK);
+    return userInfo.matcher(properties).replaceAll("$1" + MASK + "@");
+  }
+
+  /**
+   * keeps only the scheme and host of a url whose path is the secret, such as an incoming webhook
+   *
+   * @param url the url, may be null
+   * @return scheme and host followed by the mask
+   */
+  public static String path(final String url) {
+    if (url == null) {
+      return null;
+    }
+    final int scheme = url.indexOf("://");
+    final int pathStart = url.indexOf('/', scheme < 0 ? 0 : scheme + 3);
+    return (pathStart < 0 ? url : url.substring(0, pathStart)) + "/" + MASK;
+  }
+}
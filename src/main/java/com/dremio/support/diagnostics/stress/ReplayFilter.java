@@ -0,0 +1,113 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.Collection;
+import java.util.Locale;
+import java.util.Set;
+import java.util.TreeSet;
+import java.util.regex.Pattern;
+
+/**
+ * ReplayFilter narrows a queries.json replay down to the slice of the workload being investigated,
+ * for example the queries of one user or queue, the slow ones or everything but metadata refreshes.
+ * A row missing a field that is filtered on is left out, as it cannot be shown to match.
+ */
+public class ReplayFilter {
+
+  // metadata refreshes and catalog lookups tools run on their own, not part of the user workload
+  private static final Pattern metadataQuery =
+      Pattern.compile(
+          "(?is)^\\s*(refresh\\b|alter\\s+(table|pds|vds)\\b.*\\brefresh\\s+metadata\\b"
+              + "|show\\s|describe\\s|.*\\binformation_schema\\b)");
+
+  private final Set<String> users;
+  private final Set<String> queues;
+  private final Set<String> queryTypes;
+  private final long minDurationMS;
+  private final boolean excludeMetadata;
+
+  /**
+   * @param users only replay queries of these users, empty replays every user
+   * @param queues only replay queries that ran in these queues, empty replays every queue
+   * @param queryTypes only replay queries of these types such as UI_RUN, JDBC or ODBC, empty
+   *     replays every type
+   * @param minDurationSeconds only replay queries that took at least this long, 0 replays all
+   * @param excludeMetadata leave out metadata refreshes, SHOW, DESCRIBE and INFORMATION_SCHEMA
+   */
+  public ReplayFilter(
+      final Collection<String> users,
+      final Collection<String> queues,
+      final Collection<String> queryTypes,
+      final double minDurationSeconds,
+      final boolean excludeMetadata) {
+    this.users = new TreeSet<>(users);
+    this.queues = new TreeSet<>(String.CASE_INSENSITIVE_ORDER);
+    this.queues.addAll(queues);
+    this.queryTypes = new TreeSet<>(String.CASE_INSENSITIVE_ORDER);
+    this.queryTypes.addAll(queryTypes);
+    this.minDurationMS = (long) (minDurationSeconds * 1000);
+    this.excludeMetadata = excludeMetadata;
+  }
+
+  /** @return true when no filter is set */
+  public boolean isEmpty() {
+    return users.isEmpty()
+        && queues.isEmpty()
+        && queryTypes.isEmpty()
+        && minDurationMS <= 0
+        && !excludeMetadata;
+  }
+
+  /**
+   * @param row a query of queries.json
+   * @return true when the query is part of the slice to replay
+   */
+  public boolean accepts(final QueryJsonRow row) {
+    if (!users.isEmpty() && (row.getUsername() == null || !users.contains(row.getUsername()))) {
+      return false;
+    }
+    if (!queues.isEmpty() && (row.getQueueName() == null || !queues.contains(row.getQueueName()))) {
+      return false;
+    }
+    if (!queryTypes.isEmpty()
+        && (row.getQueryType() == null || !queryTypes.contains(row.getQueryType()))) {
+      return false;
+    }
+    if (minDurationMS > 0) {
+      if (row.getStart() == null || row.getFinish() == null) {
+        return false;
+      }
+      if (row.getFinish() - row.getStart() < minDurationMS) {
+        return false;
+      }
+    }
+    if (excludeMetadata) {
+      final String type =
+          row.getQueryType() == null ? "" : row.getQueryType().toUpperCase(Locale.ROOT);
+      if (type.contains("METADATA")
+          || (row.getQueryText() != null && metadataQuery.matcher(row.getQueryText()).find())) {
+        return false;
+      }
+    }
+    return true;
+  }
+
+  @Override
+  public String toString() {
+    return String.format(
+        "users=%s queues=%s queryTypes=%s minDurationMS=%d excludeMetadata=%s",
+        users, queues, queryTypes, minDurationMS, excludeMetadata);
+  }
+}
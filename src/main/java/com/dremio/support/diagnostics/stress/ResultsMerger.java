@@ -0,0 +1,248 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.time.Duration;
+import java.time.Instant;
+import java.time.format.DateTimeParseException;
+import java.util.ArrayList;
+import java.util.Comparator;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.TreeMap;
+
+/**
+ * combines the results files of agents that ran at the same time, for example several dremio-stress
+ * processes driving one cluster from different machines, into the results of a single run. Counts
+ * are summed, the latency percentiles are computed from the histograms of all the agents together
+ * and the time series are aligned on the wall clock.
+ */
+public class ResultsMerger {
+
+  private static final String[] counters = {"submitted", "successful", "failures", "skipped"};
+
+  private ResultsMerger() {}
+
+  /**
+   * merges results
+   *
+   * @param runs the results as written to the results files
+   * @return the merged results, in the format of a results file
+   * @throws IllegalArgumentException when a results file has no latency histograms
+   */
+  @SuppressWarnings("unchecked")
+  public static Map<String, Object> merge(final List<Map<String, Object>> runs) {
+    final List<String> runIds = new ArrayList<>();
+    final List<Object> configs = new ArrayList<>();
+    final List<Object> urls = new ArrayList<>();
+    Instant start = null;
+    Instant end = null;
+    final Map<String, Long> totals = new LinkedHashMap<>();
+    long latencyMS = 0;
+    final Map<String, Double> phaseMS = new LinkedHashMap<>();
+    final Map<String, TargetStats> queries = new TreeMap<>();
+    TargetStats probe = null;
+    long probeMaxMS = 0;
+    final List<Object> violations = new ArrayList<>();
+    final List<Map<String, Object>> events = new ArrayList<>();
+    final List<Map<String, Object>> agents = new ArrayList<>();
+    for (final Map<String, Object> run : runs) {
+      final String runId = String.valueOf(run.get("runId"));
+      runIds.add(runId);
+      addDistinct(configs, run.get("config"));
+      addDistinct(urls, run.get("url"));
+      start = earliest(start, time(run.get("start")));
+      end = latest(end, time(run.get("end")));
+      for (final String counter : counters) {
+        totals.merge(counter, number(run.get(counter)), Long::sum);
+      }
+      final long successful = number(run.get("successful"));
+      latencyMS += number(run.get("avgLatencyMS")) * successful;
+      if (run.get("avgPhaseMS") instanceof Map) {
+        for (final Map.Entry<String, Object> phase :
+            ((Map<String, Object>) run.get("avgPhaseMS")).entrySet()) {
+          phaseMS.merge(
+              phase.getKey(), (double) number(phase.getValue()) * successful, Double::sum);
+        }
+      }
+      for (final Map<String, Object> query : list(run.get("queries"))) {
+        final String name = String.valueOf(query.get("name"));
+        queries.computeIfAbsent(name, k -> new TargetStats(name, "")).add(query);
+      }
+      if (run.get("probe") instanceof Map) {
+        final Map<String, Object> runProbe = (Map<String, Object>) run.get("probe");
+        if (probe == null) {
+          probe = new TargetStats("probe", String.valueOf(runProbe.get("url")));
+        }
+        probe.add(runProbe);
+        probeMaxMS = Math.max(probeMaxMS, number(runProbe.get("maxMS")));
+      }
+      if (run.get("slaViolations") instanceof List) {
+        violations.addAll((List<Object>) run.get("slaViolations"));
+      }
+      for (final Map<String, Object> event : list(run.get("events"))) {
+        final Map<String, Object> tagged = new LinkedHashMap<>(event);
+        tagged.put("runId", runId);
+        events.add(tagged);
+      }
+      final Map<String, Object> agent = new LinkedHashMap<>();
+      agent.put("runId", runId);
+      agent.put("start", run.get("start"));
+      agent.put("end", run.get("end"));
+      for (final String counter : counters) {
+        agent.put(counter, run.get(counter));
+      }
+      agent.put("client", run.get("client"));
+      agent.put("workerUtilization", run.get("workerUtilization"));
+      agents.add(agent);
+    }
+    final long successful = totals.getOrDefault("successful", 0L);
+    final Map<String, Object> merged = new LinkedHashMap<>();
+    merged.put("runId", String.join("+", runIds));
+    merged.put("config", join(configs));
+    merged.put("url", join(urls));
+    merged.put("start", start == null ? null : start.toString());
+    merged.put("end", end == null ? null : end.toString());
+    merged.putAll(totals);
+    merged.put("avgLatencyMS", successful == 0 ? 0 : latencyMS / successful);
+    final Map<String, Long> phases = new LinkedHashMap<>();
+    for (final Map.Entry<String, Double> phase : phaseMS.entrySet()) {
+      phases.put(phase.getKey(), successful == 0 ? 0 : Math.round(phase.getValue() / successful));
+    }
+    merged.put("avgPhaseMS", phases);
+    final List<Map<String, Object>> mergedQueries = new ArrayList<>();
+    for (final TargetStats stats : queries.values()) {
+      final Map<String, Object> query = stats.toMap();
+      query.remove("url");
+      mergedQueries.add(query);
+    }
+    merged.put("queries", mergedQueries);
+    if (probe != null) {
+      final Map<String, Object> probeMap = probe.toMap();
+      probeMap.put("maxMS", probeMaxMS);
+      merged.put("probe", probeMap);
+    }
+    if (!violations.isEmpty()) {
+      merged.put("slaViolations", violations);
+    }
+    events.sort(
+        Comparator.comparing(
+            e -> time(e.get("time")), Comparator.nullsFirst(Comparator.<Instant>naturalOrder())));
+    merged.put("events", events);
+    final List<Map<String, Object>> timeSeries = mergeTimeSeries(runs);
+    if (!timeSeries.isEmpty()) {
+      merged.put("timeSeries", timeSeries);
+    }
+    merged.put("runs", agents);
+    return merged;
+  }
+
+  /**
+   * merges the rows of the time series that start at the same time. Counts, throughput and the mean
+   * are exact, the percentiles of a bucket are the highest of the agents as the rows do not keep
+   * their histograms, an upper bound of the merged percentile.
+   *
+   * @param runs the results
+   * @return the merged rows in time order
+   */
+  private static List<Map<String, Object>> mergeTimeSeries(final List<Map<String, Object>> runs) {
+    final Map<Instant, Map<String, Object>> rows = new TreeMap<>();
+    for (final Map<String, Object> run : runs) {
+      for (final Map<String, Object> row : list(run.get("timeSeries"))) {
+        final Instant rowStart = time(row.get("start"));
+        if (rowStart == null) {
+          continue;
+        }
+        final Map<String, Object> merged =
+            rows.computeIfAbsent(rowStart, k -> new LinkedHashMap<>());
+        final long successful = number(row.get("successful"));
+        final long mergedSuccessful = number(merged.get("successful"));
+        final long total = successful + mergedSuccessful;
+        final long latencyMS =
+            number(merged.get("meanMS")) * mergedSuccessful + number(row.get("meanMS")) * successful;
+        merged.put("start", rowStart.toString());
+        merged.put("meanMS", total == 0 ? 0 : latencyMS / total);
+        merged.put("successful", total);
+        merged.put("failures", number(merged.get("failures")) + number(row.get("failures")));
+        merged.put("qps", decimal(merged.get("qps")) + decimal(row.get("qps")));
+        for (final String field : new String[] {"p50MS", "p95MS", "p99MS", "maxMS"}) {
+          merged.put(field, Math.max(number(merged.get(field)), number(row.get(field))));
+        }
+      }
+    }
+    final List<Map<String, Object>> series = new ArrayList<>();
+    Instant first = null;
+    for (final Map.Entry<Instant, Map<String, Object>> row : rows.entrySet()) {
+      first = first == null ? row.getKey() : first;
+      final Map<String, Object> ordered = new LinkedHashMap<>();
+      ordered.put("offsetSeconds", Duration.between(first, row.getKey()).getSeconds());
+      ordered.putAll(row.getValue());
+      series.add(ordered);
+    }
+    return series;
+  }
+
+  @SuppressWarnings("unchecked")
+  private static List<Map<String, Object>> list(final Object value) {
+    final List<Map<String, Object>> list = new ArrayList<>();
+    if (value instanceof List) {
+      for (final Object item : (List<Object>) value) {
+        if (item instanceof Map) {
+          list.add((Map<String, Object>) item);
+        }
+      }
+    }
+    return list;
+  }
+
+  private static void addDistinct(final List<Object> values, final Object value) {
+    if (value != null && !values.contains(value)) {
+      values.add(value);
+    }
+  }
+
+  private static String join(final List<Object> values) {
+    final List<String> strings = new ArrayList<>();
+    for (final Object value : values) {
+      strings.add(String.valueOf(value));
+    }
+    return String.join(", ", strings);
+  }
+
+  /** @return the time of an iso-8601 string, null when it is missing or invalid */
+  private static Instant time(final Object value) {
+    try {
+      return value == null ? null : Instant.parse(String.valueOf(value));
+    } catch (DateTimeParseException e) {
+      return null;
+    }
+  }
+
+  private static Instant earliest(final Instant a, final Instant b) {
+    return a == null || (b != null && b.isBefore(a)) ? b : a;
+  }
+
+  private static Instant latest(final Instant a, final Instant b) {
+    return a == null || (b != null && b.isAfter(a)) ? b : a;
+  }
+
+  private static long number(final Object value) {
+    return value instanceof Number ? ((Number) value).longValue() : 0;
+  }
+
+  private static double decimal(final Object value) {
+    return value instanceof Number ? ((Number) value).doubleValue() : 0;
+  }
+}
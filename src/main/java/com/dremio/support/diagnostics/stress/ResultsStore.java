@@ -0,0 +1,119 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.sql.Connection;
+import java.sql.DriverManager;
+import java.sql.PreparedStatement;
+import java.sql.SQLException;
+import java.sql.Statement;
+import java.sql.Types;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * ResultsStore appends the results of every run to a sqlite file, so trends across weeks of nightly
+ * runs can be queried with plain sql.
+ *
+ * <ul>
+ *   <li>runs - one row per run with the totals
+ *   <li>run_queries - one row per query label and run with the latency percentiles
+ * </ul>
+ */
+public class ResultsStore {
+
+  private final File file;
+
+  /** @param file sqlite file, created when missing */
+  public ResultsStore(final File file) {
+    this.file = file;
+  }
+
+  public File getFile() {
+    return file;
+  }
+
+  /**
+   * appends the results of a run
+   *
+   * @param results the results as written to the results file
+   * @throws SQLException when unable to write to the store
+   */
+  @SuppressWarnings("unchecked")
+  public void append(final Map<String, Object> results) throws SQLException {
+    try (Connection connection = DriverManager.getConnection("jdbc:sqlite:" + file.getPath())) {
+      createTables(connection);
+      connection.setAutoCommit(false);
+      try (PreparedStatement run =
+          connection.prepareStatement(
+              "INSERT INTO runs (run_id, config, url, start_time, end_time, submitted, successful,"
+                  + " failures, avg_latency_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")) {
+        run.setString(1, String.valueOf(results.get("runId")));
+        run.setString(2, String.valueOf(results.get("config")));
+        run.setString(3, String.valueOf(results.get("url")));
+        run.setString(4, String.valueOf(results.get("start")));
+        run.setString(5, String.valueOf(results.get("end")));
+        run.setLong(6, number(results.get("submitted")));
+        run.setLong(7, number(results.get("successful")));
+        run.setLong(8, number(results.get("failures")));
+        run.setLong(9, number(results.get("avgLatencyMS")));
+        run.executeUpdate();
+      }
+      final Object queries = results.get("queries");
+      if (queries instanceof List) {
+        try (PreparedStatement query =
+            connection.prepareStatement(
+                "INSERT INTO run_queries (run_id, label, successful, failures, mean_ms, p50_ms,"
+                    + " p95_ms, p99_ms, accelerated) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")) {
+          for (final Map<String, Object> row : (List<Map<String, Object>>) queries) {
+            query.setString(1, String.valueOf(results.get("runId")));
+            query.setString(2, String.valueOf(row.get("name")));
+            query.setLong(3, number(row.get("successful")));
+            query.setLong(4, number(row.get("failures")));
+            query.setLong(5, number(row.get("meanMS")));
+            query.setLong(6, number(row.get("p50MS")));
+            query.setLong(7, number(row.get("p95MS")));
+            query.setLong(8, number(row.get("p99MS")));
+            if (row.get("accelerated") == null) {
+              query.setNull(9, Types.INTEGER);
+            } else {
+              query.setLong(9, number(row.get("accelerated")));
+            }
+            query.addBatch();
+          }
+          query.executeBatch();
+        }
+      }
+      connection.commit();
+    }
+  }
+
+  private static void createTables(final Connection connection) throws SQLException {
+    try (Statement statement = connection.createStatement()) {
+      statement.executeUpdate(
+          "CREATE TABLE IF NOT EXISTS runs (run_id TEXT PRIMARY KEY, config TEXT, url TEXT,"
+              + " start_time TEXT, end_time TEXT, submitted INTEGER, successful INTEGER, failures"
+              + " INTEGER, avg_latency_ms INTEGER)");
+      statement.executeUpdate(
+          "CREATE TABLE IF NOT EXISTS run_queries (run_id TEXT, label TEXT, successful INTEGER,"
+              + " failures INTEGER, mean_ms INTEGER, p50_ms INTEGER, p95_ms INTEGER, p99_ms"
+              + " INTEGER, accelerated INTEGER, PRIMARY KEY (run_id, label))");
+    }
+  }
+
+  private static long number(final Object value) {
+    return value instanceof Number ? ((Number) value).longValue() : 0;
+  }
+}
@@ -0,0 +1,164 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.io.IOException;
+import java.net.URL;
+import java.security.PrivilegedActionException;
+import java.security.PrivilegedExceptionAction;
+import java.util.Base64;
+import java.util.HashMap;
+import java.util.Map;
+import java.util.logging.Logger;
+import javax.security.auth.Subject;
+import javax.security.auth.login.AppConfigurationEntry;
+import javax.security.auth.login.Configuration;
+import javax.security.auth.login.LoginContext;
+import javax.security.auth.login.LoginException;
+import org.ietf.jgss.GSSContext;
+import org.ietf.jgss.GSSException;
+import org.ietf.jgss.GSSManager;
+import org.ietf.jgss.GSSName;
+import org.ietf.jgss.Oid;
+
+/**
+ * Spnego authenticates HTTP requests with kerberos. It logs in once from a keytab or a ticket cache
+ * and creates a fresh Negotiate token for every request, as a token can only be used once. When a
+ * token cannot be created, usually because the ticket expired during a long run, it logs in again.
+ */
+public class Spnego {
+
+  private static final Logger logger = Logger.getLogger(Spnego.class.getName());
+  private static final String SPNEGO_OID = "1.3.6.1.5.5.2";
+
+  private final String principal;
+  private final File keytab;
+  private final File ticketCache;
+  private volatile Subject subject;
+
+  /**
+   * logs in to kerberos
+   *
+   * @param principal the client principal, required with a keytab, with a ticket cache null uses
+   *     the principal of the cache
+   * @param keytab keytab to log in with, null uses the ticket cache
+   * @param ticketCache ticket cache to read, null uses the default cache of kinit
+   * @throws IOException when the login fails
+   */
+  public Spnego(final String principal, final File keytab, final File ticketCache)
+      throws IOException {
+    this.principal = principal;
+    this.keytab = keytab;
+    this.ticketCache = ticketCache;
+    this.subject = login();
+  }
+
+  private Subject login() throws IOException {
+    final Map<String, String> options = new HashMap<>();
+    // never ask for a password on the console
+    options.put("doNotPrompt", "true");
+    options.put("refreshKrb5Config", "true");
+    if (principal != null) {
+      options.put("principal", principal);
+    }
+    if (keytab != null) {
+      options.put("useKeyTab", "true");
+      options.put("keyTab", keytab.getPath());
+      options.put("storeKey", "true");
+    } else {
+      options.put("useTicketCache", "true");
+      options.put("renewTGT", "true");
+      if (ticketCache != null) {
+        options.put("ticketCache", ticketCache.getPath());
+      }
+    }
+    final Configuration configuration =
+        new Configuration() {
+          @Override
+          public AppConfigurationEntry[] getAppConfigurationEntry(final String name) {
+            return new AppConfigurationEntry[] {
+              new AppConfigurationEntry(
+                  "com.sun.security.auth.module.Krb5LoginModule",
+                  AppConfigurationEntry.LoginModuleControlFlag.REQUIRED,
+                  options)
+            };
+          }
+        };
+    try {
+      final LoginContext context =
+          new LoginContext("dremio-stress", new Subject(), null, configuration);
+      context.login();
+      return context.getSubject();
+    } catch (LoginException e) {
+      throw new IOException("kerberos login failed: " + e.getMessage(), e);
+    }
+  }
+
+  /**
+   * creates the Authorization header for a request, the service principal is HTTP@ the host of the
+   * url
+   *
+   * @param url the url the request goes to
+   * @return the Negotiate header value
+   * @throws IOException when no token can be created even after logging in again
+   */
+  public String header(final URL url) throws IOException {
+    try {
+      return "Negotiate " + token(url);
+    } catch (GSSException e) {
+      logger.warning(
+          () -> String.format("kerberos token for %s failed, logging in again", url.getHost()));
+      subject = login();
+      try {
+        return "Negotiate " + token(url);
+      } catch (GSSException retry) {
+        throw new IOException(
+            String.format(
+                "unable to create a kerberos token for HTTP@%s: %s",
+                url.getHost(), retry.getMessage()),
+            retry);
+      }
+    }
+  }
+
+  private String token(final URL url) throws GSSException {
+    try {
+      return Subject.doAs(
+          subject,
+          (PrivilegedExceptionAction<String>)
+              () -> {
+                final GSSManager manager = GSSManager.getInstance();
+                final GSSName server =
+                    manager.createName("HTTP@" + url.getHost(), GSSName.NT_HOSTBASED_SERVICE);
+                final GSSContext context =
+                    manager.createContext(
+                        server, new Oid(SPNEGO_OID), null, GSSContext.DEFAULT_LIFETIME);
+                try {
+                  context.requestMutualAuth(false);
+                  context.requestCredDeleg(false);
+                  final byte[] token = context.initSecContext(new byte[0], 0, 0);
+                  return Base64.getEncoder().encodeToString(token);
+                } finally {
+                  context.dispose();
+                }
+              });
+    } catch (PrivilegedActionException e) {
+      if (e.getException() instanceof GSSException) {
+        throw (GSSException) e.getException();
+      }
+      throw new RuntimeException(e.getException());
+    }
+  }
+}
@@ -0,0 +1,182 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.ArrayList;
+import java.util.Collection;
+import java.util.Collections;
+import java.util.List;
+import java.util.Objects;
+
+/**
+ * SqlContext is the path (source, spaces and folders) a query is run in. The parts are kept
+ * unquoted so they can be sent as is to the rest api, and are quoted when rendered for a USE
+ * statement.
+ */
+public class SqlContext {
+
+  private static final SqlContext empty = new SqlContext(Collections.emptyList());
+
+  private final List<String> parts;
+
+  /**
+   * creates a context from its components
+   *
+   * @param parts unquoted path components, for example ["Samples", "samples.dremio.com"]
+   */
+  public SqlContext(final Collection<String> parts) {
+    final List<String> cleaned = new ArrayList<>();
+    if (parts != null) {
+      for (final String part : parts) {
+        if (part != null && !part.isEmpty()) {
+          cleaned.add(part);
+        }
+      }
+    }
+    this.parts = Collections.unmodifiableList(cleaned);
+  }
+
+  /** @return a context with no path, queries run in the default context */
+  public static SqlContext empty() {
+    return empty;
+  }
+
+  /**
+   * parses the context as written in queries.json, for example [Samples, "samples.dremio.com"].
+   * Components may be double quoted, a doubled double quote inside a quoted component is an
+   * escaped double quote. Unquoted components are trimmed, quoted ones are kept as written.
+   *
+   * @param text context string from queries.json
+   * @return the parsed context
+   */
+  public static SqlContext parse(final String text) {
+    if (text == null) {
+      return empty;
+    }
+    String trimmed = text.trim();
+    if (trimmed.startsWith("[") && trimmed.endsWith("]")) {
+      trimmed = trimmed.substring(1, trimmed.length() - 1);
+    }
+    return split(trimmed, ',');
+  }
+
+  /**
+   * parses a path as written in sql or the schema property of a JDBC url, for example
+   * Samples."samples.dremio.com"
+   *
+   * @param text dot separated path, components with dots are double quoted
+   * @return the parsed context
+   */
+  public static SqlContext parseSqlPath(final String text) {
+    if (text == null) {
+      return empty;
+    }
+    return split(text.trim(), '.');
+  }
+
+  private static SqlContext split(final String text, final char separator) {
+    final List<String> parts = new ArrayList<>();
+    final StringBuilder current = new StringBuilder();
+    // range of the current component written inside quotes, its spaces are kept
+    int firstQuoted = -1;
+    int lastQuoted = -1;
+    boolean quoted = false;
+    for (int i = 0; i < text.length(); i++) {
+      final char c = text.charAt(i);
+      if (c == '"') {
+        if (quoted && i + 1 < text.length() && text.charAt(i + 1) == '"') {
+          current.append('"');
+          i++;
+        } else {
+          quoted = !quoted;
+          continue;
+        }
+      } else if (c == separator && !quoted) {
+        parts.add(trim(current, firstQuoted, lastQuoted));
+        current.setLength(0);
+        firstQuoted = -1;
+        lastQuoted = -1;
+        continue;
+      } else {
+        current.append(c);
+      }
+      if (quoted) {
+        if (firstQuoted == -1) {
+          firstQuoted = current.length() - 1;
+        }
+        lastQuoted = current.length() - 1;
+      }
+    }
+    parts.add(trim(current, firstQuoted, lastQuoted));
+    return new SqlContext(parts);
+  }
+
+  /** trims the spaces around a component that were not written inside quotes */
+  private static String trim(
+      final StringBuilder part, final int firstQuoted, final int lastQuoted) {
+    int start = 0;
+    int end = part.length();
+    while (start < end
+        && (firstQuoted == -1 || start < firstQuoted)
+        && Character.isWhitespace(part.charAt(start))) {
+      start++;
+    }
+    while (end > start && end - 1 > lastQuoted && Character.isWhitespace(part.charAt(end - 1))) {
+      end--;
+    }
+    return part.substring(start, end);
+  }
+
+  /** @return the unquoted path components */
+  public List<String> getParts() {
+    return parts;
+  }
+
+  /** @return true when there is no path */
+  public boolean isEmpty() {
+    return parts.isEmpty();
+  }
+
+  /**
+   * renders the path for use in sql, every component is double quoted so dots, spaces and reserved
+   * words are handled
+   *
+   * @return the quoted path, for example "Samples"."samples.dremio.com"
+   */
+  public String toSqlPath() {
+    final List<String> quoted = new ArrayList<>();
+    for (final String part : parts) {
+      quoted.add('"' + part.replace("\"", "\"\"") + '"');
+    }
+    return String.join(".", quoted);
+  }
+
+  @Override
+  public boolean equals(Object o) {
+    if (this == o) return true;
+    if (!(o instanceof SqlContext)) return false;
+    SqlContext that = (SqlContext) o;
+    return Objects.equals(parts, that.parts);
+  }
+
+  @Override
+  public int hashCode() {
+    return Objects.hash(parts);
+  }
+
+  @Override
+  public String toString() {
+    return toSqlPath();
+  }
+}
@@ -0,0 +1,221 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.security.InvalidParameterException;
+import java.util.ArrayList;
+import java.util.List;
+import java.util.Locale;
+import java.util.Random;
+import java.util.logging.Logger;
+import java.util.regex.Matcher;
+import java.util.regex.Pattern;
+
+/**
+ * SqlFuzzer mutates already mapped queries to stress the planner and error handling paths. The
+ * mutations are deliberately simple text rewrites: a random subset of the selected columns, an
+ * extra random predicate or a cast that is likely to fail. Only single SELECT and WITH statements
+ * are mutated, wrapping a CREATE or INSERT in a select would only produce invalid sql.
+ */
+public class SqlFuzzer {
+
+  private static final Logger logger = Logger.getLogger(SqlFuzzer.class.getName());
+
+  /** the select keyword the select list follows */
+  private static final Pattern select = Pattern.compile("(?is)^\\s*select\\s+");
+
+  private static final String[] castTypes = {"INTEGER", "BIGINT", "DOUBLE", "DATE", "TIMESTAMP"};
+
+  private final Random random;
+  private final double rate;
+
+  /**
+   * @param random source of randomness for the mutations
+   * @param rate fraction of queries between 0 and 1 to mutate
+   */
+  public SqlFuzzer(final Random random, final double rate) {
+    if (rate < 0 || rate > 1) {
+      throw new InvalidParameterException("fuzz rate must be between 0 and 1 but was " + rate);
+    }
+    this.random = random;
+    this.rate = rate;
+  }
+
+  /**
+   * mutates the sql text at the configured rate, every mutated statement is logged so the run can
+   * be reproduced
+   *
+   * @param sql the sql to mutate
+   * @return the sql unchanged or a mutated version of it
+   */
+  public String fuzz(final String sql) {
+    if (rate == 0 || !isQuery(sql) || random.nextDouble() >= rate) {
+      return sql;
+    }
+    final String mutated;
+    switch (random.nextInt(3)) {
+      case 0:
+        mutated = randomColumnSubset(sql);
+        break;
+      case 1:
+        mutated = randomPredicate(sql);
+        break;
+      default:
+        mutated = badCast(sql);
+        break;
+    }
+    logger.warning(() -> String.format("fuzzed query: %s", mutated));
+    return mutated;
+  }
+
+  /**
+   * @param sql the sql of a statement
+   * @return true for a single statement starting with SELECT or WITH
+   */
+  static boolean isQuery(final String sql) {
+    if (sql == null || StatementSplitter.split(sql).size() != 1) {
+      return false;
+    }
+    final List<String> words = ReadOnlyGuard.keywords(sql);
+    return !words.isEmpty() && ("SELECT".equals(words.get(0)) || "WITH".equals(words.get(0)));
+  }
+
+  /**
+   * finds the select list of a statement starting with SELECT, the FROM of a function call such as
+   * extract(year from d) or of a subquery is inside parentheses and does not end it
+   *
+   * @param sql the statement
+   * @return the start and end of the select list, null when the statement has no FROM
+   */
+  static int[] selectList(final String sql) {
+    final Matcher m = select.matcher(sql);
+    if (!m.lookingAt()) {
+      return null;
+    }
+    int depth = 0;
+    char quote = 0;
+    for (int i = m.end(); i < sql.length(); i++) {
+      final char c = sql.charAt(i);
+      if (quote != 0) {
+        // a doubled quote closes and opens again, so it needs no special case
+        if (c == quote) {
+          quote = 0;
+        }
+      } else if (c == '\'' || c == '"') {
+        quote = c;
+      } else if (c == '(') {
+        depth++;
+      } else if (c == ')') {
+        depth--;
+      } else if (depth == 0 && isFrom(sql, i)) {
+        return new int[] {m.end(), i};
+      }
+    }
+    return null;
+  }
+
+  private static boolean isFrom(final String sql, final int i) {
+    final int end = i + 4;
+    return sql.regionMatches(true, i, "from", 0, 4)
+        && Character.isWhitespace(sql.charAt(i - 1))
+        && (end == sql.length() || !Character.isJavaIdentifierPart(sql.charAt(end)));
+  }
+
+  /**
+   * splits a select list on the commas between columns, commas inside function calls and quotes
+   * stay in their column
+   *
+   * @param list the select list
+   * @return the trimmed columns
+   */
+  static List<String> columns(final String list) {
+    final List<String> columns = new ArrayList<>();
+    int depth = 0;
+    char quote = 0;
+    int start = 0;
+    for (int i = 0; i < list.length(); i++) {
+      final char c = list.charAt(i);
+      if (quote != 0) {
+        if (c == quote) {
+          quote = 0;
+        }
+      } else if (c == '\'' || c == '"') {
+        quote = c;
+      } else if (c == '(') {
+        depth++;
+      } else if (c == ')') {
+        depth--;
+      } else if (c == ',' && depth == 0) {
+        addColumn(columns, list.substring(start, i));
+        start = i + 1;
+      }
+    }
+    addColumn(columns, list.substring(start));
+    return columns;
+  }
+
+  private static void addColumn(final List<String> columns, final String column) {
+    if (!column.trim().isEmpty()) {
+      columns.add(column.trim());
+    }
+  }
+
+  String randomColumnSubset(final String sql) {
+    final int[] range = selectList(sql);
+    if (range == null || sql.substring(range[0], range[1]).trim().equals("*")) {
+      return randomPredicate(sql);
+    }
+    final List<String> columns = columns(sql.substring(range[0], range[1]));
+    final List<String> subset = new ArrayList<>();
+    for (final String c : columns) {
+      if (random.nextBoolean()) {
+        subset.add(c);
+      }
+    }
+    if (subset.isEmpty()) {
+      subset.add(columns.get(random.nextInt(columns.size())));
+    }
+    return sql.substring(0, range[0]) + String.join(", ", subset) + " " + sql.substring(range[1]);
+  }
+
+  String randomPredicate(final String sql) {
+    final int value = random.nextInt(100);
+    final String predicate;
+    if (random.nextBoolean()) {
+      predicate = String.format("%d = %d", value, random.nextInt(100));
+    } else {
+      predicate = String.format("%d IS NOT NULL", value);
+    }
+    return String.format("SELECT * FROM (%s) fuzz WHERE %s", stripSemicolon(sql), predicate);
+  }
+
+  String badCast(final String sql) {
+    final String type = castTypes[random.nextInt(castTypes.length)];
+    final String literal = "fuzz" + random.nextInt(1000);
+    return String.format(
+        Locale.ROOT,
+        "SELECT CAST('%s' AS %s) AS fuzz_cast, fuzz.* FROM (%s) fuzz",
+        literal,
+        type,
+        stripSemicolon(sql));
+  }
+
+  private String stripSemicolon(final String sql) {
+    final String trimmed = sql.trim();
+    if (trimmed.endsWith(";")) {
+      return trimmed.substring(0, trimmed.length() - 1);
+    }
+    return trimmed;
+  }
+}
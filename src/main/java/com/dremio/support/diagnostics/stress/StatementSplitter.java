@@ -0,0 +1,87 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.ArrayList;
+import java.util.List;
+
+/**
+ * StatementSplitter splits a script copied from an editor into its statements. Semicolons inside
+ * string literals, quoted identifiers and comments do not end a statement, and pieces holding
+ * nothing but whitespace and comments, such as after a trailing semicolon, are dropped.
+ */
+public class StatementSplitter {
+
+  /** prevent instantiation */
+  private StatementSplitter() {}
+
+  /**
+   * splits the sql on semicolons that end a statement
+   *
+   * @param sql one or more statements
+   * @return the trimmed statements without their semicolons, the sql itself when there is nothing
+   *     to split
+   */
+  public static List<String> split(final String sql) {
+    final List<String> statements = new ArrayList<>();
+    int start = 0;
+    boolean hasCode = false;
+    int i = 0;
+    while (i < sql.length()) {
+      final char c = sql.charAt(i);
+      if (sql.startsWith("--", i)) {
+        final int newline = sql.indexOf('\n', i);
+        i = newline < 0 ? sql.length() : newline;
+      } else if (sql.startsWith("/*", i)) {
+        final int close = sql.indexOf("*/", i + 2);
+        i = close < 0 ? sql.length() : close + 2;
+      } else if (c == '\'' || c == '"') {
+        i = closingQuote(sql, i, c);
+        hasCode = true;
+      } else if (c == ';') {
+        if (hasCode) {
+          statements.add(sql.substring(start, i).trim());
+        }
+        start = i + 1;
+        hasCode = false;
+        i++;
+      } else {
+        hasCode |= !Character.isWhitespace(c);
+        i++;
+      }
+    }
+    if (hasCode) {
+      statements.add(sql.substring(start).trim());
+    }
+    if (statements.isEmpty()) {
+      statements.add(sql);
+    }
+    return statements;
+  }
+
+  private static int closingQuote(final String sql, final int start, final char quote) {
+    int i = start + 1;
+    while (i < sql.length()) {
+      if (sql.charAt(i) == quote) {
+        if (i + 1 < sql.length() && sql.charAt(i + 1) == quote) {
+          i += 2;
+          continue;
+        }
+        return i + 1;
+      }
+      i++;
+    }
+    return sql.length();
+  }
+}
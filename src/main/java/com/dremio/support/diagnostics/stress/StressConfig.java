@@ -19,6 +19,9 @@ public class StressConfig {
 
   private List<QueryConfig> queries;
   private List<QueryGroup> queryGroups;
+  private List<String> sessionInit;
+  private QueryDefaults defaults;
+  private List<UserRate> users;
 
   public List<QueryConfig> getQueries() {
     return queries;
@@ -35,4 +38,44 @@ public class StressConfig {
   public void setQueryGroups(List<QueryGroup> queryGroups) {
     this.queryGroups = queryGroups;
   }
+
+  /**
+   * statements run once per connection before the workload starts, for example ALTER SESSION SET
+   *
+   * @return session initialization statements
+   */
+  public List<String> getSessionInit() {
+    return sessionInit;
+  }
+
+  public void setSessionInit(List<String> sessionInit) {
+    this.sessionInit = sessionInit;
+  }
+
+  /**
+   * settings applied to the queries that do not set them
+   *
+   * @return the defaults or null
+   */
+  public QueryDefaults getDefaults() {
+    return defaults;
+  }
+
+  public void setDefaults(QueryDefaults defaults) {
+    this.defaults = defaults;
+  }
+
+  /**
+   * rate caps of the simulated sessions, the first sessions take the users in order and the
+   * remaining sessions are not capped
+   *
+   * @return the users or null
+   */
+  public List<UserRate> getUsers() {
+    return users;
+  }
+
+  public void setUsers(List<UserRate> users) {
+    this.users = users;
+  }
 }
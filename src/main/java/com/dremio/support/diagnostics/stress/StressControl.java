@@ -0,0 +1,77 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.Map;
+
+/** operations an operator can perform on a running stress job */
+public interface StressControl {
+
+  /** stops submitting new queries, queries already in flight are allowed to finish */
+  void pause();
+
+  /** resumes submitting queries after a pause */
+  void resume();
+
+  /** ends the run as if the duration was reached, the summary is still printed */
+  void stop();
+
+  /**
+   * adds an annotation to the timeline of the run, such as "executor killed", to correlate cluster
+   * events with latency changes
+   *
+   * @param message what happened
+   */
+  void annotate(String message);
+
+  /**
+   * stops submitting the queries referencing a query group, the other queries continue
+   *
+   * @param name the query group
+   * @throws java.security.InvalidParameterException when there is no such query group
+   */
+  void pauseGroup(String name);
+
+  /**
+   * resumes submitting the queries referencing a query group after a pause
+   *
+   * @param name the query group
+   */
+  void resumeGroup(String name);
+
+  /**
+   * caps the rate queries are submitted at, a query group iteration counts as one submission
+   *
+   * @param perSecond submissions per second, 0 removes the cap
+   */
+  void setRate(double perSecond);
+
+  /**
+   * changes the number of shared workers, the queries in flight of removed workers finish first
+   *
+   * @param workers number of shared workers, at least 1
+   * @throws UnsupportedOperationException when the run has no fixed number of workers
+   */
+  void setWorkers(int workers);
+
+  /** @return true when submission is paused */
+  boolean isPaused();
+
+  /**
+   * current statistics of the run
+   *
+   * @return map of stat name to value suitable for serializing to json
+   */
+  Map<String, Object> status();
+}
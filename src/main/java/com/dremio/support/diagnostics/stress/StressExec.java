@@ -13,6 +13,7 @@
  */
 package com.dremio.support.diagnostics.stress;
 
+import com.fasterxml.jackson.annotation.JsonInclude;
 import com.fasterxml.jackson.core.JsonProcessingException;
 import com.fasterxml.jackson.databind.ObjectMapper;
 import java.io.File;
@@ -21,117 +22,583 @@ import java.io.InputStream;
 import java.nio.file.Files;
 import java.security.InvalidParameterException;
 import java.security.SecureRandom;
+import java.sql.SQLException;
+import java.time.Duration;
 import java.time.Instant;
 import java.util.*;
 import java.util.Map.Entry;
 import java.util.concurrent.BlockingQueue;
+import java.util.concurrent.ConcurrentHashMap;
+import java.util.concurrent.DelayQueue;
+import java.util.concurrent.Delayed;
 import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
 import java.util.concurrent.LinkedBlockingQueue;
+import java.util.concurrent.RejectedExecutionException;
+import java.util.concurrent.SynchronousQueue;
 import java.util.concurrent.ThreadPoolExecutor;
 import java.util.concurrent.TimeUnit;
+import java.util.concurrent.atomic.AtomicBoolean;
 import java.util.concurrent.atomic.AtomicInteger;
 import java.util.concurrent.atomic.AtomicLong;
 import java.util.logging.Level;
 import java.util.logging.Logger;
+import java.util.regex.Pattern;
+import java.util.stream.Collectors;
 import java.util.zip.GZIPInputStream;
 import org.apache.commons.lang3.exception.ExceptionUtils;
 
-public class StressExec {
+public class StressExec implements StressControl {
 
   private static final Logger logger = Logger.getLogger(StressExec.class.getName());
+  // built in token replaced with a value unique to each query group iteration
+  private static final Pattern uniqToken = Pattern.compile(":uniq\\b");
+  // most queries replaying the original concurrency run at once, later ones are dropped
+  private static final int maxReplayThreads = 1000;
+  // statements --explain-only runs as they are, they only change the session or do not execute
+  private static final Set<String> notPlanned =
+      new HashSet<>(Arrays.asList("EXPLAIN", "SHOW", "DESCRIBE", "DESC", "USE"));
+  // profiles of failed jobs kept in the output directory, a failing workload fails every query
+  private static final int maxProfiles = 20;
   private final Random random;
   private final File jsonConfig;
   private final QueriesGeneratorFileType fileType;
+  private StressConfig config;
   private final QueriesSequence queriesSequence;
   private final Integer queryIndexForRestart;
   private final Integer limitResults;
+  // slice of queries.json to replay, null replays every query
+  private final ReplayFilter replayFilter;
+  // subset of the stress.json queries to run by their labels, null runs every query
+  private final LabelFilter labelFilter;
+  // weights of the stress.json queries by label given on the command line, null keeps the config
+  private final LabelWeights labelWeights;
+  // stress.json parameter values given on the command line, null keeps the configured ones
+  private final ParameterOverrides parameterOverrides;
+  // refuse unknown stress.json fields and queries without a frequency instead of warning
+  private final boolean strict;
+  // replays queries.json at its original pace sped up by this factor, null ignores the pace
+  private final Double replaySpeed;
+  // start every replayed query at its time on a thread of its own instead of waiting for a worker
+  private final boolean replayOriginalConcurrency;
+  // original start of the first paced query, the offsets of the others are relative to it
+  private Long replayBaseMS;
+  // queries of stress.json by their startAfter in milliseconds that the run has not reached yet,
+  // empty when no query is delayed or limited to a number of executions
+  private final TreeMap<Long, List<QueryConfig>> pendingOnsets = new TreeMap<>();
+  private final List<QueryConfig> activeQueries = new ArrayList<>();
+  // random picks come from the started queries that have executions left, rebuilt as they change
+  private List<QueryConfig> activePool;
+  // submissions of the queries with a maxExecutions, by identity of their config
+  private final Map<QueryConfig, Integer> submissions = new IdentityHashMap<>();
+  // every query ran its maxExecutions, the run ends
+  private final AtomicBoolean exhausted = new AtomicBoolean(false);
+  // query groups whose queries are not submitted, paused by the operator
+  private final Set<String> pausedGroups = ConcurrentHashMap.newKeySet();
+  private final AtomicBoolean pausedGroupsChanged = new AtomicBoolean(false);
+  // the pool without the paused query groups and the pool it was computed from
+  private List<QueryConfig> unpausedPool;
+  private List<QueryConfig> unpausedFrom;
+  // cap on the submissions per second set by the operator, null when not capped
+  private volatile TokenBucket rateLimit;
+  // the shared workers, resized by the operator
+  private volatile ThreadPoolExecutor sharedExecutor;
+  // query groups that completed an iteration with every statement successful
+  private final Set<String> completedGroups = ConcurrentHashMap.newKeySet();
+  // a query ran its maxExecutions or a query group completed, the random picks are rebuilt
+  private final AtomicBoolean activeChanged = new AtomicBoolean(false);
+  // most replayed queries that originally ran at once, 0 when unknown
+  private volatile int originalPeakConcurrency;
   private final Protocol protocol;
   private final String dremioHost;
+  // the urls of --url, more than one when spreading the load over several coordinators
+  private final Coordinators coordinators;
   private final String dremioUser;
   private final String dremioPassword;
   private final Integer timeoutSeconds;
   private final long durationTargetMS;
+  private final int warmUpSeconds;
+  private final String warmUpSql;
+  private final int maxQueries;
+  // percent of failed statements above which the run fails
+  private final double maxErrorRate;
+  private final int printQueries;
+  private final boolean hardDeadline;
+  private final int watchdogFactor;
+  private final boolean watchdogRestart;
+  // consecutive failures after which a worker reconnects, 0 disables it
+  private final int restartAfterFailures;
+  // how often every connection is health checked, 0 disables the checks
+  private final int healthCheckSeconds;
+  // how often the probe statement runs next to the workload, 0 disables the probe
+  private final int probeSeconds;
+  private final String probeSql;
+  // busy time of the shared and query group workers, one thread per query replays are not tracked
+  private final WorkerUtilization utilization = new WorkerUtilization();
+  // throughput, errors and latency per time bucket, null when disabled
+  private final TimeSeries timeSeries;
   private final Integer maxQueriesInFlight;
+  // multiplier of the intensity, applied to the workers of query groups and the rates of users too
+  private final double scale;
+  private final int queueSize;
+  // logical users multiplexed over the workers, 0 disables session simulation
+  private final int sessions;
+  private final int thinkTimeMS;
+  // every worker logs in (HTTP) or connects (JDBC) on its own instead of sharing one connection
+  private final boolean connectionPerWorker;
+  // connections older than this are replaced, 0 keeps them for the whole run
+  private final long recycleNanos;
+  // every interval chaos drops a percentage of the connections or delays a percentage of the
+  // statements, 0 disables it
+  private final long chaosIntervalMS;
+  private final double chaosPercent;
+  private final int chaosDelayMS;
+  // statements starting before this are delayed by chaos
+  private volatile long chaosDelayUntilNanos = System.nanoTime();
   private final ConnectApi connectApi;
   private final boolean skipSSLVerification;
+  private final SqlFuzzer fuzzer;
+  private final boolean preparedStatements;
+  private final Notifier notifier;
+  // where to write the results json, null when not written
+  private final File resultsFile;
+  private final ArtifactUploader uploader;
+  // sqlite file every run appends its results to, null when not used
+  private final ResultsStore resultsStore;
+  // directory collecting every artifact of the run, null when not used
+  private final File outputDir;
+  // space created with the Samples source before the run, null when not provisioning
+  private final String provisionSpace;
+  // refuse workloads that could write, leave the writes out of replays
+  private final boolean readOnly;
+  // wrap statements in EXPLAIN PLAN FOR so only the planner runs
+  private final boolean explainOnly;
+  // second cluster every statement is mirrored to, null when not comparing
+  private final String compareHost;
+  // cluster a percentage of the statements is duplicated to, null when not shadowing
+  private final String shadowHost;
+  private final double shadowPercent;
+  // the settings of the run as resolved from the flags, secrets redacted
+  private final Map<String, Object> settings = new LinkedHashMap<>();
 
-  public StressExec(
-      final ConnectApi connectApi,
-      final File jsonConfig,
-      final QueriesGeneratorFileType fileType,
-      final QueriesSequence queriesSequence,
-      final Integer queryIndexForRestart,
-      final Integer limitResults,
-      final Protocol protocol,
-      final String dremioHost,
-      final String dremioUser,
-      final String dremioPassword,
-      final Integer maxQueriesInFlight,
-      final Integer timeoutSeconds,
-      final Integer durationSeconds,
-      final boolean skipSSLVerification) {
-    this(
-        new SecureRandom(),
-        connectApi,
-        jsonConfig,
-        fileType,
-        queriesSequence,
-        queryIndexForRestart,
-        limitResults,
-        protocol,
-        dremioHost,
-        dremioUser,
-        dremioPassword,
-        maxQueriesInFlight,
-        timeoutSeconds,
-        durationSeconds,
-        skipSSLVerification);
+  public StressExec(final ConnectApi connectApi, final StressOptions options) {
+    this(new SecureRandom(), connectApi, options);
   }
 
   public StressExec(
-      final Random random,
-      final ConnectApi connectApi,
-      final File jsonConfig,
-      final QueriesGeneratorFileType fileType,
-      final QueriesSequence queriesSequence,
-      final Integer queryIndexForRestart,
-      final Integer limitResults,
-      final Protocol protocol,
-      final String dremioHost,
-      final String dremioUser,
-      final String dremioPassword,
-      final Integer maxQueriesInFlight,
-      final Integer timeoutSeconds,
-      final Integer durationSeconds,
-      final boolean skipSSLVerification) {
+      final Random random, final ConnectApi connectApi, final StressOptions options) {
     this.random = random;
     this.connectApi = connectApi;
-    this.jsonConfig = jsonConfig;
-    this.fileType = fileType;
-    this.queriesSequence = queriesSequence;
-    this.queryIndexForRestart = queryIndexForRestart;
-    this.limitResults = limitResults;
-    this.protocol = protocol;
-    this.dremioHost = dremioHost;
-    this.dremioUser = dremioUser;
-    this.dremioPassword = dremioPassword;
-    this.maxQueriesInFlight = maxQueriesInFlight;
-    this.timeoutSeconds = timeoutSeconds;
-    this.durationTargetMS = durationSeconds * 1000L;
-    this.skipSSLVerification = skipSSLVerification;
+    this.jsonConfig = options.getJsonConfig();
+    this.fileType = options.getFileType();
+    final Double replaySpeed = options.getReplaySpeed();
+    final boolean replayOriginalConcurrency = options.isReplayOriginalConcurrency();
+    if (replaySpeed != null && replaySpeed <= 0) {
+      throw new InvalidParameterException(
+          "replay speed must be greater than 0 but was " + replaySpeed);
+    }
+    if ((replaySpeed != null || replayOriginalConcurrency)
+        && fileType != QueriesGeneratorFileType.QUERIES_JSON) {
+      throw new InvalidParameterException(
+          "replay speed and original concurrency need a QUERIES_JSON replay");
+    }
+    // the original concurrency follows from starting every query at its original time
+    this.replaySpeed = replaySpeed == null && replayOriginalConcurrency ? 1.0 : replaySpeed;
+    this.replayOriginalConcurrency = replayOriginalConcurrency;
+    // the original pace only makes sense in the original order
+    this.queriesSequence =
+        this.replaySpeed != null ? QueriesSequence.SEQUENTIAL : options.getQueriesSequence();
+    this.queryIndexForRestart = options.getQueryIndexForRestart();
+    this.limitResults = options.getLimitResults();
+    final ReplayFilter replayFilter = options.getReplayFilter();
+    this.replayFilter = replayFilter == null || replayFilter.isEmpty() ? null : replayFilter;
+    final LabelFilter labelFilter = options.getLabelFilter();
+    this.labelFilter = labelFilter == null || labelFilter.isEmpty() ? null : labelFilter;
+    final LabelWeights labelWeights = options.getLabelWeights();
+    this.labelWeights = labelWeights == null || labelWeights.isEmpty() ? null : labelWeights;
+    final ParameterOverrides parameterOverrides = options.getParameterOverrides();
+    this.parameterOverrides =
+        parameterOverrides == null || parameterOverrides.isEmpty() ? null : parameterOverrides;
+    this.strict = options.isStrict();
+    this.protocol = options.getProtocol();
+    this.dremioHost = options.getDremioHost();
+    this.coordinators = Coordinators.parse(dremioHost);
+    for (final String url : coordinators.urls()) {
+      ConnectionUrl.validate(url, protocol);
+    }
+    this.dremioUser = options.getDremioUser();
+    this.dremioPassword = options.getDremioPassword();
+    final String compareHost = options.getCompareHost();
+    this.compareHost = compareHost == null || compareHost.isEmpty() ? null : compareHost;
+    final String shadowHost = options.getShadowHost();
+    this.shadowHost = shadowHost == null || shadowHost.isEmpty() ? null : shadowHost;
+    if (this.compareHost != null) {
+      ConnectionUrl.validate(this.compareHost, protocol);
+    }
+    if (this.shadowHost != null) {
+      ConnectionUrl.validate(this.shadowHost, protocol);
+    }
+    this.shadowPercent = options.getShadowPercent() == null ? 0 : options.getShadowPercent();
+    if (this.shadowPercent < 0 || this.shadowPercent > 100) {
+      throw new InvalidParameterException(
+          "shadow percent must be between 0 and 100 but was " + options.getShadowPercent());
+    }
+    final Double scale = options.getScale();
+    this.maxQueriesInFlight = scaleQueriesInFlight(options.getMaxQueriesInFlight(), scale);
+    this.scale = scale == null ? 1 : scale;
+    this.queueSize = options.getQueueSize() == null ? 0 : options.getQueueSize();
+    this.sessions = options.getSessions() == null ? 0 : options.getSessions();
+    this.thinkTimeMS = options.getThinkTimeMS() == null ? 0 : options.getThinkTimeMS();
+    this.connectionPerWorker = options.isConnectionPerWorker();
+    if (connectionPerWorker && this.sessions > 0) {
+      throw new InvalidParameterException(
+          "connection per worker cannot be combined with sessions, every session already has its"
+              + " own connection");
+    }
+    final Integer recycleConnectionsSeconds = options.getRecycleConnectionsSeconds();
+    this.recycleNanos =
+        recycleConnectionsSeconds == null
+            ? 0
+            : TimeUnit.SECONDS.toNanos(Math.max(recycleConnectionsSeconds, 0));
+    final Integer chaosIntervalSeconds = options.getChaosIntervalSeconds();
+    this.chaosIntervalMS =
+        chaosIntervalSeconds == null ? 0 : Math.max(chaosIntervalSeconds, 0) * 1000L;
+    this.chaosPercent = options.getChaosPercent() == null ? 0 : options.getChaosPercent();
+    if (this.chaosPercent < 0 || this.chaosPercent > 100) {
+      throw new InvalidParameterException(
+          "chaos percent must be between 0 and 100 but was " + options.getChaosPercent());
+    }
+    this.chaosDelayMS = options.getChaosDelayMS() == null ? 0 : options.getChaosDelayMS();
+    this.timeoutSeconds = options.getTimeoutSeconds();
+    this.warmUpSeconds = options.getWarmUpSeconds() == null ? 0 : options.getWarmUpSeconds();
+    this.warmUpSql = options.getWarmUpSql();
+    this.durationTargetMS = options.getDurationSeconds() * 1000L;
+    this.maxQueries = options.getMaxQueries() == null ? 0 : options.getMaxQueries();
+    this.maxErrorRate = options.getMaxErrorRate() == null ? 0.0 : options.getMaxErrorRate();
+    this.printQueries = options.getPrintQueries() == null ? 0 : options.getPrintQueries();
+    this.hardDeadline = options.isHardDeadline();
+    this.watchdogFactor = options.getWatchdogFactor() == null ? 0 : options.getWatchdogFactor();
+    this.watchdogRestart = options.isWatchdogRestart();
+    this.restartAfterFailures =
+        options.getRestartAfterFailures() == null ? 0 : options.getRestartAfterFailures();
+    this.healthCheckSeconds =
+        options.getHealthCheckSeconds() == null ? 0 : options.getHealthCheckSeconds();
+    this.probeSeconds = options.getProbeSeconds() == null ? 0 : options.getProbeSeconds();
+    this.probeSql = options.getProbeSql() == null ? "SELECT 1" : options.getProbeSql();
+    this.readOnly = options.isReadOnly();
+    if (readOnly && this.probeSeconds > 0 && !ReadOnlyGuard.isReadOnly(this.probeSql)) {
+      throw new InvalidParameterException("the probe statement writes, not read-only");
+    }
+    final Integer bucketSeconds = options.getBucketSeconds();
+    this.timeSeries =
+        bucketSeconds == null || bucketSeconds <= 0 ? null : new TimeSeries(bucketSeconds);
+    this.notifier = new Notifier(options.getNotifyWebhook());
+    final File resultsDb = options.getResultsDb();
+    this.resultsStore = resultsDb == null ? null : new ResultsStore(resultsDb);
+    final String uploadUri = options.getUploadUri();
+    this.uploader =
+        uploadUri == null || uploadUri.isEmpty() ? null : new ArtifactUploader(uploadUri);
+    this.outputDir = options.getOutputDir();
+    if (outputDir != null) {
+      this.resultsFile = new File(outputDir, "results.json");
+    } else if (options.getResultsFile() == null && this.uploader != null) {
+      // there must be something to upload
+      this.resultsFile = new File("dremio-stress-results-" + runId + ".json");
+    } else {
+      this.resultsFile = options.getResultsFile();
+    }
+    this.provisionSpace = options.getProvisionSpace();
+    this.skipSSLVerification = options.isSkipSSLVerification();
+    if (provisionSpace != null && protocol != Protocol.HTTP) {
+      throw new InvalidParameterException("provisioning uses the rest api and needs HTTP");
+    }
+    this.explainOnly = options.isExplainOnly();
+    if (readOnly && provisionSpace != null) {
+      throw new InvalidParameterException(
+          "provisioning creates a space and a source, not read-only");
+    }
+    if (readOnly && !ReadOnlyGuard.isReadOnly(warmUpSql)) {
+      throw new InvalidParameterException("the warm-up statement writes, not read-only");
+    }
+    this.fuzzer = new SqlFuzzer(random, options.getFuzzRate() == null ? 0 : options.getFuzzRate());
+    this.preparedStatements = options.isPreparedStatements();
+    if (preparedStatements && protocol != Protocol.JDBC) {
+      throw new InvalidParameterException("prepared statements are only supported with JDBC");
+    }
+    settings.put("config", String.valueOf(jsonConfig));
+    settings.put("fileType", String.valueOf(fileType));
+    settings.put("queriesSequence", String.valueOf(options.getQueriesSequence()));
+    settings.put("queryIndexForRestart", queryIndexForRestart);
+    settings.put("limitResults", limitResults);
+    settings.put(
+        "replayFilter", this.replayFilter == null ? null : this.replayFilter.toString());
+    settings.put("labelFilter", this.labelFilter == null ? null : this.labelFilter.toString());
+    settings.put("labelWeights", this.labelWeights == null ? null : this.labelWeights.toString());
+    settings.put(
+        "parameterOverrides",
+        this.parameterOverrides == null ? null : this.parameterOverrides.toString());
+    settings.put("strict", strict);
+    settings.put("replaySpeed", this.replaySpeed);
+    settings.put("replayOriginalConcurrency", replayOriginalConcurrency);
+    settings.put("protocol", String.valueOf(protocol));
+    settings.put("url", Redact.url(dremioHost));
+    settings.put("user", dremioUser);
+    settings.put("password", dremioPassword == null ? null : "***");
+    settings.put("compareUrl", Redact.url(this.compareHost));
+    settings.put("shadowUrl", Redact.url(this.shadowHost));
+    settings.put("shadowPercent", this.shadowPercent);
+    settings.put("maxQueriesInFlight", this.maxQueriesInFlight);
+    settings.put("scale", scale);
+    settings.put("sessions", this.sessions);
+    settings.put("thinkTimeMS", this.thinkTimeMS);
+    settings.put("connectionPerWorker", connectionPerWorker);
+    settings.put("recycleConnectionsSeconds", recycleConnectionsSeconds);
+    settings.put("chaosIntervalSeconds", chaosIntervalSeconds);
+    settings.put("chaosPercent", this.chaosPercent);
+    settings.put("chaosDelayMS", this.chaosDelayMS);
+    settings.put("queueSize", this.queueSize);
+    settings.put("fuzzRate", options.getFuzzRate());
+    settings.put("preparedStatements", preparedStatements);
+    settings.put("timeoutSeconds", timeoutSeconds);
+    settings.put("warmUpSeconds", this.warmUpSeconds);
+    settings.put("warmUpSql", warmUpSql);
+    settings.put("durationSeconds", options.getDurationSeconds());
+    settings.put("maxQueries", this.maxQueries);
+    settings.put("maxErrorRate", this.maxErrorRate);
+    settings.put("hardDeadline", hardDeadline);
+    settings.put("watchdogFactor", this.watchdogFactor);
+    settings.put("watchdogRestart", watchdogRestart);
+    settings.put("restartAfterFailures", this.restartAfterFailures);
+    settings.put("healthCheckSeconds", this.healthCheckSeconds);
+    settings.put("probeSeconds", this.probeSeconds);
+    settings.put("probeSql", this.probeSeconds > 0 ? this.probeSql : null);
+    settings.put("bucketSeconds", bucketSeconds);
+    settings.put("notifyWebhook", Redact.path(options.getNotifyWebhook()));
+    settings.put("resultsFile", this.resultsFile == null ? null : this.resultsFile.toString());
+    settings.put("resultsDb", resultsDb == null ? null : resultsDb.toString());
+    settings.put("outputDir", outputDir == null ? null : outputDir.toString());
+    settings.put("uploadUri", uploadUri);
+    settings.put("provisionSpace", provisionSpace);
+    settings.put("readOnly", readOnly);
+    settings.put("explainOnly", explainOnly);
+    settings.put("skipSSLVerification", skipSSLVerification);
+  }
+
+  /**
+   * applies the scale factor to the max queries in flight. Frequencies are relative weights so the
+   * only way to increase the intensity of the same workload is to run more of it at once.
+   *
+   * @param maxQueriesInFlight configured max queries in flight
+   * @param scale multiplier to apply, must be greater than 0
+   * @return the scaled number of queries in flight, never less than 1
+   */
+  static int scaleQueriesInFlight(final Integer maxQueriesInFlight, final Double scale) {
+    if (scale == null) {
+      return maxQueriesInFlight;
+    }
+    if (scale <= 0) {
+      throw new InvalidParameterException("scale must be greater than 0 but was " + scale);
+    }
+    final int scaled = Math.max((int) Math.round(maxQueriesInFlight * scale), 1);
+    if (scaled != maxQueriesInFlight) {
+      logger.info(
+          String.format(
+              "scaling max queries in flight from %d to %d (scale %.2f)",
+              maxQueriesInFlight, scaled, scale));
+    }
+    return scaled;
+  }
+
+  /**
+   * applies the scale factor to the dedicated workers of a query group, so the share of the group
+   * in the workload stays the same as the shared workers are scaled
+   *
+   * @param workers configured dedicated workers
+   * @param scale multiplier to apply
+   * @return the scaled number of workers, never less than 1
+   */
+  static int scaleWorkers(final int workers, final double scale) {
+    return Math.max((int) Math.round(workers * scale), 1);
   }
 
   private final AtomicInteger counter = new AtomicInteger(0);
+  // the next iteration did not fit in --max-queries, nothing more is submitted
+  private final AtomicBoolean maxQueriesFull = new AtomicBoolean(false);
   private final AtomicInteger submittedCounter = new AtomicInteger(0);
   private final AtomicInteger failureCounter = new AtomicInteger(0);
+  // statements of query groups not run because an earlier step failed, neither successes nor
+  // failures
+  private final AtomicInteger skippedCounter = new AtomicInteger(0);
   private final AtomicInteger successfulCounter = new AtomicInteger(0);
+  // statements mutated by the fuzzer and those of them that failed, the invalid sql the fuzzer
+  // produces on purpose is neither a success nor a failure of the workload
+  private final AtomicInteger fuzzedCounter = new AtomicInteger(0);
+  private final AtomicInteger fuzzFailureCounter = new AtomicInteger(0);
   private final AtomicLong totalDurationMS = new AtomicLong(0);
+  // queries handed to an executor that have not yet started
+  private final AtomicInteger queuedCounter = new AtomicInteger(0);
+  private final AtomicLong totalQueueWaitMS = new AtomicLong(0);
+  private final AtomicLong queueWaitSamples = new AtomicLong(0);
+  private final AtomicLong generatorPausedMS = new AtomicLong(0);
+  // total time spent per job phase (planning, queued, execution) as reported by the job api
+  private final Map<String, AtomicLong> phaseTotalMS = new ConcurrentHashMap<>();
+  private final AtomicLong phaseSamples = new AtomicLong(0);
+  // latency and failures per query label of the primary cluster
+  private final Map<String, TargetStats> labelStats = new ConcurrentHashMap<>();
+  // latency and failures of every statement of the primary cluster
+  private final TargetStats runStats = new TargetStats("run", "");
+  // latency of paced replay statements from when they started and from when they were due
+  private final TargetStats actualStartStats = new TargetStats("actual start", "");
+  private final TargetStats intendedStartStats = new TargetStats("intended start", "");
+  // latency to the first row and to completion of the statements whose results were read
+  private final TargetStats firstRowStats = new TargetStats("first row", "");
+  private final TargetStats completionStats = new TargetStats("completion", "");
+  private final Map<String, TargetStats> labelFirstRowStats = new ConcurrentHashMap<>();
+  // configured queries that declare an SLA, checked at the end of the run
+  private volatile List<QueryConfig> slaQueries = Collections.emptyList();
+  // reflection hit rate per query label, only known over HTTP
+  private final Map<String, HitRate> hitRates = new ConcurrentHashMap<>();
+
+  /** how many of the queries with a label were accelerated by a reflection */
+  private static final class HitRate {
+    private final AtomicInteger accelerated = new AtomicInteger(0);
+    private final AtomicInteger total = new AtomicInteger(0);
+  }
+
+  // connections opened, reused, failed and restarted per cluster
+  private final Map<String, ConnectionStats> connectionStats = new ConcurrentHashMap<>();
+
+  /** how the connections to a cluster were used, to tell client connection churn from slowness */
+  private static final class ConnectionStats {
+    private final AtomicInteger opened = new AtomicInteger(0);
+    private final AtomicInteger reused = new AtomicInteger(0);
+    private final AtomicInteger failed = new AtomicInteger(0);
+    private final AtomicInteger reconnects = new AtomicInteger(0);
+    private final AtomicInteger unhealthy = new AtomicInteger(0);
+
+    private Map<String, Object> toMap(final String url) {
+      final Map<String, Object> map = new LinkedHashMap<>();
+      map.put("url", url);
+      map.put("opened", opened.get());
+      map.put("reused", reused.get());
+      map.put("failed", failed.get());
+      map.put("reconnects", reconnects.get());
+      map.put("unhealthy", unhealthy.get());
+      return map;
+    }
+  }
+
+  // what happened to the run and when, written to the results to correlate with latency changes
+  private final List<Event> events = Collections.synchronizedList(new ArrayList<>());
+
+  /** an entry of the timeline of the run */
+  private static final class Event {
+    private final Instant time = Instant.now();
+    private final String type;
+    private final String message;
+
+    private Event(final String type, final String message) {
+      this.type = type;
+      this.message = message;
+    }
+  }
+
+  private final AtomicBoolean paused = new AtomicBoolean(false);
+  private final AtomicBoolean stopRequested = new AtomicBoolean(false);
+  // the api workers share per coordinator, replaced when the watchdog restarts a hung connection
+  private final Map<String, DremioApi> sharedApis = new ConcurrentHashMap<>();
+  // the api and statistics of both clusters when comparing, null otherwise
+  private volatile DremioApi compareApi;
+  private volatile TargetStats primaryStats;
+  private volatile TargetStats compareStats;
+  // the api and statistics of the shadow cluster, null when not shadowing
+  private volatile DremioApi shadowApi;
+  private volatile TargetStats shadowStats;
+  // latency of the probe statement, null when not probing
+  private volatile TargetStats probeStats;
+  private volatile long lastProbeMS;
+  private Timer probeTimer;
+  // connection of each worker when every worker has its own
+  private final Map<Thread, OpenConnection> workerApis = new ConcurrentHashMap<>();
+  // every simulated session, empty when sessions are not simulated
+  private volatile List<Session> simulatedSessions = Collections.emptyList();
+  // connections of the query group iterations that run on a connection of their own
+  private final Set<DremioApi> dedicatedApis = ConcurrentHashMap.newKeySet();
+  // written next to the results file when the run starts, null when not written
+  private volatile File effectiveConfigFile;
+  // csv of every job of the run, only written to the output directory
+  private volatile JobManifest jobManifest;
+
+  /** a connection and when it was opened, so it can be recycled once it is too old */
+  private static final class OpenConnection {
+    private final String host;
+    private final DremioApi api;
+    private final long openedAtNanos = System.nanoTime();
+
+    private OpenConnection(final String host, final DremioApi api) {
+      this.host = host;
+      this.api = api;
+    }
+  }
+  // unexpected failures in a row of each worker, reset by a statement that ran as expected
+  private final Map<Thread, Integer> consecutiveFailures = new ConcurrentHashMap<>();
+  // what each worker is executing right now, used by the watchdog to find hung statements
+  private final Map<Thread, Execution> executions = new ConcurrentHashMap<>();
+
+  /** a statement being executed by a worker */
+  private static final class Execution {
+    private final Query query;
+    private final Instant start = Instant.now();
+    private boolean reported;
+
+    private Execution(final Query query) {
+      this.query = query;
+    }
+  }
+  /**
+   * a simulated user with its own connection. A session runs one statement (or group iteration) at
+   * a time and then thinks before it becomes ready again, so idle sessions hold neither a worker
+   * nor a thread.
+   */
+  private static final class Session implements Delayed {
+    private final int id;
+    // caps how often the session may start a statement, null when not capped
+    private final TokenBucket bucket;
+    // connected on first use so sessions that never run do not log in
+    private volatile OpenConnection connection;
+    private volatile long readyAtNanos = System.nanoTime();
+
+    private Session(final int id, final TokenBucket bucket) {
+      this.id = id;
+      this.bucket = bucket;
+    }
+
+    @Override
+    public long getDelay(final TimeUnit unit) {
+      return unit.convert(readyAtNanos - System.nanoTime(), TimeUnit.NANOSECONDS);
+    }
+
+    @Override
+    public int compareTo(final Delayed o) {
+      return Long.compare(getDelay(TimeUnit.NANOSECONDS), o.getDelay(TimeUnit.NANOSECONDS));
+    }
+  }
+
+  // identifies this run so :uniq values do not collide with other runs or agents
+  private final String runId = UUID.randomUUID().toString().substring(0, 8);
+  private final AtomicLong iterationSequence = new AtomicLong(0);
 
   private final Timer timer = new Timer();
   long durationLastRun = 0;
   long successfulLastRun = 0;
   int failuresLastRun = 0;
   int submittedLastRun = 0;
+  long queueWaitLastRun = 0;
+  long queueWaitSamplesLastRun = 0;
+  long generatorPausedLastRun = 0;
+  int unhealthyLastRun = 0;
+  // connections that failed a health check
+  private final AtomicInteger unhealthyCounter = new AtomicInteger(0);
   AtomicInteger queryIndex = new AtomicInteger(-1);
 
   private void startReporting(Instant d) {
@@ -154,10 +621,25 @@ public class StressExec {
             failuresLastRun = failures;
             final int submittedThisRun = submitted - submittedLastRun;
             submittedLastRun = submitted;
+            final long queueWait = totalQueueWaitMS.get();
+            final long queueWaitSamplesCount = queueWaitSamples.get();
+            final long samplesThisRun = queueWaitSamplesCount - queueWaitSamplesLastRun;
+            final long avgQueueWaitMS =
+                samplesThisRun == 0 ? 0 : (queueWait - queueWaitLastRun) / samplesThisRun;
+            queueWaitLastRun = queueWait;
+            queueWaitSamplesLastRun = queueWaitSamplesCount;
+            final long paused = generatorPausedMS.get();
+            final long pausedThisRun = paused - generatorPausedLastRun;
+            generatorPausedLastRun = paused;
+            final int unhealthy = unhealthyCounter.get();
+            final int unhealthyThisRun = unhealthy - unhealthyLastRun;
+            unhealthyLastRun = unhealthy;
             System.out.printf(
                 "%s - queries submitted (total): %d; queries successful (total): %d; queries"
                     + " successful per second (current phase): %.2f; failure rate: %.2f %% (current"
-                    + " phase) - time elapsed: %s/%s - last query index: %d%n",
+                    + " phase) - time elapsed: %s/%s - last query index: %d - queue depth: %d; avg"
+                    + " queue wait (current phase): %s; generator paused (current phase): %s;"
+                    + " unhealthy connections (current phase): %d%s%n",
                 Instant.now(),
                 submitted,
                 successful,
@@ -165,30 +647,304 @@ public class StressExec {
                 ((float) failuresThisRun / submittedThisRun) * 100.0,
                 Human.getHumanDurationFromMillis(msElapsed),
                 Human.getHumanDurationFromMillis(durationTargetMS),
-                index);
+                index,
+                queuedCounter.get(),
+                Human.getHumanDurationFromMillis(avgQueueWaitMS),
+                Human.getHumanDurationFromMillis(pausedThisRun),
+                unhealthyThisRun,
+                probeStats == null
+                    ? ""
+                    : "; probe latency (last): " + Human.getHumanDurationFromMillis(lastProbeMS));
           }
         },
         5 * 1000,
         5 * 1000);
   }
 
-  private StressConfig getConfig() {
-    try (InputStream st = Files.newInputStream(jsonConfig.toPath())) {
-      final ObjectMapper objectMapper = new ObjectMapper();
-      // TODO cache value
-      return objectMapper.readValue(st, StressConfig.class);
-    } catch (IOException e) {
-      throw new RuntimeException(e);
+  @Override
+  public void pause() {
+    if (!paused.getAndSet(true)) {
+      logger.warning("submission paused, queries in flight will continue to completion");
+    }
+  }
+
+  @Override
+  public void resume() {
+    if (paused.getAndSet(false)) {
+      logger.warning("submission resumed");
+    }
+  }
+
+  @Override
+  public void stop() {
+    if (!stopRequested.getAndSet(true)) {
+      logger.warning("stop requested, ending the run");
+    }
+  }
+
+  @Override
+  public void annotate(final String message) {
+    recordEvent("annotation", message);
+  }
+
+  @Override
+  public void pauseGroup(final String name) {
+    if (!getStringQueryGroupMap().containsKey(name)) {
+      throw new InvalidParameterException("unknown query group " + name);
+    }
+    if (pausedGroups.add(name)) {
+      pausedGroupsChanged.set(true);
+      recordEvent("pause", "query group " + name + " paused");
+    }
+  }
+
+  @Override
+  public void resumeGroup(final String name) {
+    if (pausedGroups.remove(name)) {
+      pausedGroupsChanged.set(true);
+      recordEvent("resume", "query group " + name + " resumed");
+    }
+  }
+
+  @Override
+  public void setRate(final double perSecond) {
+    rateLimit = perSecond > 0 ? new TokenBucket(perSecond * 60, (int) Math.ceil(perSecond)) : null;
+    recordEvent(
+        "rate",
+        perSecond > 0
+            ? String.format("submission capped at %s per second", Human.getHumanNumber(perSecond))
+            : "submission cap removed");
+  }
+
+  @Override
+  public void setWorkers(final int workers) {
+    final ThreadPoolExecutor executor = sharedExecutor;
+    if (executor == null || replayOriginalConcurrency) {
+      throw new UnsupportedOperationException("the run has no fixed number of workers");
+    }
+    if (workers < 1) {
+      throw new InvalidParameterException("at least 1 worker is needed");
+    }
+    // the core size can never exceed the maximum, so the order depends on the direction
+    if (workers > executor.getMaximumPoolSize()) {
+      executor.setMaximumPoolSize(workers);
+      executor.setCorePoolSize(workers);
+    } else {
+      executor.setCorePoolSize(workers);
+      executor.setMaximumPoolSize(workers);
+    }
+    recordEvent("workers", String.format("%d shared workers", workers));
+  }
+
+  @Override
+  public boolean isPaused() {
+    return paused.get();
+  }
+
+  @Override
+  public Map<String, Object> status() {
+    final Map<String, Object> status = new LinkedHashMap<>();
+    status.put("paused", paused.get());
+    status.put("pausedGroups", new TreeSet<>(pausedGroups));
+    final TokenBucket limit = rateLimit;
+    status.put("rateLimitPerSecond", limit == null ? null : limit.perSecond());
+    final ThreadPoolExecutor executor = sharedExecutor;
+    status.put("workers", executor == null ? null : executor.getMaximumPoolSize());
+    status.put("submitted", submittedCounter.get());
+    status.put("successful", successfulCounter.get());
+    status.put("failures", failureCounter.get());
+    status.put("skipped", skippedCounter.get());
+    status.put("fuzzed", fuzzedCounter.get());
+    status.put("fuzzFailures", fuzzFailureCounter.get());
+    status.put("queued", queuedCounter.get());
+    final int successful = successfulCounter.get();
+    status.put("avgLatencyMS", successful == 0 ? 0 : totalDurationMS.get() / successful);
+    status.put("stopping", stopRequested.get());
+    status.put("avgPhaseMS", averagePhases());
+    status.put("client", ClientStats.snapshot());
+    status.put("events", timeline(null));
+    return status;
+  }
+
+  /** @return true when the config is piped in, given as - */
+  private boolean readsStdin() {
+    return "-".equals(jsonConfig.getPath());
+  }
+
+  private synchronized StressConfig getConfig() {
+    // stdin can only be read once so the config is kept after the first read
+    if (config == null) {
+      try {
+        if (readsStdin()) {
+          logger.info("reading stress.json from stdin");
+          config = ConfigReader.parse("stdin", ConfigReader.readAll(System.in), strict);
+        } else {
+          config = ConfigReader.read(jsonConfig, strict);
+        }
+        if (labelFilter != null) {
+          filterLabels(config);
+        }
+        if (labelWeights != null) {
+          labelWeights.apply(config);
+        }
+        if (parameterOverrides != null) {
+          parameterOverrides.apply(config);
+        }
+      } catch (IOException e) {
+        // the message carries the location of a json error, keep it first in the output
+        throw new RuntimeException(e.getMessage(), e);
+      }
+    }
+    return config;
+  }
+
+  /**
+   * keeps only the queries of the stress.json selected by --only and --exclude
+   *
+   * @param config the parsed stress.json
+   */
+  private void filterLabels(final StressConfig config) {
+    final Map<String, QueryGroup> groups = new HashMap<>();
+    if (config.getQueryGroups() != null) {
+      for (final QueryGroup group : config.getQueryGroups()) {
+        groups.put(group.getName(), group);
+      }
+    }
+    final List<QueryConfig> queries =
+        config.getQueries() == null ? new ArrayList<>() : config.getQueries();
+    final List<QueryConfig> selected = new ArrayList<>();
+    for (final QueryConfig q : queries) {
+      if (labelFilter.accepts(q, groups.get(q.getQueryGroup()))) {
+        selected.add(q);
+      }
+    }
+    if (selected.isEmpty()) {
+      throw new InvalidParameterException(
+          String.format("no query of %s matches the labels %s", jsonConfig, labelFilter));
+    }
+    logger.info(
+        String.format(
+            "running %d of the %d queries, selected by the labels %s",
+            selected.size(), queries.size(), labelFilter));
+    config.setQueries(selected);
+  }
+
+  /**
+   * runs the queries of a query group iteration (or a single query) in order on the calling
+   * thread. Variables captured by a step are visible to the steps after it, once a step fails the
+   * remaining steps are skipped.
+   *
+   * @param dremioApi api to run the queries with
+   * @param queries the queries to run in order
+   * @param stats statistics of the cluster the api connects to, null when not comparing
+   * @return true when every statement ran as expected
+   */
+  private boolean runQueries(
+      final DremioApi dremioApi, final List<Query> queries, final TargetStats stats) {
+    final Map<String, Object> variables = new HashMap<>();
+    for (int i = 0; i < queries.size(); i++) {
+      if (Thread.currentThread().isInterrupted()) {
+        // the run has ended, do not start the remaining steps
+        return false;
+      }
+      if (!chaosDelay()) {
+        return false;
+      }
+      if (!runQuery(dremioApi, queries.get(i), variables, stats)) {
+        final List<Query> skipped = queries.subList(i + 1, queries.size());
+        if (!skipped.isEmpty()) {
+          logger.info(
+              () -> String.format("skipping the remaining %d queries of group", skipped.size()));
+          recordSkipped(skipped, stats);
+        }
+        return false;
+      }
+    }
+    return true;
+  }
+
+  /**
+   * counts the statements of a query group not run because an earlier step failed, apart from the
+   * failures so the failure rate only covers statements that ran
+   *
+   * @param skipped the statements not run
+   * @param stats statistics of the cluster the api connects to, null when not comparing
+   */
+  private void recordSkipped(final List<Query> skipped, final TargetStats stats) {
+    // shadow traffic must not change the statistics of the run
+    final boolean shadow = stats != null && stats == shadowStats;
+    final boolean primary = stats == null || stats == primaryStats;
+    if (!shadow) {
+      skippedCounter.addAndGet(skipped.size());
+    }
+    for (final Query query : skipped) {
+      if (stats != null) {
+        stats.recordSkipped();
+      }
+      if (primary) {
+        labelStats(query).recordSkipped();
+      }
+    }
+  }
+
+  /**
+   * replaces ${name} references with the captured variables
+   *
+   * @param sql sql text
+   * @param variables variables captured by earlier steps
+   * @return sql with the variables substituted
+   */
+  static String substituteVariables(final String sql, final Map<String, Object> variables) {
+    String result = sql;
+    for (final Entry<String, Object> variable : variables.entrySet()) {
+      result = result.replace("${" + variable.getKey() + "}", String.valueOf(variable.getValue()));
     }
+    return result;
   }
 
-  private void runQuery(DremioApi dremioApi, Query mappedSql) {
+  private boolean runQuery(
+      DremioApi dremioApi, Query mappedSql, Map<String, Object> variables, TargetStats stats) {
     {
+      // shadow traffic must not change the statistics of the run
+      final boolean shadow = stats != null && stats == shadowStats;
+      final boolean primary = stats == null || stats == primaryStats;
+      final Instant startTime = Instant.now();
       try {
-        Instant startTime = Instant.now();
         DremioApiResponse response = null;
-        submittedCounter.incrementAndGet();
-        response = dremioApi.runSQL(mappedSql.getQueryText(), mappedSql.getContext());
+        if (!shadow) {
+          submittedCounter.incrementAndGet();
+          if (mappedSql.isFuzzed()) {
+            fuzzedCounter.incrementAndGet();
+          }
+        }
+        mappedSql.setQueryText(substituteVariables(mappedSql.getQueryText(), variables));
+        executions.put(Thread.currentThread(), new Execution(mappedSql));
+        try {
+          if (mappedSql.getCatalogOperation() != null) {
+            response =
+                dremioApi.runCatalog(
+                    mappedSql.getCatalogOperation(),
+                    mappedSql.getCatalogPath(),
+                    mappedSql.getCatalogFormat());
+          } else if (mappedSql.getBindParameters() != null) {
+            response =
+                dremioApi.runPreparedSQL(
+                    mappedSql.getQueryText(),
+                    mappedSql.getBindParameters(),
+                    mappedSql.getContext(),
+                    mappedSql.getTimeoutMS());
+          } else {
+            response =
+                dremioApi.runSQL(
+                    mappedSql.getQueryText(),
+                    mappedSql.getContext(),
+                    mappedSql.getTimeoutMS(),
+                    mappedSql.getEngine());
+          }
+        } finally {
+          executions.remove(Thread.currentThread());
+        }
+        recordJob(mappedSql, response, startTime, dremioApi);
         if (response == null) {
           throw new RuntimeException(
               String.format("query %s failed with an empty response", mappedSql));
@@ -200,94 +956,818 @@ public class StressExec {
         }
         Instant endTime = Instant.now();
         long queryTime = endTime.toEpochMilli() - startTime.toEpochMilli();
-        totalDurationMS.addAndGet(queryTime);
-        successfulCounter.incrementAndGet();
-        logger.info(() -> String.format("query %s successful", mappedSql));
+        if (mappedSql.isExpectFailure()) {
+          // a probe such as a permission check that passes is a failure of the run
+          if (!shadow) {
+            failureCounter.incrementAndGet();
+          }
+          if (stats != null) {
+            stats.record(false, queryTime);
+          }
+          if (primary) {
+            labelStats(mappedSql).record(false, queryTime);
+            runStats.record(false, queryTime);
+            // the connection works, it must not be restarted for it
+            trackFailures(dremioApi, false);
+            recordIntended(mappedSql, false, startTime, endTime);
+            if (timeSeries != null) {
+              timeSeries.record(false, queryTime);
+            }
+          }
+          logger.severe(
+              () -> String.format("query %s succeeded but was expected to fail", mappedSql));
+          return false;
+        }
+        final Map<String, Long> phases = response.getPhases();
+        if (!shadow) {
+          totalDurationMS.addAndGet(queryTime);
+          successfulCounter.incrementAndGet();
+          recordPhases(phases);
+          recordAcceleration(mappedSql, response.getAccelerated());
+        }
+        if (stats != null) {
+          stats.record(true, queryTime);
+        }
+        if (primary) {
+          labelStats(mappedSql).record(true, queryTime);
+          runStats.record(true, queryTime);
+          trackFailures(dremioApi, false);
+          recordIntended(mappedSql, true, startTime, endTime);
+          recordFirstRow(mappedSql, response.getFirstRowMS(), queryTime);
+          if (timeSeries != null) {
+            timeSeries.record(true, queryTime);
+          }
+        }
+        if (phases != null && !phases.isEmpty()) {
+          logger.info(
+              () -> String.format("query %s successful %s", mappedSql, describePhases(phases)));
+        } else {
+          logger.info(() -> String.format("query %s successful", mappedSql));
+        }
+        if (mappedSql.getCapturedParameters() != null) {
+          variables.putAll(mappedSql.getCapturedParameters());
+        }
+        if (mappedSql.getCaptureResult() != null) {
+          variables.put(mappedSql.getCaptureResult(), dremioApi.fetchFirstValue(response));
+        }
+        return true;
       } catch (final Exception e) {
-        failureCounter.incrementAndGet();
+        final long queryTime = Instant.now().toEpochMilli() - startTime.toEpochMilli();
+        if (mappedSql.isFuzzed() && !mappedSql.isExpectFailure()) {
+          // the fuzzer produced invalid sql on purpose, this is not a failure of the cluster
+          if (!shadow) {
+            fuzzFailureCounter.incrementAndGet();
+          }
+          logger.info(() -> String.format("fuzzed query %s failed %s", mappedSql, e));
+          return true;
+        }
+        if (stats != null) {
+          stats.record(mappedSql.isExpectFailure(), queryTime);
+        }
+        if (primary) {
+          labelStats(mappedSql).record(mappedSql.isExpectFailure(), queryTime);
+          runStats.record(mappedSql.isExpectFailure(), queryTime);
+          trackFailures(dremioApi, !mappedSql.isExpectFailure());
+          recordIntended(mappedSql, mappedSql.isExpectFailure(), startTime, Instant.now());
+          if (timeSeries != null) {
+            timeSeries.record(mappedSql.isExpectFailure(), queryTime);
+          }
+        }
+        if (mappedSql.isExpectFailure()) {
+          // failures are the desired outcome for probes such as permission checks
+          if (!shadow) {
+            successfulCounter.incrementAndGet();
+          }
+          logger.info(() -> String.format("query %s failed as expected %s", mappedSql, e));
+          return true;
+        }
+        if (!shadow) {
+          failureCounter.incrementAndGet();
+        }
         logger.info(
             () ->
                 String.format(
                     "query %s failed %s %s", mappedSql, e, ExceptionUtils.getStackTrace(e)));
+        return false;
       }
     }
   }
 
-  public List<QueryConfig> getQueries() {
-    if (this.fileType == QueriesGeneratorFileType.STRESS_JSON) {
-      final StressConfig config = getConfig();
-      return getQueryConfigs(config);
-    } else {
-      List<QueryConfig> queriesConfig = new ArrayList<>();
-      if (jsonConfig.isDirectory()) {
-        logger.info("provided path " + jsonConfig + " is dir. checking for queries.json.");
-        File[] queriesDir = jsonConfig.listFiles();
-        for (File queriesFile : queriesDir) {
-          queriesConfig.addAll(openQueryJson(queriesFile));
-        }
-      } else if (jsonConfig.exists()) {
-        logger.info("provided path is a single queries.json file");
-        queriesConfig = openQueryJson(jsonConfig);
-      } else {
-        throw new RuntimeException("file or folder " + jsonConfig + " not found");
-      }
-      if (queriesConfig.isEmpty()) {
-        throw new RuntimeException("no valid queries were found");
-      } else {
-        logger.info("found a total of " + queriesConfig.size() + " queries");
-      }
-      return queriesConfig;
+  /**
+   * appends a statement to the job manifest when writing to an output directory
+   *
+   * @param query the statement
+   * @param response the response, null when there was none
+   * @param start when the statement was submitted
+   * @param api the api the statement ran on
+   */
+  private void recordJob(
+      final Query query,
+      final DremioApiResponse response,
+      final Instant start,
+      final DremioApi api) {
+    final JobManifest manifest = jobManifest;
+    if (manifest == null) {
+      return;
     }
+    final String label = query.getLabel() == null ? query.getQueryText() : query.getLabel();
+    manifest.record(
+        start,
+        label,
+        response == null ? null : response.getJobId(),
+        response != null && response.isSuccessful(),
+        Instant.now().toEpochMilli() - start.toEpochMilli(),
+        api.getUrl());
   }
 
-  public List<QueryConfig> openQueryJson(File jsonConfig) {
-    logger.info("opening " + jsonConfig);
-    List<QueryConfig> parsedQueryConfigs = new ArrayList<>();
+  /**
+   * adds the phase timings of a job to the totals of the run
+   *
+   * @param phases phase name to milliseconds, may be null when the engine does not report them
+   */
+  private void recordPhases(final Map<String, Long> phases) {
+    if (phases == null || phases.isEmpty()) {
+      return;
+    }
+    for (final Entry<String, Long> phase : phases.entrySet()) {
+      phaseTotalMS
+          .computeIfAbsent(phase.getKey(), k -> new AtomicLong(0))
+          .addAndGet(phase.getValue());
+    }
+    phaseSamples.incrementAndGet();
+  }
 
-    if (jsonConfig.toString().endsWith(".json.gz")) {
-      try (GZIPInputStream gzst = new GZIPInputStream(Files.newInputStream(jsonConfig.toPath()))) {
-        try (Scanner scanner = new Scanner(gzst)) {
-          parsedQueryConfigs = parseQueryConfigs(scanner);
-        }
-      } catch (IOException e) {
-        throw new RuntimeException(e);
+  /**
+   * compares the latency of the paced statements from their actual and their intended start, a
+   * large difference in the tail means the cluster or the workers stalled and the latency from the
+   * actual start understates what users waited
+   *
+   * @return the report
+   */
+  private String coordinatedOmissionReport() {
+    return String.format(
+        "latency corrected for coordinated omission, from the intended start: p50 %s; p95 %s; p99"
+            + " %s; max %s - from the actual start: p50 %s; p95 %s; p99 %s; max %s%n",
+        Human.getHumanDurationFromMillis(intendedStartStats.percentile(50)),
+        Human.getHumanDurationFromMillis(intendedStartStats.percentile(95)),
+        Human.getHumanDurationFromMillis(intendedStartStats.percentile(99)),
+        Human.getHumanDurationFromMillis(intendedStartStats.max()),
+        Human.getHumanDurationFromMillis(actualStartStats.percentile(50)),
+        Human.getHumanDurationFromMillis(actualStartStats.percentile(95)),
+        Human.getHumanDurationFromMillis(actualStartStats.percentile(99)),
+        Human.getHumanDurationFromMillis(actualStartStats.max()));
+  }
+
+  private static Map<String, Object> latencyMap(final TargetStats stats) {
+    final Map<String, Object> map = stats.toMap();
+    map.remove("name");
+    map.remove("url");
+    map.put("maxMS", stats.max());
+    return map;
+  }
+
+  /**
+   * records the latency of a statement that was due at a given time both from when it actually
+   * started and from when it was due. Only the latter includes the time it waited behind a stall,
+   * which the former leaves out as the stall also held back the statements that would have been
+   * slow (coordinated omission).
+   *
+   * @param query the statement
+   * @param successful true when the statement succeeded
+   * @param start when the statement started
+   * @param end when the statement ended
+   */
+  private void recordIntended(
+      final Query query, final boolean successful, final Instant start, final Instant end) {
+    if (query.getIntendedStartMS() == null) {
+      return;
+    }
+    actualStartStats.record(successful, end.toEpochMilli() - start.toEpochMilli());
+    final long due = Math.min(query.getIntendedStartMS(), start.toEpochMilli());
+    intendedStartStats.record(successful, end.toEpochMilli() - due);
+  }
+
+  /**
+   * records how long a statement took to its first row next to how long it took to complete, a
+   * dashboard waits for the former while an export waits for the latter
+   *
+   * @param query the statement
+   * @param firstRowMS time to the first row, null when the results were not read
+   * @param queryTime time to completion
+   */
+  private void recordFirstRow(final Query query, final Long firstRowMS, final long queryTime) {
+    if (firstRowMS == null) {
+      return;
+    }
+    firstRowStats.record(true, firstRowMS);
+    completionStats.record(true, queryTime);
+    final String label = query.getLabel() == null ? query.getQueryText() : query.getLabel();
+    labelFirstRowStats.computeIfAbsent(label, k -> new TargetStats(k, "")).record(true, firstRowMS);
+  }
+
+  /**
+   * compares the time to the first row with the time to completion
+   *
+   * @return the report
+   */
+  private String firstRowReport() {
+    return String.format(
+        "time to first row: p50 %s; p95 %s; p99 %s; max %s - to completion: p50 %s; p95 %s; p99"
+            + " %s; max %s%n",
+        Human.getHumanDurationFromMillis(firstRowStats.percentile(50)),
+        Human.getHumanDurationFromMillis(firstRowStats.percentile(95)),
+        Human.getHumanDurationFromMillis(firstRowStats.percentile(99)),
+        Human.getHumanDurationFromMillis(firstRowStats.max()),
+        Human.getHumanDurationFromMillis(completionStats.percentile(50)),
+        Human.getHumanDurationFromMillis(completionStats.percentile(95)),
+        Human.getHumanDurationFromMillis(completionStats.percentile(99)),
+        Human.getHumanDurationFromMillis(completionStats.max()));
+  }
+
+  private TargetStats labelStats(final Query query) {
+    final String label = query.getLabel() == null ? query.getQueryText() : query.getLabel();
+    return labelStats.computeIfAbsent(label, k -> new TargetStats(k, dremioHost));
+  }
+
+  /**
+   * writes the results json, appends them to the results store and uploads them when configured
+   *
+   * @param start when timing began
+   */
+  private void saveResults(final Instant start) {
+    if (resultsFile == null && resultsStore == null) {
+      return;
+    }
+    final Map<String, Object> results = results(start);
+    if (resultsStore != null) {
+      try {
+        resultsStore.append(results);
+        System.out.printf("results appended to %s%n", resultsStore.getFile());
+      } catch (SQLException e) {
+        logger.log(Level.SEVERE, "unable to append results to " + resultsStore.getFile(), e);
       }
-    } else if (jsonConfig.toString().endsWith(".json")) {
-      try (InputStream st = Files.newInputStream(jsonConfig.toPath())) {
-        try (Scanner scanner = new Scanner(st)) {
-          parsedQueryConfigs = parseQueryConfigs(scanner);
+    }
+    if (resultsFile == null) {
+      return;
+    }
+    try {
+      new ObjectMapper().writerWithDefaultPrettyPrinter().writeValue(resultsFile, results);
+      System.out.printf("results written to %s%n", resultsFile);
+    } catch (IOException e) {
+      logger.log(Level.SEVERE, "unable to write results to " + resultsFile, e);
+      return;
+    }
+    if (outputDir != null) {
+      finishOutputDir(results);
+    }
+    if (uploader != null) {
+      final List<File> artifacts = new ArrayList<>();
+      if (outputDir != null) {
+        collectFiles(outputDir, artifacts);
+      } else {
+        artifacts.add(resultsFile);
+        if (effectiveConfigFile != null) {
+          artifacts.add(effectiveConfigFile);
         }
+      }
+      uploader.upload(artifacts);
+    }
+  }
+
+  /**
+   * completes the output directory with the job manifest, the profiles of failed jobs and the html
+   * report
+   *
+   * @param results the results of the run
+   */
+  private void finishOutputDir(final Map<String, Object> results) {
+    final JobManifest manifest = jobManifest;
+    if (manifest != null) {
+      jobManifest = null;
+      try {
+        manifest.close();
+        System.out.printf("job manifest written to %s%n", manifest.getFile());
       } catch (IOException e) {
-        throw new RuntimeException(e);
+        logger.log(Level.WARNING, "unable to close " + manifest.getFile(), e);
       }
-    } else {
-      logger.warning("file type not supported - skipping " + jsonConfig);
+      downloadProfiles(manifest.failedJobIds());
+    }
+    final File report = new File(outputDir, "report.html");
+    try {
+      HtmlReport.write(results, report);
+      System.out.printf("report written to %s%n", report);
+    } catch (IOException e) {
+      logger.log(Level.WARNING, "unable to write the report to " + report, e);
     }
-    return parsedQueryConfigs;
   }
 
-  public List<QueryConfig> parseQueryConfigs(Scanner scanner) throws JsonProcessingException {
-    final ObjectMapper objectMapper = new ObjectMapper();
-    List<QueryConfig> configs = new ArrayList<>();
+  /**
+   * downloads the profiles of the first failed jobs to the profiles directory of the output
+   * directory, so failures can be investigated after the cluster has purged its job history
+   *
+   * @param jobIds failed jobs in the order they completed
+   */
+  private void downloadProfiles(final List<String> jobIds) {
+    final Iterator<DremioApi> apis = sharedApis.values().iterator();
+    if (jobIds.isEmpty() || !apis.hasNext()) {
+      return;
+    }
+    final DremioApi api = apis.next();
+    final File directory = new File(outputDir, "profiles");
+    if (!directory.isDirectory() && !directory.mkdirs()) {
+      logger.warning("unable to create " + directory);
+      return;
+    }
+    int downloaded = 0;
+    for (final String jobId : jobIds.subList(0, Math.min(jobIds.size(), maxProfiles))) {
+      try {
+        if (api.downloadProfile(jobId, new File(directory, jobId + ".zip"))) {
+          downloaded++;
+        }
+      } catch (IOException e) {
+        logger.log(Level.WARNING, "unable to download the profile of job " + jobId, e);
+      }
+    }
+    if (downloaded > 0) {
+      System.out.printf("%d profiles of failed jobs written to %s%n", downloaded, directory);
+    }
+  }
+
+  private static void collectFiles(final File directory, final List<File> files) {
+    final File[] children = directory.listFiles();
+    if (children == null) {
+      return;
+    }
+    for (final File child : children) {
+      if (child.isDirectory()) {
+        collectFiles(child, files);
+      } else {
+        files.add(child);
+      }
+    }
+  }
+
+  /**
+   * prints the outcome of the run as a single line of json after the human readable summary, so
+   * wrapper scripts can parse it from stdout without reading the results file
+   *
+   * @param start when timing began, null when the run was aborted before
+   * @param verdict PASS, FAIL or ABORTED
+   */
+  private void printJsonSummary(final Instant start, final String verdict) {
+    final int submitted = submittedCounter.get();
+    final List<String> violations = slaViolations();
+    final Map<String, Object> summary = new LinkedHashMap<>();
+    summary.put("runId", runId);
+    summary.put(
+        "durationMS", start == null ? 0 : Instant.now().toEpochMilli() - start.toEpochMilli());
+    summary.put("submitted", submitted);
+    summary.put("successful", successfulCounter.get());
+    summary.put("failures", failureCounter.get());
+    summary.put("skipped", skippedCounter.get());
+    summary.put("fuzzed", fuzzedCounter.get());
+    summary.put("fuzzFailures", fuzzFailureCounter.get());
+    summary.put("errorRatePercent", errorRatePercent());
+    summary.put("p50MS", runStats.percentile(50));
+    summary.put("p95MS", runStats.percentile(95));
+    summary.put("p99MS", runStats.percentile(99));
+    summary.put("maxMS", runStats.max());
+    summary.put("slaViolations", violations.size());
+    summary.put("verdict", verdict);
+    try {
+      System.out.println(new ObjectMapper().writeValueAsString(summary));
+    } catch (JsonProcessingException e) {
+      logger.log(Level.WARNING, "unable to print the json summary", e);
+    }
+  }
+
+  /**
+   * @return percent of the submitted statements that failed, 0 when none were submitted
+   */
+  private double errorRatePercent() {
+    final int submitted = submittedCounter.get();
+    return submitted == 0 ? 0.0 : failureCounter.get() * 100.0 / submitted;
+  }
+
+  /**
+   * a run fails when nothing was submitted, when more statements failed than --max-error-rate
+   * allows or when an SLA was violated, so it can gate a pipeline
+   *
+   * @return PASS or FAIL
+   */
+  private String verdict() {
+    if (submittedCounter.get() == 0
+        || errorRatePercent() > maxErrorRate
+        || !slaViolations().isEmpty()) {
+      return "FAIL";
+    }
+    return "PASS";
+  }
+
+  /**
+   * reports a run that ended before its workload finished, on the webhook and as the json summary
+   *
+   * @param start when timing began, null when the run ended before
+   * @param reason why the run was aborted
+   * @return the exit code of the run
+   */
+  private int abort(final Instant start, final String reason) {
+    notifier.aborted(reason);
+    printJsonSummary(start, "ABORTED");
+    return 1;
+  }
+
+  /**
+   * the results of the run
+   *
+   * @param start when timing began
+   * @return map of result name to value suitable for serializing to json
+   */
+  private Map<String, Object> results(final Instant start) {
+    final Map<String, Object> results = new LinkedHashMap<>();
+    results.put("runId", runId);
+    results.put("config", String.valueOf(jsonConfig));
+    results.put("url", Redact.url(dremioHost));
+    results.put("start", start.toString());
+    results.put("end", Instant.now().toString());
+    results.putAll(status());
+    final List<Map<String, Object>> queries = new ArrayList<>();
+    for (final TargetStats stats : new TreeMap<>(labelStats).values()) {
+      final Map<String, Object> query = stats.toMap();
+      query.remove("url");
+      final HitRate hitRate = hitRates.get(stats.getName());
+      if (hitRate != null) {
+        query.put("accelerated", hitRate.accelerated.get());
+      }
+      final TargetStats firstRow = labelFirstRowStats.get(stats.getName());
+      if (firstRow != null) {
+        query.put("firstRowMeanMS", firstRow.mean());
+        query.put("firstRowP50MS", firstRow.percentile(50));
+        query.put("firstRowP95MS", firstRow.percentile(95));
+        query.put("firstRowP99MS", firstRow.percentile(99));
+      }
+      queries.add(query);
+    }
+    results.put("queries", queries);
+    if (compareStats != null) {
+      results.put("compare", Arrays.asList(primaryStats.toMap(), compareStats.toMap()));
+    }
+    if (shadowStats != null) {
+      results.put("shadow", shadowStats.toMap());
+    }
+    if (intendedStartStats.getSuccessful() + intendedStartStats.getFailures() > 0) {
+      final Map<String, Object> latency = new LinkedHashMap<>();
+      latency.put("actualStart", latencyMap(actualStartStats));
+      latency.put("intendedStart", latencyMap(intendedStartStats));
+      results.put("coordinatedOmission", latency);
+    }
+    if (firstRowStats.getSuccessful() > 0) {
+      final Map<String, Object> latency = new LinkedHashMap<>();
+      latency.put("firstRow", latencyMap(firstRowStats));
+      latency.put("completion", latencyMap(completionStats));
+      results.put("timeToFirstRow", latency);
+    }
+    if (probeStats != null) {
+      final Map<String, Object> probe = probeStats.toMap();
+      probe.put("maxMS", probeStats.max());
+      results.put("probe", probe);
+    }
+    if (!slaQueries.isEmpty()) {
+      results.put("slaViolations", slaViolations());
+    }
+    if (!replayOriginalConcurrency) {
+      results.put("workerUtilization", utilization.toMap());
+    }
+    if (timeSeries != null) {
+      results.put("timeSeries", timeSeries.toList());
+    }
+    results.put("events", timeline(start));
+    results.put("effectiveConfig", effectiveConfig());
+    final List<Map<String, Object>> connections = new ArrayList<>();
+    for (final Entry<String, ConnectionStats> entry : new TreeMap<>(connectionStats).entrySet()) {
+      connections.add(entry.getValue().toMap(Redact.url(entry.getKey())));
+    }
+    results.put("connections", connections);
+    return results;
+  }
+
+  /**
+   * the fully resolved configuration of the run, the settings from the flags and the workload with
+   * its defaults applied, so a run can be reproduced from its results alone
+   *
+   * @return map suitable for serializing to json
+   */
+  private Map<String, Object> effectiveConfig() {
+    final Map<String, Object> effective = new LinkedHashMap<>();
+    effective.put("settings", settings);
+    if (this.fileType == QueriesGeneratorFileType.STRESS_JSON) {
+      final ObjectMapper mapper = new ObjectMapper();
+      mapper.setSerializationInclusion(JsonInclude.Include.NON_NULL);
+      effective.put("workload", mapper.convertValue(getConfig(), Map.class));
+    }
+    return effective;
+  }
+
+  /**
+   * writes the effective configuration next to the results file when the run starts, so it is
+   * there even when the run does not finish
+   *
+   * @return the file written, null when there is no results file or it could not be written
+   */
+  private File writeEffectiveConfig() {
+    if (resultsFile == null) {
+      return null;
+    }
+    final File directory = resultsFile.getAbsoluteFile().getParentFile();
+    final File file = new File(directory, "dremio-stress-config-" + runId + ".json");
+    try {
+      new ObjectMapper().writerWithDefaultPrettyPrinter().writeValue(file, effectiveConfig());
+      logger.info(() -> "effective configuration written to " + file);
+      return file;
+    } catch (IOException | RuntimeException e) {
+      logger.log(Level.WARNING, "unable to write the effective configuration to " + file, e);
+      return null;
+    }
+  }
+
+  /**
+   * the timeline of the run
+   *
+   * @param start when timing began, null leaves out the time elapsed since the start
+   * @return one map per event suitable for serializing to json
+   */
+  private List<Map<String, Object>> timeline(final Instant start) {
+    final List<Map<String, Object>> timeline = new ArrayList<>();
+    synchronized (events) {
+      for (final Event event : events) {
+        final Map<String, Object> entry = new LinkedHashMap<>();
+        entry.put("time", event.time.toString());
+        if (start != null) {
+          entry.put("elapsedMS", Math.max(event.time.toEpochMilli() - start.toEpochMilli(), 0));
+        }
+        entry.put("type", event.type);
+        entry.put("message", event.message);
+        timeline.add(entry);
+      }
+    }
+    return timeline;
+  }
+
+  /**
+   * adds an entry to the timeline of the run
+   *
+   * @param type what kind of event, for example chaos
+   * @param message what happened
+   */
+  private void recordEvent(final String type, final String message) {
+    events.add(new Event(type, message));
+    logger.warning(() -> String.format("%s: %s", type, message));
+  }
+
+  /**
+   * counts the query towards the reflection hit rate of its label
+   *
+   * @param query the query that succeeded
+   * @param accelerated whether a reflection was chosen, null when the engine does not report it
+   */
+  private void recordAcceleration(final Query query, final Boolean accelerated) {
+    if (accelerated == null) {
+      return;
+    }
+    final String label = query.getLabel() == null ? query.getQueryText() : query.getLabel();
+    final HitRate hitRate = hitRates.computeIfAbsent(label, k -> new HitRate());
+    hitRate.total.incrementAndGet();
+    if (accelerated) {
+      hitRate.accelerated.incrementAndGet();
+    }
+  }
+
+  /**
+   * prints the share of queries accelerated by a reflection, overall and per query label
+   *
+   * @return the report, empty when no acceleration details were reported
+   */
+  private String hitRateReport() {
+    if (hitRates.isEmpty()) {
+      return "";
+    }
+    final StringBuilder report = new StringBuilder();
+    int accelerated = 0;
+    int total = 0;
+    for (final Entry<String, HitRate> entry : new TreeMap<>(hitRates).entrySet()) {
+      final int labelAccelerated = entry.getValue().accelerated.get();
+      final int labelTotal = entry.getValue().total.get();
+      accelerated += labelAccelerated;
+      total += labelTotal;
+      report.append(
+          String.format(
+              "  %.1f %% (%d of %d) %s%n",
+              labelAccelerated * 100.0 / labelTotal, labelAccelerated, labelTotal, entry.getKey()));
+    }
+    return String.format(
+            "reflection hit rate: %.1f %% (%d of %d queries accelerated)%n",
+            accelerated * 100.0 / total, accelerated, total)
+        + report;
+  }
+
+  /**
+   * checks the latency of every query that declares an SLA. A query without successful statements
+   * violates its SLA as it could not be verified.
+   *
+   * @return one description per violated SLA, empty when every SLA was met
+   */
+  private List<String> slaViolations() {
+    final List<String> violations = new ArrayList<>();
+    for (final QueryConfig q : slaQueries) {
+      final TargetStats stats = labelStats.get(describe(q));
+      if (stats == null || stats.getSuccessful() == 0) {
+        violations.add(describe(q) + ": no successful statements to check the SLA against");
+        continue;
+      }
+      if (q.getP95Max() != null) {
+        final long limit = Human.parseDurationMillis(q.getP95Max());
+        if (stats.percentile(95) > limit) {
+          violations.add(
+              String.format(
+                  "%s: p95 %s is over the p95Max of %s",
+                  describe(q),
+                  Human.getHumanDurationFromMillis(stats.percentile(95)),
+                  Human.getHumanDurationFromMillis(limit)));
+        }
+      }
+      if (q.getMaxDuration() != null) {
+        final long limit = Human.parseDurationMillis(q.getMaxDuration());
+        if (stats.max() > limit) {
+          violations.add(
+              String.format(
+                  "%s: slowest run %s is over the maxDuration of %s",
+                  describe(q),
+                  Human.getHumanDurationFromMillis(stats.max()),
+                  Human.getHumanDurationFromMillis(limit)));
+        }
+      }
+    }
+    return violations;
+  }
+
+  /**
+   * lists the violated SLAs
+   *
+   * @return the report, empty when no query declares an SLA
+   */
+  private String slaReport() {
+    if (slaQueries.isEmpty()) {
+      return "";
+    }
+    final List<String> violations = slaViolations();
+    if (violations.isEmpty()) {
+      return String.format("SLA: all %d queries met their SLA%n", slaQueries.size());
+    }
+    final StringBuilder report =
+        new StringBuilder(String.format("SLA: %d violations%n", violations.size()));
+    for (final String violation : violations) {
+      report.append(String.format("  %s%n", violation));
+    }
+    return report.toString();
+  }
+
+  /**
+   * average time per job spent in each phase
+   *
+   * @return phase name to average milliseconds, empty when no phase timings were reported
+   */
+  private Map<String, Long> averagePhases() {
+    final Map<String, Long> averages = new LinkedHashMap<>();
+    final long samples = phaseSamples.get();
+    if (samples == 0) {
+      return averages;
+    }
+    for (final String phase : Arrays.asList("planning", "queued", "execution")) {
+      final AtomicLong total = phaseTotalMS.get(phase);
+      if (total != null) {
+        averages.put(phase, total.get() / samples);
+      }
+    }
+    return averages;
+  }
+
+  private static String describePhases(final Map<String, Long> phases) {
+    final List<String> parts = new ArrayList<>();
+    for (final Entry<String, Long> phase : phases.entrySet()) {
+      parts.add(phase.getKey() + " " + Human.getHumanDurationFromMillis(phase.getValue()));
+    }
+    return String.join("; ", parts);
+  }
+
+  public List<QueryConfig> getQueries() {
+    if (this.fileType == QueriesGeneratorFileType.STRESS_JSON) {
+      final StressConfig config = getConfig();
+      return getQueryConfigs(config);
+    } else {
+      List<QueryConfig> queriesConfig = new ArrayList<>();
+      if (readsStdin()) {
+        logger.info("reading queries.json from stdin");
+        try {
+          queriesConfig = parseQueryConfigs(new Scanner(System.in));
+        } catch (JsonProcessingException e) {
+          throw new RuntimeException(e);
+        }
+      } else {
+        // a directory or glob of rotated logs is replayed as one, in path order
+        final List<File> files;
+        try {
+          files = QueryLogFiles.resolve(jsonConfig);
+        } catch (IOException e) {
+          throw new RuntimeException(e.getMessage(), e);
+        }
+        logger.info(() -> String.format("reading %d queries.json files", files.size()));
+        for (final File queriesFile : files) {
+          queriesConfig.addAll(openQueryJson(queriesFile));
+        }
+      }
+      if (queriesConfig.isEmpty()) {
+        throw new RuntimeException("no valid queries were found");
+      } else {
+        logger.info("found a total of " + queriesConfig.size() + " queries");
+      }
+      if (replaySpeed != null) {
+        // queries.json is written as queries finish, the pace follows when they started
+        queriesConfig.sort(
+            Comparator.comparing(
+                QueryConfig::getReplayStartMS, Comparator.nullsFirst(Comparator.naturalOrder())));
+      }
+      if (replayOriginalConcurrency) {
+        originalPeakConcurrency = peakConcurrency(queriesConfig);
+        logger.info(
+            () ->
+                String.format(
+                    "the replayed queries originally peaked at %d running at once",
+                    originalPeakConcurrency));
+      }
+      return queriesConfig;
+    }
+  }
+
+  public List<QueryConfig> openQueryJson(File jsonConfig) {
+    logger.info("opening " + jsonConfig);
+    List<QueryConfig> parsedQueryConfigs = new ArrayList<>();
+
+    final String name = jsonConfig.getName();
+    if (!QueryLogFiles.isQueryLog(name)) {
+      logger.warning("file type not supported - skipping " + jsonConfig);
+    } else if (QueryLogFiles.isGzipped(name)) {
+      try (GZIPInputStream gzst = new GZIPInputStream(Files.newInputStream(jsonConfig.toPath()))) {
+        try (Scanner scanner = new Scanner(gzst)) {
+          parsedQueryConfigs = parseQueryConfigs(scanner);
+        }
+      } catch (IOException e) {
+        throw new RuntimeException(e);
+      }
+    } else {
+      try (InputStream st = Files.newInputStream(jsonConfig.toPath())) {
+        try (Scanner scanner = new Scanner(st)) {
+          parsedQueryConfigs = parseQueryConfigs(scanner);
+        }
+      } catch (IOException e) {
+        throw new RuntimeException(e);
+      }
+    }
+    return parsedQueryConfigs;
+  }
+
+  public List<QueryConfig> parseQueryConfigs(Scanner scanner) throws JsonProcessingException {
+    final ObjectMapper objectMapper = new ObjectMapper();
+    List<QueryConfig> configs = new ArrayList<>();
     int skipCount = 0;
+    int filteredCount = 0;
+    int writeCount = 0;
     int includeCount = 0;
+    boolean first = true;
     while (scanner.hasNextLine()) {
-      final String line = scanner.nextLine();
+      String line = scanner.nextLine();
+      if (first && line.startsWith("\uFEFF")) {
+        // byte order mark written by some editors
+        line = line.substring(1);
+      }
+      first = false;
+      if (line.trim().isEmpty()) {
+        continue;
+      }
       final QueryJsonRow row = objectMapper.readValue(line, QueryJsonRow.class);
       final QueryConfig query = new QueryConfig();
       if (skipQuery(row)) {
         skipCount += 1;
         continue;
+      } else if (replayFilter != null && !replayFilter.accepts(row)) {
+        filteredCount += 1;
+        continue;
+      } else if (readOnly && !ReadOnlyGuard.isReadOnly(row.getQueryText())) {
+        writeCount += 1;
+        continue;
       } else {
         includeCount += 1;
       }
-      String context = row.getContext();
-      List<String> sqlContext = new ArrayList<>();
-      if (!context.equals("") && !context.equals("[]")) {
-        // TODO: May need additional handling of escaped chars, like \"
-        context = context.substring(1, context.length() - 1); // Remove square brackets
-        sqlContext = Arrays.asList(context.split(",\\s*"));
-      }
+      final List<String> sqlContext = SqlContext.parse(row.getContext()).getParts();
       String queryText = row.getQueryText();
       if (!Objects.isNull(limitResults) && limitResults > 0) {
         if (queryText.toLowerCase().contains("limit")) {
@@ -302,6 +1782,8 @@ public class StressExec {
       queryText = "--Replay of " + queryId + "\n" + queryText;
 
       query.setFrequency(1);
+      query.setReplayStartMS(row.getStart());
+      query.setReplayFinishMS(row.getFinish());
       query.setParameters(new HashMap<>());
       query.setQuery(queryText);
       query.setSqlContext(sqlContext);
@@ -309,15 +1791,30 @@ public class StressExec {
     }
     System.out.println("Total number of queries included: " + includeCount);
     System.out.println("Total number of queries excluded: " + skipCount);
+    if (replayFilter != null) {
+      System.out.println("Total number of queries filtered out: " + filteredCount);
+    }
+    if (readOnly) {
+      System.out.println("Total number of queries left out as they write: " + writeCount);
+    }
     return configs;
   }
 
-  private boolean skipQuery(QueryJsonRow row) {
-    if (row.getUsername().equals("$dremio$")) {
+  /**
+   * filters out internal, failed, non-SQL and DDL/DML queries that cannot be replayed. Fields
+   * missing from the row are not used for filtering.
+   *
+   * @param row a query from queries.json or a job history export
+   * @return true when the query should not be replayed
+   */
+  static boolean skipQuery(QueryJsonRow row) {
+    if (row.getQueryText() == null) {
+      return true;
+    } else if ("$dremio$".equals(row.getUsername())) {
       // Internal queries are context dependent (e.g. on reflection IDs) and usually cannot be
       // re-run
       return true;
-    } else if (!row.getOutcome().equals("COMPLETED")) {
+    } else if (row.getOutcome() != null && !row.getOutcome().equalsIgnoreCase("COMPLETED")) {
       // Queries that did not finish successfully, are not expected to work
       return true;
     } else if (row.getQueryText().equals("NA")) {
@@ -350,31 +1847,269 @@ public class StressExec {
    * @return exit code of the process
    */
   public int run() {
+    if (printQueries > 0) {
+      printQueries();
+      return 0;
+    }
+    if (readOnly && this.fileType == QueriesGeneratorFileType.STRESS_JSON) {
+      // before the first connection as it runs the sessionInit statements, replays already left
+      // out the statements that write
+      validateReadOnly(getQueries(), getStringQueryGroupMap(), getConfig().getSessionInit());
+    }
+    if (provisionSpace == null) {
+      return runWorkload();
+    }
+    final Provisioner provisioner;
     try {
-      final DremioApi dremioApi =
-          this.connectApi.connect(
-              dremioUser,
-              dremioPassword,
-              dremioHost,
-              timeoutSeconds,
-              protocol,
-              skipSSLVerification);
-
-      final BlockingQueue<Runnable> queue =
-          new LinkedBlockingQueue<>(this.maxQueriesInFlight * 1000);
+      provisioner = new Provisioner((DremioV3Api) connect());
+    } catch (IOException e) {
+      logger.log(Level.SEVERE, "unable to connect", e);
+      return abort(null, "unable to connect: " + e.getMessage());
+    }
+    try {
+      provisioner.setUp(provisionSpace);
+      return runWorkload();
+    } catch (IOException e) {
+      logger.log(Level.SEVERE, "unable to provision " + Redact.url(dremioHost), e);
+      return abort(null, "unable to provision: " + e.getMessage());
+    } finally {
+      // also removes what was created before a failed setup
+      provisioner.tearDown();
+    }
+  }
+
+  private int runWorkload() {
+    try {
+      for (final String coordinator : coordinators.urls()) {
+        final DremioApi api = connect(coordinator);
+        if (!initSession(api)) {
+          return abort(null, "session initialization failed on " + Redact.url(coordinator));
+        }
+        sharedApis.put(coordinator, api);
+      }
+      if (coordinators.urls().size() > 1) {
+        logger.info(
+            String.format(
+                "spreading the load over %d coordinators", coordinators.urls().size()));
+      }
+      if (compareHost != null) {
+        compareApi = connect(compareHost);
+        if (!initSession(compareApi)) {
+          return abort(null, "session initialization failed on " + Redact.url(compareHost));
+        }
+        primaryStats = new TargetStats("A", Redact.url(dremioHost));
+        compareStats = new TargetStats("B", Redact.url(compareHost));
+        logger.info(
+            () ->
+                String.format(
+                    "comparing %s (A) with %s (B), every statement is run on both",
+                    Redact.url(dremioHost), Redact.url(compareHost)));
+      }
+      if (shadowHost != null && shadowPercent > 0) {
+        shadowApi = connect(shadowHost);
+        if (!initSession(shadowApi)) {
+          return abort(null, "session initialization failed on " + Redact.url(shadowHost));
+        }
+        shadowStats = new TargetStats("shadow", Redact.url(shadowHost));
+        logger.info(
+            () ->
+                String.format(
+                    "duplicating %.1f %% of the statements to %s",
+                    shadowPercent, Redact.url(shadowHost)));
+      }
+      // statements run per generated statement, mirrored statements count towards the limits
+      final int copies = compareApi == null ? 1 : 2;
       final List<QueryConfig> queryPool = getQueries();
       final Map<String, QueryGroup> queryGroups = getStringQueryGroupMap();
+      validateParameters(queryPool, queryGroups);
+      if (protocol != Protocol.HTTP && queryPool.stream().anyMatch(q -> q.getCatalog() != null)) {
+        logger.severe("catalog calls need the rest api, run with --protocol HTTP");
+        return abort(null, "catalog calls need the rest api");
+      }
+      if (protocol != Protocol.HTTP
+          && queryPool.stream()
+              .anyMatch(q -> engine(q, queryGroups.get(q.getQueryGroup())) != null)) {
+        logger.warning("engine is only sent over HTTP, over JDBC routing rules pick the engine");
+      }
+      if (this.fileType == QueriesGeneratorFileType.STRESS_JSON) {
+        printFrequencyReport(getConfig().getQueries(), queryGroups);
+        slaQueries =
+            getConfig().getQueries().stream()
+                .filter(q -> q.getP95Max() != null || q.getMaxDuration() != null)
+                .collect(Collectors.toList());
+        if (sessions == 0 && getConfig().getUsers() != null) {
+          logger.warning("users only cap the rate of simulated sessions, run with --sessions");
+        }
+        for (final QueryConfig q : getConfig().getQueries()) {
+          final long onsetMS = startAfterMS(q, queryGroups.get(q.getQueryGroup()));
+          pendingOnsets.computeIfAbsent(onsetMS, k -> new ArrayList<>()).add(q);
+        }
+        final boolean limited =
+            getConfig().getQueries().stream().anyMatch(q -> q.getMaxExecutions() != null);
+        final boolean dependent =
+            queryGroups.values().stream()
+                .anyMatch(g -> g.getDependsOn() != null && !g.getDependsOn().isEmpty());
+        if (!limited && !dependent && pendingOnsets.size() == 1 && pendingOnsets.containsKey(0L)) {
+          pendingOnsets.clear();
+        }
+      }
+      if (protocol == Protocol.JDBC && mixesContexts(queryPool)) {
+        for (final String url : Coordinators.parse(dremioHost).urls()) {
+          if (DremioArrowFlightJDBCDriver.defaultContext(url, null).isEmpty()) {
+            // there is no USE for the root, these queries would run in the context of another
+            logger.severe(
+                "queries without a sqlContext cannot run next to queries with one over JDBC, give"
+                    + " every query a sqlContext or set a default with schema in the JDBC url");
+            return abort(
+                null, "queries without a sqlContext mixed with queries with one over JDBC");
+          }
+        }
+      }
       if (queriesSequence == QueriesSequence.SEQUENTIAL) {
         queryIndex = new AtomicInteger(this.queryIndexForRestart);
       }
-      final ExecutorService executorService =
-          new ThreadPoolExecutor(
-              this.maxQueriesInFlight, this.maxQueriesInFlight, 0L, TimeUnit.MILLISECONDS, queue);
+      final Map<String, ThreadPoolExecutor> groupExecutors = new HashMap<>();
+      int sharedWorkers = this.maxQueriesInFlight;
+      for (final QueryGroup g : queryGroups.values()) {
+        if (g.getWorkers() > 0) {
+          final int workers = scaleWorkers(g.getWorkers(), scale);
+          sharedWorkers -= workers;
+          groupExecutors.put(g.getName(), newExecutor(workers, workers * 1000, utilization));
+          logger.info(
+              String.format("query group %s has %d dedicated workers", g.getName(), workers));
+        }
+      }
+      if (sharedWorkers < 1) {
+        throw new InvalidParameterException(
+            String.format(
+                "query groups dedicate more workers than the %d max queries in flight, at least"
+                    + " one worker must remain for the other queries",
+                this.maxQueriesInFlight));
+      }
+      final int pauseQueueSize = this.queueSize > 0 ? this.queueSize : sharedWorkers * 10;
+      // replaying the original concurrency starts a thread per query when all of them are busy
+      final ThreadPoolExecutor executorService =
+          replayOriginalConcurrency
+              ? new ThreadPoolExecutor(
+                  0,
+                  maxReplayThreads,
+                  60L,
+                  TimeUnit.SECONDS,
+                  new SynchronousQueue<>(),
+                  this::newReplayThread)
+              : newExecutor(
+                  sharedWorkers, Math.max(sharedWorkers * 1000, pauseQueueSize * 2), utilization);
+      final BlockingQueue<Runnable> queue = executorService.getQueue();
+      sharedExecutor = executorService;
+      // best effort, when the shadow cluster falls behind its statements are dropped
+      final int shadowWorkers =
+          Math.max((int) Math.ceil(this.maxQueriesInFlight * shadowPercent / 100.0), 1);
+      final ThreadPoolExecutor shadowExecutor =
+          shadowApi == null ? null : newExecutor(shadowWorkers, shadowWorkers * 10);
+      logger.info(
+          String.format(
+              "%d shared workers, submission pauses when %d queries are queued",
+              sharedWorkers, pauseQueueSize));
+      final List<DremioApi> warmUpApis = new ArrayList<>(sharedApis.values());
+      warmUpApis.add(compareApi);
+      warmUpApis.add(shadowApi);
+      for (final DremioApi api : warmUpApis) {
+        if (api != null && !warmUp(api)) {
+          return abort(null, "engines of " + api.getUrl() + " did not start");
+        }
+      }
+      final DelayQueue<Session> readySessions = sessions > 0 ? new DelayQueue<>() : null;
+      if (readySessions != null) {
+        simulatedSessions = newSessions();
+        readySessions.addAll(simulatedSessions);
+        logger.info(
+            String.format(
+                "simulating %d sessions with %d ms think time over %d workers",
+                sessions, thinkTimeMS, this.maxQueriesInFlight));
+      }
+      if (connectionPerWorker) {
+        logger.info("every worker opens its own connection on its first statement");
+      }
+      effectiveConfigFile = writeEffectiveConfig();
+      if (outputDir != null) {
+        final File manifest = new File(outputDir, "jobs.csv");
+        try {
+          jobManifest = new JobManifest(manifest);
+        } catch (IOException e) {
+          logger.log(Level.WARNING, "unable to write the job manifest to " + manifest, e);
+        }
+      }
       final Instant d = Instant.now();
+      if (timeSeries != null) {
+        timeSeries.start(d);
+      }
+      utilization.start();
       startReporting(d);
+      notifier.started(
+          String.format(
+              "%s against %s for %s",
+              jsonConfig,
+              Redact.url(dremioHost),
+              Human.getHumanDurationFromMillis(durationTargetMS)));
       try {
-        monitorForEnd(d, executorService, queryPool.size());
+        final List<ExecutorService> executors = new ArrayList<>();
+        executors.add(executorService);
+        executors.addAll(groupExecutors.values());
+        if (shadowExecutor != null) {
+          executors.add(shadowExecutor);
+        }
+        monitorForEnd(d, executors, queryPool.size());
+        startWatchdog();
+        startRecycling();
+        startHealthChecks();
+        startProbe();
+        startChaos();
         while (!executorService.isShutdown()) {
+          if (paused.get()) {
+            Thread.sleep(500);
+            continue;
+          }
+          if (maxQueriesReached()) {
+            // wait for the in flight queries to finish so monitorForEnd can shutdown the executor
+            Thread.sleep(1000);
+            continue;
+          }
+          final TokenBucket limit = rateLimit;
+          if (limit != null) {
+            final long waitNanos = limit.nanosUntilAvailable();
+            if (waitNanos > 0) {
+              // checked again at least twice a second so a new cap applies quickly
+              TimeUnit.NANOSECONDS.sleep(Math.min(waitNanos, 500_000_000L));
+              continue;
+            }
+          }
+          final Session session =
+              readySessions == null ? null : readySessions.poll(500, TimeUnit.MILLISECONDS);
+          if (readySessions != null && session == null) {
+            // every session is busy or thinking
+            continue;
+          }
+          final List<QueryConfig> pool =
+              queriesSequence == QueriesSequence.RANDOM
+                  ? active(queryPool, queryGroups, d)
+                  : queryPool;
+          if (pool.isEmpty()) {
+            // every query starts after a delay, waits for a query group or ran its maxExecutions
+            if (pendingOnsets.isEmpty() && activeQueries.isEmpty() && !exhausted.getAndSet(true)) {
+              logger.info("every query ran its maxExecutions, ending the run");
+            }
+            releaseSession(readySessions, session, false);
+            Thread.sleep(500);
+            continue;
+          }
+          final List<QueryConfig> runnable =
+              queriesSequence == QueriesSequence.RANDOM ? unpaused(pool) : pool;
+          if (runnable.isEmpty()) {
+            // every query that can run references a paused query group
+            releaseSession(readySessions, session, false);
+            Thread.sleep(500);
+            continue;
+          }
           final int nextQuery;
           if (queriesSequence == QueriesSequence.SEQUENTIAL) {
             if (queryIndex.get() + 1 < queryPool.size()) {
@@ -391,40 +2126,1263 @@ public class StressExec {
               continue;
             }
           } else if (queriesSequence == QueriesSequence.RANDOM) {
-            nextQuery = random.nextInt(queryPool.size());
+            nextQuery = random.nextInt(runnable.size());
           } else {
             throw new RuntimeException("unexpected queriesSequence: " + queriesSequence);
           }
-          final QueryConfig query = queryPool.get(nextQuery);
-          final List<Query> mappedSqls = mapSql(query, queryGroups);
-          for (final Query mappedSql : mappedSqls) {
-            final Runnable runnable = () -> runQuery(dremioApi, mappedSql);
-            executorService.submit(runnable);
-            counter.incrementAndGet();
+          final QueryConfig query = runnable.get(nextQuery);
+          if (query.getQueryGroup() != null && pausedGroups.contains(query.getQueryGroup())) {
+            // ran in order, the statements of a paused query group are skipped
+            releaseSession(readySessions, session, false);
+            continue;
           }
-          if (queue.size() > this.maxQueriesInFlight * 10) {
-            logger.fine("pausing as queue is too large");
-            while (queue.size() > this.maxQueriesInFlight * 5) {
-              // take out time pausing while we let the queue clear out
+          if (!awaitReplayStart(query, d, executorService)
+              || !awaitOnset(query, queryGroups, d, executorService)
+              || !awaitDependencies(query, queryGroups, executorService)) {
+            continue;
+          }
+          if (!hasExecutionsLeft(query)) {
+            // ran in order, the query is skipped once it ran its maxExecutions
+            releaseSession(readySessions, session, false);
+            continue;
+          }
+          final ThreadPoolExecutor groupExecutor = groupExecutors.get(query.getQueryGroup());
+          if (groupExecutor != null
+              && groupExecutor.getQueue().size() > groupExecutor.getMaximumPoolSize() * 10) {
+            // the dedicated workers are backed up, skip this group so it does not starve the rest
+            logger.fine(() -> "skipping saturated query group " + query.getQueryGroup());
+            releaseSession(readySessions, session, false);
+            // backs off as the group stays saturated until its workers catch up
+            Thread.sleep(100);
+            continue;
+          }
+          final ExecutorService target = groupExecutor == null ? executorService : groupExecutor;
+          final List<Query> mappedSqls = mapSql(query, queryGroups);
+          final Instant due = replayDue(query, d);
+          if (due != null && !mappedSqls.isEmpty()) {
+            // a stall delays the submission too, the latency counts from when it was due
+            mappedSqls.get(0).setIntendedStartMS(due.toEpochMilli());
+          }
+          if (maxQueries > 0 && counter.get() + mappedSqls.size() * copies > maxQueries) {
+            // an iteration is dropped rather than cut short as its steps depend on each other and
+            // a cut query group would count as completed
+            logger.info(() -> String.format("max queries %d reached", maxQueries));
+            maxQueriesFull.set(true);
+            releaseSession(readySessions, session, false);
+            continue;
+          }
+          // copied before submitting as running a query substitutes variables into its text
+          final List<Query> mirrored =
+              compareApi == null
+                  ? null
+                  : mappedSqls.stream().map(Query::copy).collect(Collectors.toList());
+          final QueryGroup group = queryGroups.get(query.getQueryGroup());
+          final boolean dedicated = group != null && group.isDedicatedConnection();
+          final long enqueued = System.nanoTime();
+          final Runnable runnable =
+              () -> {
+                queuedCounter.decrementAndGet();
+                totalQueueWaitMS.addAndGet((System.nanoTime() - enqueued) / 1_000_000);
+                queueWaitSamples.incrementAndGet();
+                if (dedicated) {
+                  try {
+                    recordIteration(group, mappedSqls, runDedicated(mappedSqls));
+                  } finally {
+                    releaseSession(readySessions, session, true);
+                  }
+                  return;
+                }
+                if (session == null) {
+                  final DremioApi workerApi = workerApi();
+                  if (workerApi != null) {
+                    recordIteration(
+                        group, mappedSqls, runQueries(workerApi, mappedSqls, primaryStats));
+                  }
+                  return;
+                }
+                try {
+                  final DremioApi sessionApi = sessionApi(session);
+                  if (sessionApi != null) {
+                    recordIteration(
+                        group, mappedSqls, runQueries(sessionApi, mappedSqls, primaryStats));
+                  }
+                } finally {
+                  releaseSession(readySessions, session, true);
+                }
+              };
+          queuedCounter.incrementAndGet();
+          boolean submitted = false;
+          // the rate caps are only charged for submissions, not for skipped picks. The session is
+          // charged first as its worker reads the bucket when releasing it
+          if (session != null && session.bucket != null) {
+            session.bucket.take();
+          }
+          try {
+            target.submit(runnable);
+            submitted = true;
+            if (limit != null) {
+              limit.take();
+            }
+            if (mirrored != null) {
+              queuedCounter.incrementAndGet();
+              target.submit(
+                  () -> {
+                    queuedCounter.decrementAndGet();
+                    connectionStats(compareHost).reused.incrementAndGet();
+                    runQueries(compareApi, mirrored, compareStats);
+                  });
+            }
+          } catch (RejectedExecutionException e) {
+            queuedCounter.decrementAndGet();
+            if (!submitted) {
+              releaseSession(readySessions, session, false);
+            }
+            if (target.isShutdown()) {
+              // the deadline hit between the shutdown check and the submit
+              break;
+            }
+            if (replayOriginalConcurrency) {
+              logger.warning(
+                  () ->
+                      String.format(
+                          "%d replayed queries are already running, dropping %s",
+                          maxReplayThreads, describe(query)));
+            } else {
+              logger.fine("queue is full, dropping query");
+            }
+            continue;
+          }
+          counter.addAndGet(mappedSqls.size() * copies);
+          countExecution(query);
+          if (shadowExecutor != null && random.nextDouble() * 100.0 < shadowPercent) {
+            final List<Query> shadowed =
+                mappedSqls.stream().map(Query::copy).collect(Collectors.toList());
+            try {
+              shadowExecutor.submit(
+                  () -> {
+                    connectionStats(shadowHost).reused.incrementAndGet();
+                    runQueries(shadowApi, shadowed, shadowStats);
+                  });
+            } catch (RejectedExecutionException e) {
+              logger.fine("shadow queue is full, dropping shadow query");
+            }
+          }
+          if (queue.size() >= pauseQueueSize) {
+            logger.fine("pausing as queue is too large");
+            final long pauseStart = System.nanoTime();
+            while (queue.size() > pauseQueueSize / 2 && !executorService.isShutdown()) {
+              // take out time pausing while we let the queue clear out
               Thread.sleep(500);
             }
+            generatorPausedMS.addAndGet((System.nanoTime() - pauseStart) / 1_000_000);
           }
         }
-      } catch (InterruptedException e) {
-        throw new RuntimeException(e);
-      } finally {
-        timer.cancel();
-        executorService.shutdown();
+        saveResults(d);
+        printJsonSummary(d, verdict());
+      } catch (InterruptedException e) {
+        Thread.currentThread().interrupt();
+        logger.log(Level.SEVERE, "interrupted while submitting queries", e);
+        return abort(d, "interrupted");
+      } finally {
+        timer.cancel();
+        executorService.shutdown();
+        for (final ExecutorService groupExecutor : groupExecutors.values()) {
+          groupExecutor.shutdownNow();
+        }
+        if (shadowExecutor != null) {
+          shadowExecutor.shutdownNow();
+        }
+      }
+    } catch (IOException e) {
+      logger.log(Level.SEVERE, "unable to connect", e);
+      return abort(null, "unable to connect: " + e.getMessage());
+    }
+    return "PASS".equals(verdict()) ? 0 : 1;
+  }
+
+  /**
+   * the most queries that ran at once in the original workload, from their start and finish times
+   *
+   * @param queries the replayed queries
+   * @return the peak, 0 when no query has both times
+   */
+  static int peakConcurrency(final List<QueryConfig> queries) {
+    // +1 at every start and -1 at every finish, finishes first when they coincide
+    final List<long[]> changes = new ArrayList<>();
+    for (final QueryConfig query : queries) {
+      if (query.getReplayStartMS() != null && query.getReplayFinishMS() != null) {
+        changes.add(new long[] {query.getReplayStartMS(), 1});
+        changes.add(new long[] {query.getReplayFinishMS(), -1});
+      }
+    }
+    changes.sort(
+        Comparator.<long[]>comparingLong(change -> change[0])
+            .thenComparingLong(change -> change[1]));
+    int running = 0;
+    int peak = 0;
+    for (final long[] change : changes) {
+      running += (int) change[1];
+      peak = Math.max(peak, running);
+    }
+    return peak;
+  }
+
+  /**
+   * waits until a replayed query is due, its original offset from the first replayed query divided
+   * by the replay speed after the start of the run
+   *
+   * @param query the query about to be submitted
+   * @param start when the run started
+   * @param executor the shared workers, waiting stops when they are shut down
+   * @return false when the run ended while waiting
+   * @throws InterruptedException when interrupted while waiting
+   */
+  private boolean awaitReplayStart(
+      final QueryConfig query, final Instant start, final ExecutorService executor)
+      throws InterruptedException {
+    final Instant due = replayDue(query, start);
+    return due == null || sleepUntil(due, executor);
+  }
+
+  /**
+   * @param query a replayed query
+   * @param start when the run started
+   * @return when the query is due, null when the replay is not paced
+   */
+  private Instant replayDue(final QueryConfig query, final Instant start) {
+    if (replaySpeed == null || query.getReplayStartMS() == null) {
+      return null;
+    }
+    if (replayBaseMS == null) {
+      replayBaseMS = query.getReplayStartMS();
+    }
+    final long offsetMS = (long) ((query.getReplayStartMS() - replayBaseMS) / replaySpeed);
+    return start.plusMillis(offsetMS);
+  }
+
+  /**
+   * waits until the startAfter of the query when the queries run in order, random picks only come
+   * from the queries that started
+   *
+   * @param query query about to be submitted
+   * @param queryGroups query groups by name
+   * @param start start of the run
+   * @param executor executor the query is submitted to, waiting stops when it shuts down
+   * @return false when the run ended while waiting
+   * @throws InterruptedException when interrupted while waiting
+   */
+  private boolean awaitOnset(
+      final QueryConfig query,
+      final Map<String, QueryGroup> queryGroups,
+      final Instant start,
+      final ExecutorService executor)
+      throws InterruptedException {
+    if (pendingOnsets.isEmpty()) {
+      return true;
+    }
+    final long onsetMS = startAfterMS(query, queryGroups.get(query.getQueryGroup()));
+    return sleepUntil(start.plusMillis(onsetMS), executor);
+  }
+
+  private static boolean sleepUntil(final Instant due, final ExecutorService executor)
+      throws InterruptedException {
+    while (Instant.now().isBefore(due)) {
+      if (executor.isShutdown()) {
+        return false;
+      }
+      Thread.sleep(Math.min(Math.max(Duration.between(Instant.now(), due).toMillis(), 1), 500));
+    }
+    return true;
+  }
+
+  /**
+   * the queries whose startAfter has passed, whose query group dependencies completed and that
+   * have executions left. Every onset reached is recorded on the timeline so the latency before
+   * and after it can be compared.
+   *
+   * @param queryPool every query of the run
+   * @param queryGroups query groups by name
+   * @param start start of the run
+   * @return the pool to pick from, empty when no query can run yet or all ran their maxExecutions
+   */
+  private List<QueryConfig> active(
+      final List<QueryConfig> queryPool,
+      final Map<String, QueryGroup> queryGroups,
+      final Instant start) {
+    if (pendingOnsets.isEmpty() && activePool == null) {
+      return queryPool;
+    }
+    final long elapsedMS = Duration.between(start, Instant.now()).toMillis();
+    final boolean onsetReached = !pendingOnsets.isEmpty() && pendingOnsets.firstKey() <= elapsedMS;
+    if (activePool != null && !onsetReached && !activeChanged.getAndSet(false)) {
+      return activePool;
+    }
+    while (!pendingOnsets.isEmpty() && pendingOnsets.firstKey() <= elapsedMS) {
+      final Entry<Long, List<QueryConfig>> onset = pendingOnsets.pollFirstEntry();
+      activeQueries.addAll(onset.getValue());
+      if (onset.getKey() > 0) {
+        recordEvent(
+            "onset",
+            String.format(
+                "%d queries starting after %s are running",
+                onset.getValue().size(),
+                Human.getHumanDurationFromMillis(onset.getKey())));
+      }
+    }
+    final List<QueryConfig> ready =
+        activeQueries.stream()
+            .filter(q -> dependenciesMet(q, queryGroups))
+            .collect(Collectors.toList());
+    activePool = ready.isEmpty() ? Collections.emptyList() : new QueryPool(ready);
+    return activePool;
+  }
+
+  /**
+   * @param pool the queries that can run
+   * @return the queries not referencing a paused query group, empty when every query does
+   */
+  private List<QueryConfig> unpaused(final List<QueryConfig> pool) {
+    if (pausedGroups.isEmpty()) {
+      return pool;
+    }
+    if (pool != unpausedFrom || pausedGroupsChanged.getAndSet(false)) {
+      final List<QueryConfig> unpaused =
+          pool.stream()
+              .filter(q -> q.getQueryGroup() == null || !pausedGroups.contains(q.getQueryGroup()))
+              .collect(Collectors.toList());
+      unpausedPool = unpaused.isEmpty() ? Collections.emptyList() : new QueryPool(unpaused);
+      unpausedFrom = pool;
+    }
+    return unpausedPool;
+  }
+
+  /**
+   * @param query configured query
+   * @param queryGroups query groups by name
+   * @return true when every query group the group of the query depends on has completed
+   */
+  private boolean dependenciesMet(
+      final QueryConfig query, final Map<String, QueryGroup> queryGroups) {
+    final QueryGroup group = queryGroups.get(query.getQueryGroup());
+    return group == null
+        || group.getDependsOn() == null
+        || completedGroups.containsAll(group.getDependsOn());
+  }
+
+  /**
+   * waits until the query groups the query depends on have completed when the queries run in
+   * order, random picks only come from queries whose dependencies completed
+   *
+   * @param query query about to be submitted
+   * @param queryGroups query groups by name
+   * @param executor executor the query is submitted to, waiting stops when it shuts down
+   * @return false when the run ended while waiting
+   * @throws InterruptedException when interrupted while waiting
+   */
+  private boolean awaitDependencies(
+      final QueryConfig query,
+      final Map<String, QueryGroup> queryGroups,
+      final ExecutorService executor)
+      throws InterruptedException {
+    while (!dependenciesMet(query, queryGroups)) {
+      if (executor.isShutdown()) {
+        return false;
+      }
+      Thread.sleep(500);
+    }
+    return true;
+  }
+
+  /**
+   * marks the query group completed once an iteration ran every statement of the group as
+   * expected, so the groups depending on it start. An iteration with a fuzzed statement does not
+   * complete the group, as a fuzzed failure is not counted as a failure of the iteration.
+   *
+   * @param group query group of the iteration, null for plain queries
+   * @param queries statements of the iteration
+   * @param succeeded true when every statement of the iteration ran as expected
+   */
+  private void recordIteration(
+      final QueryGroup group, final List<Query> queries, final boolean succeeded) {
+    if (group == null
+        || !succeeded
+        || queries.size() != group.getQueries().size()
+        || queries.stream().anyMatch(Query::isFuzzed)) {
+      return;
+    }
+    if (completedGroups.add(group.getName())) {
+      logger.info(() -> String.format("query group %s completed", group.getName()));
+      activeChanged.set(true);
+    }
+  }
+
+  /**
+   * @param query query about to be submitted
+   * @return false when the query already ran its maxExecutions and is skipped
+   */
+  private boolean hasExecutionsLeft(final QueryConfig query) {
+    return query.getMaxExecutions() == null
+        || submissions.getOrDefault(query, 0) < query.getMaxExecutions();
+  }
+
+  /**
+   * counts a submission of a query with a maxExecutions and takes it out of the random picks once
+   * it reached them, only the generator calls it once the submission was accepted
+   *
+   * @param query query that was submitted
+   */
+  private void countExecution(final QueryConfig query) {
+    if (query.getMaxExecutions() == null) {
+      return;
+    }
+    final int count = submissions.getOrDefault(query, 0) + 1;
+    submissions.put(query, count);
+    if (count == query.getMaxExecutions()) {
+      logger.info(() -> String.format("%s ran its %d executions", describe(query), count));
+      if (activeQueries.remove(query)) {
+        activeChanged.set(true);
+      }
+    }
+  }
+
+  /**
+   * @param q configured query
+   * @param group query group the query references, null when it does not
+   * @return milliseconds into the run the query starts, the later of its own startAfter and the
+   *     startAfter of its group
+   */
+  static long startAfterMS(final QueryConfig q, final QueryGroup group) {
+    long onsetMS = 0;
+    if (q.getStartAfter() != null) {
+      onsetMS = Human.parseDurationMillis(q.getStartAfter());
+    }
+    if (group != null && group.getStartAfter() != null) {
+      onsetMS = Math.max(onsetMS, Human.parseDurationMillis(group.getStartAfter()));
+    }
+    return onsetMS;
+  }
+
+  /**
+   * runs the warm-up statement until it succeeds so engines that start on demand are running
+   * before timing begins. The time taken is reported separately as the cold start time.
+   *
+   * @param api the api to warm up
+   * @return true when warm-up is disabled or succeeded before the warm-up timeout
+   * @throws IOException when the api is unable to submit the statement
+   */
+  private boolean warmUp(final DremioApi api) throws IOException {
+    if (warmUpSeconds <= 0) {
+      return true;
+    }
+    final Instant deadline = Instant.now().plusSeconds(warmUpSeconds);
+    final long start = System.nanoTime();
+    int attempts = 0;
+    while (true) {
+      attempts++;
+      final DremioApiResponse response = api.runSQL(warmUpSql, SqlContext.empty());
+      if (response.isSuccessful()) {
+        System.out.printf(
+            "engine warm-up of %s took %s (%d attempts)%n",
+            api.getUrl(), Human.getHumanDurationFromNanos(System.nanoTime() - start), attempts);
+        return true;
+      }
+      if (Instant.now().isAfter(deadline)) {
+        logger.severe(
+            String.format(
+                "engines of %s did not start within %d seconds, last error: %s",
+                api.getUrl(), warmUpSeconds, response.getErrorMessage()));
+        return false;
+      }
+      final String error = response.getErrorMessage();
+      logger.info(() -> String.format("warm-up statement failed, retrying: %s", error));
+      try {
+        Thread.sleep(5 * 1000);
+      } catch (InterruptedException e) {
+        Thread.currentThread().interrupt();
+        return false;
+      }
+    }
+  }
+
+  /**
+   * prints sampled and fully expanded queries without connecting to dremio, so the workload can be
+   * checked before spending cluster time on it
+   */
+  private void printQueries() {
+    final List<QueryConfig> queryPool = getQueries();
+    final Map<String, QueryGroup> queryGroups = getStringQueryGroupMap();
+    validateParameters(queryPool, queryGroups);
+    int index = queriesSequence == QueriesSequence.SEQUENTIAL ? this.queryIndexForRestart : -1;
+    for (int i = 1; i <= printQueries; i++) {
+      final QueryConfig query;
+      if (queriesSequence == QueriesSequence.SEQUENTIAL) {
+        index++;
+        if (index >= queryPool.size()) {
+          break;
+        }
+        query = queryPool.get(index);
+      } else {
+        query = queryPool.get(random.nextInt(queryPool.size()));
+      }
+      final String source =
+          query.getQueryGroup() == null ? "query" : "query group " + query.getQueryGroup();
+      for (final Query mapped : mapSql(query, queryGroups)) {
+        System.out.printf(
+            "-- sample %d (%s) context: %s%n%s%n",
+            i,
+            source,
+            mapped.getContext().isEmpty() ? "default" : mapped.getContext(),
+            mapped.getQueryText());
+        if (mapped.getBindParameters() != null) {
+          System.out.printf("-- bind parameters: %s%n", mapped.getBindParameters());
+        }
+      }
+    }
+  }
+
+  private DremioApi connect() throws IOException {
+    return connect(coordinators.next());
+  }
+
+  /**
+   * opens a connection to the cluster, counted in the connection statistics
+   *
+   * @param host url of the cluster
+   * @return the connection
+   * @throws IOException when unable to connect
+   */
+  private DremioApi connect(final String host) throws IOException {
+    final ConnectionStats stats = connectionStats(host);
+    try {
+      final DremioApi api =
+          this.connectApi.connect(
+              dremioUser, dremioPassword, host, timeoutSeconds, protocol, skipSSLVerification);
+      stats.opened.incrementAndGet();
+      return api;
+    } catch (IOException | RuntimeException e) {
+      stats.failed.incrementAndGet();
+      throw e;
+    }
+  }
+
+  private ConnectionStats connectionStats(final String host) {
+    return connectionStats.computeIfAbsent(host, k -> new ConnectionStats());
+  }
+
+  /**
+   * prints the connection statistics per cluster
+   *
+   * @return the report, empty when nothing connected
+   */
+  private String connectionReport() {
+    final StringBuilder report = new StringBuilder();
+    for (final Entry<String, ConnectionStats> entry : new TreeMap<>(connectionStats).entrySet()) {
+      final ConnectionStats stats = entry.getValue();
+      report.append(
+          String.format(
+              "connections to %s: %d opened, %d reused, %d failed, %d reconnects, %d"
+                  + " unhealthy%n",
+              Redact.url(entry.getKey()),
+              stats.opened.get(),
+              stats.reused.get(),
+              stats.failed.get(),
+              stats.reconnects.get(),
+              stats.unhealthy.get()));
+    }
+    return report.toString();
+  }
+
+  /**
+   * checks every 10 seconds for workers stuck in a single statement for longer than the watchdog
+   * factor times the timeout, such as a hung driver call. Stuck workers are logged with their
+   * stack trace and, when enabled, interrupted while new queries switch to a fresh connection.
+   */
+  private void startWatchdog() {
+    if (watchdogFactor <= 0) {
+      return;
+    }
+    timer.schedule(
+        new TimerTask() {
+          public void run() {
+            final Instant now = Instant.now();
+            for (final Map.Entry<Thread, Execution> entry : executions.entrySet()) {
+              final Execution execution = entry.getValue();
+              final long elapsed = now.toEpochMilli() - execution.start.toEpochMilli();
+              // queries with their own timeout are only stuck relative to that timeout
+              final Long queryTimeoutMS = execution.query.getTimeoutMS();
+              final long limitMS =
+                  watchdogFactor
+                      * (queryTimeoutMS == null ? timeoutSeconds * 1000L : queryTimeoutMS);
+              if (execution.reported || elapsed < limitMS) {
+                continue;
+              }
+              execution.reported = true;
+              final Thread worker = entry.getKey();
+              final StringBuilder stack = new StringBuilder();
+              for (final StackTraceElement element : worker.getStackTrace()) {
+                stack.append("\n\tat ").append(element);
+              }
+              logger.warning(
+                  String.format(
+                      "worker %s has been executing %s for %s, longer than %d times the timeout%s",
+                      worker.getName(),
+                      execution.query,
+                      Human.getHumanDurationFromMillis(elapsed),
+                      watchdogFactor,
+                      stack));
+              if (watchdogRestart) {
+                restartWorker(worker);
+              }
+            }
+          }
+        },
+        10 * 1000,
+        10 * 1000);
+  }
+
+  private void restartWorker(final Thread worker) {
+    worker.interrupt();
+    if (connectionPerWorker) {
+      final OpenConnection hung = workerApis.get(worker);
+      if (hung != null) {
+        connectionStats(hung.host).reconnects.incrementAndGet();
+      }
+      // the worker connects again on its next statement
+      workerApis.remove(worker);
+      logger.warning("interrupted hung worker, its next query uses a fresh connection");
+      return;
+    }
+    // which coordinator the worker is stuck on is not known, so every shared connection is renewed
+    for (final String coordinator : coordinators.urls()) {
+      connectionStats(coordinator).reconnects.incrementAndGet();
+      try {
+        final DremioApi fresh = connect(coordinator);
+        if (initSession(fresh)) {
+          sharedApis.put(coordinator, fresh);
+          logger.warning("interrupted hung worker, new queries use a fresh connection");
+        }
+      } catch (IOException | RuntimeException e) {
+        logger.log(Level.WARNING, "unable to open a fresh connection for hung worker", e);
+      }
+    }
+  }
+
+  /**
+   * counts the unexpected failures in a row of the calling worker and reconnects it once there are
+   * --restart-after-failures of them, as a poisoned connection or expired login would otherwise
+   * fail every statement for the rest of the run
+   *
+   * @param api the api the statement ran on
+   * @param failed true when the statement failed unexpectedly
+   */
+  private void trackFailures(final DremioApi api, final boolean failed) {
+    if (restartAfterFailures <= 0) {
+      return;
+    }
+    final Thread worker = Thread.currentThread();
+    if (!failed) {
+      consecutiveFailures.remove(worker);
+      return;
+    }
+    final int failures = consecutiveFailures.merge(worker, 1, Integer::sum);
+    if (failures < restartAfterFailures) {
+      return;
+    }
+    consecutiveFailures.remove(worker);
+    recordEvent(
+        "restart",
+        String.format(
+            "worker %s failed %d statements in a row, reconnecting", worker.getName(), failures));
+    reconnect(worker, api);
+  }
+
+  /**
+   * replaces the connection a worker failed on. An own connection of the worker or of a session is
+   * closed so it connects again on its next statement, a shared connection is replaced for every
+   * worker and closed once the statements still running on it hit the timeout. Dedicated
+   * connections are closed after every iteration anyway.
+   *
+   * @param worker the worker that failed
+   * @param api the api it failed on
+   */
+  private void reconnect(final Thread worker, final DremioApi api) {
+    final OpenConnection own = workerApis.get(worker);
+    if (own != null && own.api == api) {
+      // the worker is between statements so nothing runs on the old connection
+      workerApis.remove(worker);
+      connectionStats(own.host).reconnects.incrementAndGet();
+      own.api.close();
+      return;
+    }
+    for (final Session session : simulatedSessions) {
+      final OpenConnection connection = session.connection;
+      if (connection != null && connection.api == api) {
+        session.connection = null;
+        connectionStats(connection.host).reconnects.incrementAndGet();
+        connection.api.close();
+        return;
+      }
+    }
+    for (final String coordinator : coordinators.urls()) {
+      if (sharedApis.get(coordinator) == api) {
+        replaceShared(coordinator, api);
+        return;
+      }
+    }
+  }
+
+  /**
+   * replaces the connection the workers share to a coordinator, the old one is closed once the
+   * statements still running on it hit the timeout
+   *
+   * @param coordinator url of the coordinator
+   * @param old the connection to replace, nothing is replaced when another thread did it first
+   */
+  private void replaceShared(final String coordinator, final DremioApi old) {
+    connectionStats(coordinator).reconnects.incrementAndGet();
+    try {
+      final DremioApi fresh = connect(coordinator);
+      if (!initSession(fresh)) {
+        fresh.close();
+        return;
+      }
+      if (sharedApis.replace(coordinator, old, fresh)) {
+        closeLater(old);
+      } else {
+        fresh.close();
+      }
+    } catch (IOException | RuntimeException e) {
+      logger.log(Level.WARNING, "unable to replace the shared connection", e);
+    }
+  }
+
+  /**
+   * closes a connection once the statements still running on it hit the timeout
+   *
+   * @param api the connection to close
+   */
+  private void closeLater(final DremioApi api) {
+    timer.schedule(
+        new TimerTask() {
+          public void run() {
+            api.close();
+          }
+        },
+        timeoutSeconds * 1000L);
+  }
+
+  /**
+   * runs the probe statement on a connection of its own every probeSeconds, not queued behind the
+   * workload, so its latency shows how responsive the cluster stays for interactive queries while
+   * the heavy load runs
+   */
+  private void startProbe() {
+    if (probeSeconds <= 0) {
+      return;
+    }
+    final String host = coordinators.urls().get(0);
+    final DremioApi api;
+    try {
+      api = connect(host);
+    } catch (IOException | RuntimeException e) {
+      logger.log(Level.WARNING, "unable to connect the probe, running without it", e);
+      return;
+    }
+    probeStats = new TargetStats("probe", Redact.url(host));
+    probeTimer = new Timer("probe", true);
+    probeTimer.schedule(
+        new TimerTask() {
+          public void run() {
+            final long start = System.currentTimeMillis();
+            boolean successful;
+            try {
+              successful = api.runSQL(probeSql, SqlContext.empty()).isSuccessful();
+            } catch (IOException | RuntimeException e) {
+              logger.fine(() -> "probe failed: " + e.getMessage());
+              successful = false;
+            }
+            lastProbeMS = System.currentTimeMillis() - start;
+            probeStats.record(successful, lastProbeMS);
+          }
+        },
+        0,
+        probeSeconds * 1000L);
+  }
+
+  /**
+   * health checks the shared, worker and session connections every --health-check-seconds on a
+   * thread of its own, so slow checks do not delay the progress output. An unhealthy connection is
+   * replaced like a recycled one, the event is recorded on the timeline and counted in the
+   * progress output. The --compare-url and --shadow-url connections are not checked.
+   */
+  private void startHealthChecks() {
+    if (healthCheckSeconds <= 0) {
+      return;
+    }
+    final Timer checks = new Timer("health-checks", true);
+    checks.schedule(
+        new TimerTask() {
+          public void run() {
+            for (final String coordinator : coordinators.urls()) {
+              final DremioApi shared = sharedApis.get(coordinator);
+              if (shared != null && !shared.healthCheck()) {
+                unhealthy(coordinator);
+                replaceShared(coordinator, shared);
+              }
+            }
+            for (final Entry<Thread, OpenConnection> entry : workerApis.entrySet()) {
+              final OpenConnection connection = entry.getValue();
+              if (!connection.api.healthCheck()) {
+                unhealthy(connection.host);
+                // the worker connects again on its next statement
+                if (workerApis.remove(entry.getKey(), connection)) {
+                  closeLater(connection.api);
+                }
+              }
+            }
+            for (final Session session : simulatedSessions) {
+              final OpenConnection connection = session.connection;
+              if (connection != null && !connection.api.healthCheck()) {
+                unhealthy(connection.host);
+                session.connection = null;
+                closeLater(connection.api);
+              }
+            }
+          }
+        },
+        healthCheckSeconds * 1000L,
+        healthCheckSeconds * 1000L);
+  }
+
+  private void unhealthy(final String host) {
+    unhealthyCounter.incrementAndGet();
+    connectionStats(host).unhealthy.incrementAndGet();
+    recordEvent(
+        "unhealthy",
+        String.format(
+            "a connection to %s failed its health check, reconnecting", Redact.url(host)));
+  }
+
+  /**
+   * the connection of the calling worker, connected on first use when every worker has its own
+   *
+   * @return the connection to run with, null when the worker could not connect
+   */
+  private DremioApi workerApi() {
+    if (!connectionPerWorker) {
+      final String coordinator = coordinators.next();
+      connectionStats(coordinator).reused.incrementAndGet();
+      return sharedApis.get(coordinator);
+    }
+    final Thread worker = Thread.currentThread();
+    final OpenConnection existing = workerApis.get(worker);
+    if (existing != null && !expired(existing)) {
+      connectionStats(existing.host).reused.incrementAndGet();
+      return existing.api;
+    }
+    if (existing != null) {
+      // the worker is between statements so nothing runs on the old connection
+      workerApis.remove(worker);
+      existing.api.close();
+    }
+    try {
+      final OpenConnection opened = open();
+      if (!initSession(opened.api)) {
+        return null;
+      }
+      workerApis.put(worker, opened);
+      return opened.api;
+    } catch (IOException e) {
+      logger.log(Level.WARNING, String.format("worker %s unable to connect", worker.getName()), e);
+      return null;
+    }
+  }
+
+  /**
+   * a thread replaying the original concurrency. The threads come and go with the bursts of the
+   * workload, so the connection of the thread is closed when it exits
+   *
+   * @param task the work of the thread
+   * @return the thread
+   */
+  private Thread newReplayThread(final Runnable task) {
+    return Executors.defaultThreadFactory()
+        .newThread(
+            () -> {
+              try {
+                task.run();
+              } finally {
+                final OpenConnection own = workerApis.remove(Thread.currentThread());
+                if (own != null) {
+                  own.api.close();
+                }
+              }
+            });
+  }
+
+  /**
+   * runs an iteration of a query group on a connection of its own that is closed afterwards, so
+   * USE and ALTER SESSION statements of the group do not leak into other queries
+   *
+   * @param queries statements of the iteration
+   * @return true when every statement ran as expected
+   */
+  private boolean runDedicated(final List<Query> queries) {
+    final DremioApi dedicated;
+    try {
+      dedicated = connect();
+    } catch (IOException e) {
+      logger.log(Level.WARNING, "unable to open a dedicated connection", e);
+      return false;
+    }
+    dedicatedApis.add(dedicated);
+    try {
+      return initSession(dedicated) && runQueries(dedicated, queries, primaryStats);
+    } catch (IOException e) {
+      logger.log(Level.WARNING, "unable to initialize a dedicated connection", e);
+      return false;
+    } finally {
+      dedicatedApis.remove(dedicated);
+      dedicated.close();
+    }
+  }
+
+  /**
+   * connects the session on first use
+   *
+   * @param session the session about to run a statement
+   * @return the connection of the session, null when it could not connect
+   */
+  private DremioApi sessionApi(final Session session) {
+    // read once as chaos may drop the connection from another thread
+    final OpenConnection existing = session.connection;
+    if (existing != null && !expired(existing)) {
+      connectionStats(existing.host).reused.incrementAndGet();
+      return existing.api;
+    }
+    if (existing != null) {
+      existing.api.close();
+      session.connection = null;
+    }
+    try {
+      final OpenConnection opened = open();
+      if (!initSession(opened.api)) {
+        return null;
+      }
+      session.connection = opened;
+      return opened.api;
+    } catch (IOException e) {
+      logger.log(Level.WARNING, String.format("session %d unable to connect", session.id), e);
+      return null;
+    }
+  }
+
+  /**
+   * connects to the next coordinator
+   *
+   * @return the connection and the coordinator it goes to
+   * @throws IOException when unable to connect
+   */
+  private OpenConnection open() throws IOException {
+    final String coordinator = coordinators.next();
+    return new OpenConnection(coordinator, connect(coordinator));
+  }
+
+  private boolean expired(final OpenConnection connection) {
+    return recycleNanos > 0 && System.nanoTime() - connection.openedAtNanos > recycleNanos;
+  }
+
+  /**
+   * replaces the connection shared by the workers every recycle interval, so long runs against
+   * load balanced coordinators rebalance. The old connection is closed once the statements still
+   * running on it hit the timeout.
+   */
+  private void startRecycling() {
+    if (recycleNanos == 0 || connectionPerWorker || sessions > 0) {
+      return;
+    }
+    final long periodMS = TimeUnit.NANOSECONDS.toMillis(recycleNanos);
+    timer.schedule(
+        new TimerTask() {
+          public void run() {
+            for (final String coordinator : coordinators.urls()) {
+              try {
+                final DremioApi fresh = connect(coordinator);
+                if (!initSession(fresh)) {
+                  fresh.close();
+                  continue;
+                }
+                final DremioApi old = sharedApis.put(coordinator, fresh);
+                logger.info("recycled the shared connection to " + Redact.url(coordinator));
+                timer.schedule(
+                    new TimerTask() {
+                      public void run() {
+                        old.close();
+                      }
+                    },
+                    timeoutSeconds * 1000L);
+              } catch (IOException | RuntimeException e) {
+                logger.log(Level.WARNING, "unable to recycle the shared connection", e);
+              }
+            }
+          }
+        },
+        periodMS,
+        periodMS);
+  }
+
+  /**
+   * every chaos interval either drops the chaos percentage of the connections, as a coordinator
+   * restart would, or delays the chaos percentage of the statements of the next interval by the
+   * chaos delay on the client. Every action is recorded in the timeline of the results.
+   */
+  private void startChaos() {
+    if (chaosIntervalMS == 0 || chaosPercent == 0) {
+      return;
+    }
+    timer.schedule(
+        new TimerTask() {
+          public void run() {
+            if (chaosDelayMS > 0 && random.nextBoolean()) {
+              chaosDelayUntilNanos =
+                  System.nanoTime() + TimeUnit.MILLISECONDS.toNanos(chaosIntervalMS);
+              recordEvent(
+                  "chaos",
+                  String.format(
+                      "delaying %.1f %% of the statements by %d ms for %s",
+                      chaosPercent,
+                      chaosDelayMS,
+                      Human.getHumanDurationFromMillis(chaosIntervalMS)));
+              return;
+            }
+            recordEvent("chaos", String.format("dropped %d connections", dropConnections()));
+          }
+        },
+        chaosIntervalMS,
+        chaosIntervalMS);
+  }
+
+  /**
+   * closes the chaos percentage of the open connections. Statements running on them fail, shared
+   * connections are replaced right away and the others are opened again on their next statement.
+   *
+   * @return number of connections dropped
+   */
+  private int dropConnections() {
+    int dropped = 0;
+    for (final String coordinator : coordinators.urls()) {
+      if (random.nextDouble() * 100.0 >= chaosPercent) {
+        continue;
+      }
+      try {
+        final DremioApi fresh = connect(coordinator);
+        if (initSession(fresh)) {
+          sharedApis.put(coordinator, fresh).close();
+          connectionStats(coordinator).reconnects.incrementAndGet();
+          dropped++;
+        }
+      } catch (IOException | RuntimeException e) {
+        logger.log(Level.WARNING, "chaos was unable to replace the shared connection", e);
       }
-    } catch (IOException e) {
-      logger.log(Level.SEVERE, "unable to connect", e);
-      return 1;
     }
-    return 0;
+    for (final Map.Entry<Thread, OpenConnection> entry : workerApis.entrySet()) {
+      if (random.nextDouble() * 100.0 < chaosPercent
+          && workerApis.remove(entry.getKey(), entry.getValue())) {
+        entry.getValue().api.close();
+        dropped++;
+      }
+    }
+    for (final Session session : simulatedSessions) {
+      final OpenConnection connection = session.connection;
+      if (connection != null && random.nextDouble() * 100.0 < chaosPercent) {
+        session.connection = null;
+        connection.api.close();
+        dropped++;
+      }
+    }
+    return dropped;
+  }
+
+  /**
+   * delays the statement when chaos is injecting client side delays
+   *
+   * @return false when interrupted while delaying
+   */
+  private boolean chaosDelay() {
+    if (chaosDelayMS == 0
+        || System.nanoTime() - chaosDelayUntilNanos > 0
+        || random.nextDouble() * 100.0 >= chaosPercent) {
+      return true;
+    }
+    try {
+      Thread.sleep(chaosDelayMS);
+      return true;
+    } catch (InterruptedException e) {
+      Thread.currentThread().interrupt();
+      return false;
+    }
+  }
+
+  /**
+   * creates the simulated sessions, the first sessions take the rate caps of the users configured
+   * in the stress.json in order and the remaining sessions are not capped
+   *
+   * @return the sessions
+   * @throws InvalidParameterException when a user has an invalid rate
+   */
+  private List<Session> newSessions() {
+    final List<UserRate> users =
+        this.fileType == QueriesGeneratorFileType.STRESS_JSON ? getConfig().getUsers() : null;
+    final List<Session> created = new ArrayList<>();
+    if (users != null) {
+      for (final UserRate user : users) {
+        final String name = user.getName() == null ? "user " + users.indexOf(user) : user.getName();
+        final int count = user.getSessions() == null ? 1 : user.getSessions();
+        final int burst = user.getBurst() == null ? 1 : user.getBurst();
+        if (count < 1 || burst < 1) {
+          throw new InvalidParameterException("sessions and burst must be at least 1 for " + name);
+        }
+        if (user.getQueriesPerMinute() == null || user.getQueriesPerMinute() <= 0) {
+          throw new InvalidParameterException(
+              "queriesPerMinute must be greater than 0 for " + name);
+        }
+        final int capped = Math.min(count, sessions - created.size());
+        if (capped < count) {
+          logger.warning(
+              String.format(
+                  "only %d of the %d sessions of %s fit in --sessions %d",
+                  capped, count, name, sessions));
+        }
+        // the rate follows --scale like the workers do
+        final double perMinute = user.getQueriesPerMinute() * scale;
+        for (int i = 0; i < capped; i++) {
+          created.add(new Session(created.size(), new TokenBucket(perMinute, burst)));
+        }
+        logger.info(
+            String.format(
+                "%s: %d sessions capped at %.1f queries per minute (burst %d)",
+                name, capped, perMinute, burst));
+      }
+    }
+    while (created.size() < sessions) {
+      created.add(new Session(created.size(), null));
+    }
+    return created;
+  }
+
+  /**
+   * makes the session available again
+   *
+   * @param readySessions sessions ready to run, null when sessions are not simulated
+   * @param session the session to release, may be null
+   * @param think true when the session ran a statement and should think before its next one
+   */
+  private void releaseSession(
+      final DelayQueue<Session> readySessions, final Session session, final boolean think) {
+    if (readySessions == null || session == null) {
+      return;
+    }
+    if (think && thinkTimeMS > 0) {
+      // spread the think time between half and one and a half times the configured value so the
+      // sessions do not act in lockstep
+      final long jitteredMS = thinkTimeMS / 2 + (long) (random.nextDouble() * thinkTimeMS);
+      session.readyAtNanos = System.nanoTime() + jitteredMS * 1_000_000;
+    } else {
+      session.readyAtNanos = System.nanoTime();
+    }
+    if (session.bucket != null) {
+      // a capped session also waits for its next token
+      session.readyAtNanos =
+          Math.max(session.readyAtNanos, System.nanoTime() + session.bucket.nanosUntilAvailable());
+    }
+    readySessions.add(session);
+  }
+
+  /**
+   * runs the configured session initialization statements on the connection
+   *
+   * @param dremioApi connection to initialize
+   * @return false when one of the statements failed
+   * @throws IOException when the underlying api call fails
+   */
+  private boolean initSession(final DremioApi dremioApi) throws IOException {
+    if (this.fileType != QueriesGeneratorFileType.STRESS_JSON) {
+      return true;
+    }
+    final List<String> statements = getConfig().getSessionInit();
+    if (statements == null || statements.isEmpty()) {
+      return true;
+    }
+    if (protocol == Protocol.HTTP) {
+      logger.warning(
+          "sessionInit statements are only supported with JDBC, the HTTP api has no session to"
+              + " configure so they are ignored");
+      return true;
+    }
+    for (final String sql : statements) {
+      logger.info(() -> String.format("initializing session with %s", sql));
+      final DremioApiResponse response = dremioApi.runSQL(sql, SqlContext.empty());
+      if (response == null || !response.isSuccessful()) {
+        logger.severe(
+            String.format(
+                "session initialization statement '%s' failed %s",
+                sql, response == null ? "" : response.getErrorMessage()));
+        return false;
+      }
+    }
+    return true;
+  }
+
+  private boolean maxQueriesReached() {
+    return maxQueries > 0 && (counter.get() >= maxQueries || maxQueriesFull.get());
+  }
+
+  private static ThreadPoolExecutor newExecutor(final int workers, final int capacity) {
+    return newExecutor(workers, capacity, null);
   }
 
-  private void monitorForEnd(Instant d, ExecutorService executorService, Integer numQueries) {
-    new Thread(
+  /**
+   * @param workers number of workers
+   * @param capacity capacity of the queue
+   * @param utilization tracks the busy time of the workers, null to not track it
+   * @return the executor
+   */
+  private static ThreadPoolExecutor newExecutor(
+      final int workers, final int capacity, final WorkerUtilization utilization) {
+    return new ThreadPoolExecutor(
+        workers, workers, 0L, TimeUnit.MILLISECONDS, new LinkedBlockingQueue<>(capacity)) {
+      @Override
+      protected void beforeExecute(final Thread t, final Runnable r) {
+        super.beforeExecute(t, r);
+        if (utilization != null) {
+          utilization.begin(t);
+        }
+      }
+
+      @Override
+      protected void afterExecute(final Runnable r, final Throwable t) {
+        if (utilization != null) {
+          utilization.end(Thread.currentThread());
+        }
+        super.afterExecute(r, t);
+      }
+    };
+  }
+
+  /**
+   * watches for the end of the run (duration, max queries or the end of a sequential run) and then
+   * prints the summary and stops every executor. The queued queries are dropped and the workers are
+   * interrupted so the run ends promptly at the deadline even when every worker is busy with a long
+   * query.
+   *
+   * @param d start of the run
+   * @param executors all executors used by the run, the shared one first
+   * @param numQueries number of queries in the pool
+   */
+  private void monitorForEnd(
+      final Instant d, final List<ExecutorService> executors, final Integer numQueries) {
+    final Thread monitor =
+        new Thread(
             () -> {
               while (true) {
                 try {
@@ -434,10 +3392,22 @@ public class StressExec {
                 }
                 final Instant now = Instant.now();
                 long msElapsed = now.toEpochMilli() - d.toEpochMilli();
-                if (msElapsed > durationTargetMS || queryIndex.get() + 1 >= numQueries) {
+                final boolean maxQueriesCompleted =
+                    maxQueriesReached()
+                        && successfulCounter.get()
+                                + failureCounter.get()
+                                + skippedCounter.get()
+                                + fuzzFailureCounter.get()
+                            >= counter.get();
+                if (msElapsed > durationTargetMS
+                    || stopRequested.get()
+                    || exhausted.get()
+                    || queryIndex.get() + 1 >= numQueries
+                    || maxQueriesCompleted) {
                   final int submitted = submittedCounter.get();
                   final int successful = successfulCounter.get();
                   final int failures = failureCounter.get();
+                  final int skipped = skippedCounter.get();
                   final int index = queryIndex.get();
                   final long secondsElapsed = msElapsed / 1000;
                   try {
@@ -445,24 +3415,120 @@ public class StressExec {
                   } catch (InterruptedException e) {
                     throw new RuntimeException(e);
                   }
-                  System.out.printf(
-                      "%s - Stress Summary: queries submitted: %d; queries successful: %d; queries"
-                          + " successful per second: %.2f; failure rate: %.2f %% - time elapsed:"
-                          + " %s/%s - last query index: %d%n",
-                      Instant.now(),
-                      submitted,
-                      successful,
-                      (float) submitted / secondsElapsed,
-                      ((float) failures / submitted) * 100.0,
-                      Human.getHumanDurationFromMillis(msElapsed),
-                      Human.getHumanDurationFromMillis(durationTargetMS),
-                      index);
-                  executorService.shutdownNow();
+                  final StringBuilder summary = new StringBuilder();
+                  summary.append(
+                      String.format(
+                          "%s - Stress Summary: queries submitted: %d; queries successful: %d;"
+                              + " queries skipped after a failed group step: %d; queries"
+                              + " successful per second: %.2f; failure rate: %.2f %% - time"
+                              + " elapsed: %s/%s - last query index: %d%n",
+                          Instant.now(),
+                          submitted,
+                          successful,
+                          skipped,
+                          (float) submitted / secondsElapsed,
+                          ((float) failures / submitted) * 100.0,
+                          Human.getHumanDurationFromMillis(msElapsed),
+                          Human.getHumanDurationFromMillis(durationTargetMS),
+                          index));
+                  final Map<String, Long> phases = averagePhases();
+                  if (!phases.isEmpty()) {
+                    summary.append(
+                        String.format(
+                            "average time per query by phase: %s%n", describePhases(phases)));
+                  }
+                  if (fuzzedCounter.get() > 0) {
+                    summary.append(
+                        String.format(
+                            "fuzzed statements: %d, %d of them failed and are not counted as"
+                                + " failures%n",
+                            fuzzedCounter.get(), fuzzFailureCounter.get()));
+                  }
+                  summary.append(hitRateReport());
+                  summary.append(slaReport());
+                  summary.append(connectionReport());
+                  if (replayOriginalConcurrency) {
+                    summary.append(
+                        String.format(
+                            "peak queries running at once: %d, originally %d%n",
+                            ((ThreadPoolExecutor) executors.get(0)).getLargestPoolSize(),
+                            originalPeakConcurrency));
+                  }
+                  if (compareStats != null) {
+                    summary.append(TargetStats.compare(primaryStats, compareStats));
+                  }
+                  if (shadowStats != null) {
+                    summary.append(String.format("%s%n", shadowStats.summary()));
+                  }
+                  if (intendedStartStats.getSuccessful() > 0) {
+                    summary.append(coordinatedOmissionReport());
+                  }
+                  if (firstRowStats.getSuccessful() > 0) {
+                    summary.append(firstRowReport());
+                  }
+                  if (probeStats != null) {
+                    probeTimer.cancel();
+                    summary.append(
+                        String.format(
+                            "%s; max: %s%n",
+                            probeStats.summary(),
+                            Human.getHumanDurationFromMillis(probeStats.max())));
+                  }
+                  if (!replayOriginalConcurrency) {
+                    summary.append(String.format("%s%n", utilization.summary()));
+                  }
+                  summary.append(String.format("%s%n", ClientStats.summary()));
+                  System.out.print(summary);
+                  notifier.completed(summary.toString());
+                  for (final ExecutorService executor : executors) {
+                    executor.shutdownNow();
+                  }
+                  if (hardDeadline) {
+                    System.out.printf(
+                        "hard deadline: cancelled %d in flight queries%n", cancelEveryInFlight());
+                  }
+                  return;
                 }
               }
             },
-            "reporting")
-        .start();
+            "reporting");
+    // never keep the jvm alive just to report
+    monitor.setDaemon(true);
+    monitor.start();
+  }
+
+  /**
+   * cancels the statements still running on every open connection: the shared ones, those of the
+   * workers and sessions, the dedicated connections of query groups and the compare and shadow
+   * clusters
+   *
+   * @return number of statements a cancel was sent for
+   */
+  private int cancelEveryInFlight() {
+    final Set<DremioApi> apis = Collections.newSetFromMap(new IdentityHashMap<>());
+    apis.addAll(sharedApis.values());
+    for (final OpenConnection connection : workerApis.values()) {
+      apis.add(connection.api);
+    }
+    for (final Session session : simulatedSessions) {
+      // read once as chaos or recycling may replace the connection from another thread
+      final OpenConnection connection = session.connection;
+      if (connection != null) {
+        apis.add(connection.api);
+      }
+    }
+    apis.addAll(dedicatedApis);
+    if (compareApi != null) {
+      apis.add(compareApi);
+    }
+    if (shadowApi != null) {
+      apis.add(shadowApi);
+    }
+    int cancelled = 0;
+    for (final DremioApi api : apis) {
+      cancelled += api.cancelInFlight();
+    }
+    return cancelled;
   }
 
   private Map<String, QueryGroup> getStringQueryGroupMap() {
@@ -483,57 +3549,176 @@ public class StressExec {
     return queryGroups;
   }
 
-  private static List<QueryConfig> getQueryConfigs(StressConfig config) {
-    final List<QueryConfig> queryPool = new ArrayList<>();
-    for (final QueryConfig q : config.getQueries()) {
-      int i = 0;
-      final int frequency = Math.max(q.getFrequency(), 1);
-      while (i < frequency) {
-        i++;
-        queryPool.add(q);
+  /**
+   * whether some queries have a sqlContext and others do not
+   *
+   * @param queries the queries of the run
+   * @return true when both kinds are present
+   */
+  static boolean mixesContexts(final List<QueryConfig> queries) {
+    boolean with = false;
+    boolean without = false;
+    for (final QueryConfig q : queries) {
+      if (new SqlContext(q.getSqlContext()).isEmpty()) {
+        without = true;
+      } else {
+        with = true;
       }
     }
-    return queryPool;
+    return with && without;
+  }
+
+  /**
+   * prints the effective probability of every configured query and query group and a projected
+   * rate, so misconfigured weights are obvious before the run finishes. Latency is unknown before
+   * the run so the projection assumes every statement takes one second.
+   *
+   * @param queries configured queries
+   * @param queryGroups query groups by name
+   */
+  private void printFrequencyReport(
+      final List<QueryConfig> queries, final Map<String, QueryGroup> queryGroups) {
+    double totalWeight = 0;
+    double weightedStatements = 0;
+    for (final QueryConfig q : queries) {
+      totalWeight += QueryPool.weightOf(q);
+    }
+    for (final QueryConfig q : queries) {
+      weightedStatements += QueryPool.weightOf(q) * statementCount(q, queryGroups);
+    }
+    final double statementsPerMinute = this.maxQueriesInFlight * 60.0;
+    System.out.printf(
+        "workload mix (%d workers, projected rates assume 1 second per statement):%n",
+        this.maxQueriesInFlight);
+    for (final QueryConfig q : queries) {
+      final double weight = QueryPool.weightOf(q);
+      final double probability = weight / totalWeight;
+      // a group iteration runs all of its statements so it takes longer than a single query
+      final double perMinute = statementsPerMinute * weight / weightedStatements;
+      System.out.printf(
+          "  %6.2f %% (%s %s) ~%s runs/min - %s%n",
+          probability * 100.0,
+          q.getWeight() == null ? "frequency" : "weight",
+          QueryPool.format(weight),
+          Human.getHumanNumber(perMinute),
+          describe(q) + describeSchedule(q, queryGroups.get(q.getQueryGroup())));
+    }
+  }
+
+  /**
+   * @param q configured query
+   * @param group query group the query references, null when it does not
+   * @return when the query starts into the run and how often it runs, an empty string when it runs
+   *     for the whole run
+   */
+  static String describeSchedule(final QueryConfig q, final QueryGroup group) {
+    final List<String> schedule = new ArrayList<>();
+    final long onsetMS = startAfterMS(q, group);
+    if (onsetMS > 0) {
+      schedule.add("starts after " + Human.getHumanDurationFromMillis(onsetMS));
+    }
+    if (q.getMaxExecutions() != null) {
+      schedule.add("at most " + q.getMaxExecutions() + " executions");
+    }
+    return schedule.isEmpty() ? "" : " (" + String.join(", ", schedule) + ")";
+  }
+
+  private static int statementCount(
+      final QueryConfig q, final Map<String, QueryGroup> queryGroups) {
+    final QueryGroup group = queryGroups.get(q.getQueryGroup());
+    if (group == null || group.getQueries() == null) {
+      return q.getQuery() == null ? 1 : StatementSplitter.split(q.getQuery()).size();
+    }
+    return Math.max(group.getQueries().size(), 1);
+  }
+
+  /**
+   * short human readable description of a configured query
+   *
+   * @param q the configured query
+   * @return the query group name or the start of the query text
+   */
+  static String describe(final QueryConfig q) {
+    // the same sql on two engines is compared, so each gets statistics of its own
+    final String engine = q.getEngine() == null ? "" : " [engine " + q.getEngine() + "]";
+    if (q.getQueryGroup() != null && !q.getQueryGroup().isEmpty()) {
+      return "query group " + q.getQueryGroup() + engine;
+    }
+    if (q.getCatalog() != null) {
+      final List<String> path =
+          q.getCatalogPath() == null ? Collections.emptyList() : q.getCatalogPath();
+      return String.format("catalog %s %s", q.getCatalog(), String.join(".", path)).trim();
+    }
+    final String text = String.valueOf(q.getQuery()).replaceAll("\\s+", " ").trim();
+    return (text.length() > 80 ? text.substring(0, 77) + "..." : text) + engine;
+  }
+
+  /**
+   * wraps a statement in EXPLAIN PLAN FOR so the planner and metadata are exercised without
+   * executing it. Statements that only change the session or do not execute anyway run as they are.
+   *
+   * @param sql a single statement
+   * @return the statement to run
+   */
+  static String explainPlan(final String sql) {
+    final List<String> words = ReadOnlyGuard.keywords(sql);
+    if (words.isEmpty()
+        || notPlanned.contains(words.get(0))
+        || ReadOnlyGuard.isSessionChange(words)) {
+      return sql;
+    }
+    return "EXPLAIN PLAN FOR " + sql;
+  }
+
+  private static List<QueryConfig> getQueryConfigs(StressConfig config) {
+    return new QueryPool(config.getQueries());
   }
 
   public List<Query> mapSql(final QueryConfig q, final Map<String, QueryGroup> queryGroupsMap) {
-    final List<String> rawQueries = new ArrayList<>();
+    if (q.getCatalog() != null) {
+      return Collections.singletonList(mapCatalog(q));
+    }
+    final List<QueryGroupMember> members = new ArrayList<>();
+    final Long timeoutMS = timeoutMS(q, queryGroupsMap.get(q.getQueryGroup()));
+    final String engine = engine(q, queryGroupsMap.get(q.getQueryGroup()));
     if (q.getQueryGroup() != null && !q.getQueryGroup().isEmpty()) {
-      final List<String> queries = queryGroupsMap.get(q.getQueryGroup()).getQueries();
-      rawQueries.addAll(queries);
+      members.addAll(queryGroupsMap.get(q.getQueryGroup()).getQueries());
     } else if (q.getQuery() != null && !q.getQuery().isEmpty()) {
-      rawQueries.add(q.getQuery());
-    }
-    final Map<String, List<Object>> parameters;
-    if (q.getParameters() == null) {
-      parameters = new HashMap<>();
-    } else {
-      parameters = q.getParameters();
+      // a script of several statements runs them in order like an implicit query group
+      for (final String statement : StatementSplitter.split(q.getQuery())) {
+        members.add(new QueryGroupMember(statement));
+      }
     }
     final List<Query> mappedQueries = new ArrayList<>();
-    for (final String sql : rawQueries) {
+    // the same unique value is used by every step of the iteration so create and drop match
+    final String uniq = String.format("%s_%d", runId, iterationSequence.incrementAndGet());
+    for (final QueryGroupMember member : members) {
+      final String sql = uniqToken.matcher(member.getQuery()).replaceAll(uniq);
+      // member parameters override the parameters of the query referencing the group
+      final Map<String, List<Object>> parameters = merge(q.getParameters(), member.getParameters());
+      final Map<String, String> parameterTypes =
+          merge(q.getParameterTypes(), member.getParameterTypes());
       final Query query = new Query();
-      query.setContext(q.getSqlContext());
-      if (parameters.size() > 0) {
+      query.setLabel(describe(q));
+      query.setContext(new SqlContext(q.getSqlContext()));
+      query.setExpectFailure(q.isExpectFailure() || member.isExpectFailure());
+      query.setTimeoutMS(timeoutMS);
+      query.setEngine(engine);
+      query.setCaptureResult(member.getCaptureResult());
+      final Map<String, Object> chosen = new HashMap<>();
+      if (preparedStatements) {
+        mapPrepared(query, sql, parameters, parameterTypes, chosen);
+      } else if (parameters.size() > 0) {
         final String[] tokens = sql.split(" ");
         final int words = tokens.length;
         for (int i = 0; i < words; i++) {
           final String word = tokens[i];
           for (final Entry<String, List<Object>> x : parameters.entrySet()) {
-            if (word.equals(":" + x.getKey())) {
-              final int valueCount = x.getValue().size();
-              if (valueCount > 0) {
-                final int valueIndex = random.nextInt(valueCount);
-                final String v = String.valueOf(x.getValue().get(valueIndex));
-                tokens[i] = v;
-              }
-            } else if (word.equals("':" + x.getKey() + "'")) {
-              final int valueCount = x.getValue().size();
-              if (valueCount > 0) {
-                final int valueIndex = random.nextInt(valueCount);
-                final String v = String.valueOf(x.getValue().get(valueIndex));
-                tokens[i] = "'" + v + "'";
-              }
+            final boolean quoted = word.equals("':" + x.getKey() + "'");
+            if ((quoted || word.equals(":" + x.getKey())) && !x.getValue().isEmpty()) {
+              final Object v = x.getValue().get(random.nextInt(x.getValue().size()));
+              chosen.put(x.getKey(), v);
+              tokens[i] = toSql(parameterTypes, x.getKey(), v, quoted);
             }
           }
         }
@@ -541,8 +3726,351 @@ public class StressExec {
       } else {
         query.setQueryText(sql);
       }
+      if (member.getCaptureParameters() != null) {
+        final Map<String, Object> captured = new HashMap<>();
+        for (final String name : member.getCaptureParameters()) {
+          if (chosen.containsKey(name)) {
+            captured.put(name, chosen.get(name));
+          }
+        }
+        query.setCapturedParameters(captured);
+      }
+      final String fuzzed = fuzzer.fuzz(query.getQueryText());
+      query.setFuzzed(!fuzzed.equals(query.getQueryText()));
+      query.setQueryText(fuzzed);
+      if (explainOnly) {
+        query.setQueryText(explainPlan(query.getQueryText()));
+      }
+      if (q.isCacheBuster()) {
+        // prepended so a trailing line comment or semicolon in the query cannot swallow it
+        query.setQueryText(
+            String.format(
+                "/* cache buster %s_%d */ %s", uniq, mappedQueries.size(), query.getQueryText()));
+      }
       mappedQueries.add(query);
     }
     return mappedQueries;
   }
+
+  /**
+   * the timeout of the query, falling back to the timeout of its query group
+   *
+   * @param q the configured query
+   * @param group the group the query references, may be null
+   * @return the timeout in milliseconds or null to use the --http-timeout-seconds flag
+   */
+  private static Long timeoutMS(final QueryConfig q, final QueryGroup group) {
+    final String timeout =
+        q.getTimeout() != null ? q.getTimeout() : group == null ? null : group.getTimeout();
+    return timeout == null ? null : Human.parseDurationMillis(timeout);
+  }
+
+  /**
+   * the Dremio Cloud engine of the query, falling back to the engine of its query group
+   *
+   * @param q the configured query
+   * @param group the group the query references, may be null
+   * @return the engine name or null to leave the choice to the routing rules
+   */
+  private static String engine(final QueryConfig q, final QueryGroup group) {
+    return q.getEngine() != null ? q.getEngine() : group == null ? null : group.getEngine();
+  }
+
+  /**
+   * maps a catalog call, path components that are :name parameters get a random value
+   *
+   * @param q the configured catalog call
+   * @return the call to run
+   */
+  private Query mapCatalog(final QueryConfig q) {
+    final CatalogOperation operation = CatalogOperation.of(q.getCatalog());
+    final List<String> path = new ArrayList<>();
+    if (q.getCatalogPath() != null) {
+      for (final String part : q.getCatalogPath()) {
+        final List<Object> values =
+            q.getParameters() == null || !part.startsWith(":")
+                ? null
+                : q.getParameters().get(part.substring(1));
+        path.add(
+            values == null || values.isEmpty()
+                ? part
+                : String.valueOf(values.get(random.nextInt(values.size()))));
+      }
+    }
+    final Query query = new Query();
+    query.setLabel(describe(q));
+    query.setQueryText(
+        String.format("catalog %s %s", operation.name().toLowerCase(), String.join(".", path))
+            .trim());
+    query.setContext(SqlContext.empty());
+    query.setExpectFailure(q.isExpectFailure());
+    query.setCatalogOperation(operation);
+    query.setCatalogPath(path);
+    query.setCatalogFormat(q.getCatalogFormat());
+    return query;
+  }
+
+  private static <V> Map<String, V> merge(
+      final Map<String, V> base, final Map<String, V> overrides) {
+    final Map<String, V> merged = new HashMap<>();
+    if (base != null) {
+      merged.putAll(base);
+    }
+    if (overrides != null) {
+      merged.putAll(overrides);
+    }
+    return merged;
+  }
+
+  private static ParameterType parameterType(
+      final Map<String, String> parameterTypes, final String name, final Object value) {
+    final String typeName = parameterTypes == null ? null : parameterTypes.get(name);
+    return ParameterType.of(typeName, value);
+  }
+
+  /**
+   * renders the value for a :name or ':name' token, an unquoted string token without a
+   * parameterTypes entry is substituted verbatim as it always was so configs that put identifiers
+   * in parameters keep working, a declared type opts in to escaping
+   */
+  static String toSql(
+      final Map<String, String> parameterTypes,
+      final String name,
+      final Object value,
+      final boolean quoted) {
+    final ParameterType type = parameterType(parameterTypes, name, value);
+    final boolean typed = parameterTypes != null && parameterTypes.get(name) != null;
+    if (!quoted && !typed && type == ParameterType.STRING) {
+      return String.valueOf(value);
+    }
+    return type.toSql(value, quoted);
+  }
+
+  /**
+   * checks every parameter value renders as its type and every timeout and SLA parses so bad
+   * values fail at startup instead of producing broken sql mid run
+   *
+   * @param queries configured queries to check
+   */
+  static void validateParameters(
+      final List<QueryConfig> queries, final Map<String, QueryGroup> queryGroups) {
+    // the pool repeats every query by its weight, each is checked once
+    for (final QueryConfig q : new LinkedHashSet<>(queries)) {
+      validateParameters(q.getParameters(), q.getParameterTypes());
+      final QueryGroup group = queryGroups.get(q.getQueryGroup());
+      try {
+        timeoutMS(q, group);
+        startAfterMS(q, group);
+        if (q.getMaxExecutions() != null && q.getMaxExecutions() < 1) {
+          throw new IllegalArgumentException("maxExecutions must be at least 1");
+        }
+        if (q.getP95Max() != null) {
+          Human.parseDurationMillis(q.getP95Max());
+        }
+        if (q.getMaxDuration() != null) {
+          Human.parseDurationMillis(q.getMaxDuration());
+        }
+      } catch (IllegalArgumentException e) {
+        throw new InvalidParameterException(e.getMessage() + " for " + describe(q));
+      }
+      if (q.getCatalog() != null) {
+        if (q.getQuery() != null || q.getQueryGroup() != null) {
+          throw new InvalidParameterException(
+              "a catalog call cannot also have a query or queryGroup: " + describe(q));
+        }
+        if (CatalogOperation.of(q.getCatalog()).needsPath()
+            && (q.getCatalogPath() == null || q.getCatalogPath().isEmpty())) {
+          throw new InvalidParameterException("catalogPath is required for " + describe(q));
+        }
+      }
+      if (group != null) {
+        for (final QueryGroupMember member : group.getQueries()) {
+          if (member.getQuery() == null || member.getQuery().trim().isEmpty()) {
+            throw new InvalidParameterException(
+                "query group members must be a sql string or an object with a query field in "
+                    + group.getName());
+          }
+          validateParameters(
+              member.getParameters(), merge(q.getParameterTypes(), member.getParameterTypes()));
+        }
+      }
+    }
+    validateDependencies(queries, queryGroups);
+  }
+
+  /**
+   * checks a stress.json without connecting: query group names and references, weights,
+   * parameter values, timeouts, SLAs, dependencies and with read-only that every statement reads
+   *
+   * @param config the parsed stress.json
+   * @param readOnly also refuse statements that write
+   * @throws InvalidParameterException describing the first problem found
+   */
+  public static void validate(final StressConfig config, final boolean readOnly) {
+    if (config.getQueries() == null || config.getQueries().isEmpty()) {
+      throw new InvalidParameterException("the config has no queries");
+    }
+    final Map<String, QueryGroup> queryGroups = new HashMap<>();
+    if (config.getQueryGroups() != null) {
+      for (final QueryGroup g : config.getQueryGroups()) {
+        if (queryGroups.put(g.getName(), g) != null) {
+          throw new InvalidParameterException("there are two query groups named " + g.getName());
+        }
+        if (g.getQueries() == null || g.getQueries().isEmpty()) {
+          throw new InvalidParameterException("query group " + g.getName() + " has no queries");
+        }
+      }
+    }
+    for (final QueryConfig q : config.getQueries()) {
+      if (q.getQueryGroup() != null
+          && !q.getQueryGroup().isEmpty()
+          && !queryGroups.containsKey(q.getQueryGroup())) {
+        throw new InvalidParameterException("unknown query group " + q.getQueryGroup());
+      }
+    }
+    new QueryPool(config.getQueries());
+    validateParameters(config.getQueries(), queryGroups);
+    if (readOnly) {
+      validateReadOnly(config.getQueries(), queryGroups, config.getSessionInit());
+    }
+  }
+
+  /**
+   * checks the workload only reads so --read-only refuses it before anything runs, a query group
+   * with a single statement that writes is refused as a whole
+   *
+   * @param queries configured queries
+   * @param queryGroups query groups by name
+   * @param sessionInit statements run once per connection, null when there are none
+   */
+  static void validateReadOnly(
+      final List<QueryConfig> queries,
+      final Map<String, QueryGroup> queryGroups,
+      final List<String> sessionInit) {
+    if (sessionInit != null) {
+      for (final String sql : sessionInit) {
+        final String write = ReadOnlyGuard.firstWrite(sql);
+        if (write != null) {
+          throw new InvalidParameterException("sessionInit writes, not read-only: " + write);
+        }
+      }
+    }
+    for (final QueryConfig q : new LinkedHashSet<>(queries)) {
+      if (q.getCatalog() != null) {
+        final CatalogOperation operation = CatalogOperation.of(q.getCatalog());
+        if (operation == CatalogOperation.PROMOTE || operation == CatalogOperation.UNPROMOTE) {
+          throw new InvalidParameterException("catalog call writes, not read-only: " + describe(q));
+        }
+        continue;
+      }
+      final QueryGroup group = queryGroups.get(q.getQueryGroup());
+      if (group != null) {
+        for (final QueryGroupMember member : group.getQueries()) {
+          final String write = ReadOnlyGuard.firstWrite(member.getQuery());
+          if (write != null) {
+            throw new InvalidParameterException(
+                String.format("query group %s writes, not read-only: %s", group.getName(), write));
+          }
+        }
+      } else if (!ReadOnlyGuard.isReadOnly(q.getQuery())) {
+        throw new InvalidParameterException("query writes, not read-only: " + describe(q));
+      }
+    }
+  }
+
+  /**
+   * checks every query group a group depends on exists and is run by a query, and that the
+   * dependencies have no cycle, as the queries waiting on them would never run
+   *
+   * @param queries configured queries
+   * @param queryGroups query groups by name
+   */
+  static void validateDependencies(
+      final List<QueryConfig> queries, final Map<String, QueryGroup> queryGroups) {
+    final Set<String> referenced = new HashSet<>();
+    for (final QueryConfig q : new LinkedHashSet<>(queries)) {
+      referenced.add(q.getQueryGroup());
+    }
+    for (final QueryGroup group : queryGroups.values()) {
+      // groups no query runs, for example left out by their labels, never wait
+      if (group.getDependsOn() == null || !referenced.contains(group.getName())) {
+        continue;
+      }
+      for (final String name : group.getDependsOn()) {
+        if (!queryGroups.containsKey(name)) {
+          throw new InvalidParameterException(
+              String.format("query group %s depends on unknown group %s", group.getName(), name));
+        }
+        if (!referenced.contains(name)) {
+          throw new InvalidParameterException(
+              String.format(
+                  "query group %s depends on %s but no query runs %s",
+                  group.getName(), name, name));
+        }
+      }
+      final Deque<String> path = new ArrayDeque<>();
+      path.push(group.getName());
+      checkCycle(group, queryGroups, path);
+    }
+  }
+
+  private static void checkCycle(
+      final QueryGroup group, final Map<String, QueryGroup> queryGroups, final Deque<String> path) {
+    if (group.getDependsOn() == null) {
+      return;
+    }
+    for (final String name : group.getDependsOn()) {
+      if (path.contains(name)) {
+        final List<String> cycle = new ArrayList<>(path);
+        Collections.reverse(cycle);
+        cycle.add(name);
+        throw new InvalidParameterException(
+            "query groups depend on each other: " + String.join(" -> ", cycle));
+      }
+      path.push(name);
+      checkCycle(queryGroups.get(name), queryGroups, path);
+      path.pop();
+    }
+  }
+
+  private static void validateParameters(
+      final Map<String, List<Object>> parameters, final Map<String, String> parameterTypes) {
+    if (parameters == null) {
+      return;
+    }
+    for (final Entry<String, List<Object>> x : parameters.entrySet()) {
+      for (final Object v : x.getValue()) {
+        parameterType(parameterTypes, x.getKey(), v).validate(v);
+      }
+    }
+  }
+
+  /**
+   * replaces the :name and ':name' tokens with ? placeholders and collects a random value for each
+   * as a bind parameter
+   */
+  private void mapPrepared(
+      final Query query,
+      final String sql,
+      final Map<String, List<Object>> parameters,
+      final Map<String, String> parameterTypes,
+      final Map<String, Object> chosen) {
+    final String[] tokens = sql.split(" ");
+    final List<Object> bindParameters = new ArrayList<>();
+    for (int i = 0; i < tokens.length; i++) {
+      final String word = tokens[i];
+      for (final Entry<String, List<Object>> x : parameters.entrySet()) {
+        final boolean quoted = word.equals("':" + x.getKey() + "'");
+        if ((quoted || word.equals(":" + x.getKey())) && !x.getValue().isEmpty()) {
+          final Object v = x.getValue().get(random.nextInt(x.getValue().size()));
+          chosen.put(x.getKey(), v);
+          final ParameterType type = parameterType(parameterTypes, x.getKey(), v);
+          bindParameters.add(quoted ? String.valueOf(v) : type.toBindValue(v));
+          tokens[i] = "?";
+        }
+      }
+    }
+    query.setQueryText(String.join(" ", tokens));
+    query.setBindParameters(bindParameters);
+  }
 }
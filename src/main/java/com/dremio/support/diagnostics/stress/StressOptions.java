@@ -0,0 +1,527 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+
+/**
+ * StressOptions holds the settings of a run for StressExec, filled from the command line. Settings
+ * left unset are disabled, except those a run cannot do without, which default as on the command
+ * line. StressExec validates them when it is created.
+ */
+public class StressOptions {
+  // the workload
+  private File jsonConfig;
+  private QueriesGeneratorFileType fileType;
+  private QueriesSequence queriesSequence = QueriesSequence.RANDOM;
+  private Integer queryIndexForRestart = -1;
+  private Integer limitResults;
+  // selection and weighting of the queries
+  private ReplayFilter replayFilter;
+  private LabelFilter labelFilter;
+  private LabelWeights labelWeights;
+  private ParameterOverrides parameterOverrides;
+  private boolean strict;
+  private Double replaySpeed;
+  private boolean replayOriginalConcurrency;
+  // connection
+  private Protocol protocol = Protocol.HTTP;
+  private String dremioHost;
+  private String dremioUser;
+  private String dremioPassword;
+  private String compareHost;
+  private String shadowHost;
+  private Double shadowPercent;
+  // concurrency and intensity
+  private Integer maxQueriesInFlight = 32;
+  private Integer sessions;
+  private Integer thinkTimeMS;
+  private boolean connectionPerWorker;
+  private Integer recycleConnectionsSeconds;
+  private Integer chaosIntervalSeconds;
+  private Double chaosPercent;
+  private Integer chaosDelayMS;
+  private Integer queueSize;
+  private Double scale;
+  private Double fuzzRate;
+  private boolean preparedStatements;
+  // timing and stopping
+  private Integer timeoutSeconds = 600;
+  private Integer warmUpSeconds;
+  private String warmUpSql = "SELECT 1";
+  private Integer durationSeconds = 600;
+  private Integer maxQueries;
+  private Double maxErrorRate;
+  private Integer printQueries;
+  private boolean hardDeadline;
+  // supervision of the workers and connections
+  private Integer watchdogFactor;
+  private boolean watchdogRestart;
+  private Integer restartAfterFailures;
+  private Integer healthCheckSeconds;
+  private Integer probeSeconds;
+  private String probeSql;
+  // outputs
+  private Integer bucketSeconds;
+  private String notifyWebhook;
+  private File resultsFile;
+  private File resultsDb;
+  private File outputDir;
+  private String uploadUri;
+  // safety and setup
+  private String provisionSpace;
+  private boolean readOnly;
+  private boolean explainOnly;
+  private boolean skipSSLVerification;
+
+  public File getJsonConfig() {
+    return jsonConfig;
+  }
+
+  public void setJsonConfig(File jsonConfig) {
+    this.jsonConfig = jsonConfig;
+  }
+
+  public QueriesGeneratorFileType getFileType() {
+    return fileType;
+  }
+
+  public void setFileType(QueriesGeneratorFileType fileType) {
+    this.fileType = fileType;
+  }
+
+  public QueriesSequence getQueriesSequence() {
+    return queriesSequence;
+  }
+
+  public void setQueriesSequence(QueriesSequence queriesSequence) {
+    this.queriesSequence = queriesSequence;
+  }
+
+  public Integer getQueryIndexForRestart() {
+    return queryIndexForRestart;
+  }
+
+  public void setQueryIndexForRestart(Integer queryIndexForRestart) {
+    this.queryIndexForRestart = queryIndexForRestart;
+  }
+
+  public Integer getLimitResults() {
+    return limitResults;
+  }
+
+  public void setLimitResults(Integer limitResults) {
+    this.limitResults = limitResults;
+  }
+
+  public ReplayFilter getReplayFilter() {
+    return replayFilter;
+  }
+
+  public void setReplayFilter(ReplayFilter replayFilter) {
+    this.replayFilter = replayFilter;
+  }
+
+  public LabelFilter getLabelFilter() {
+    return labelFilter;
+  }
+
+  public void setLabelFilter(LabelFilter labelFilter) {
+    this.labelFilter = labelFilter;
+  }
+
+  public LabelWeights getLabelWeights() {
+    return labelWeights;
+  }
+
+  public void setLabelWeights(LabelWeights labelWeights) {
+    this.labelWeights = labelWeights;
+  }
+
+  public ParameterOverrides getParameterOverrides() {
+    return parameterOverrides;
+  }
+
+  public void setParameterOverrides(ParameterOverrides parameterOverrides) {
+    this.parameterOverrides = parameterOverrides;
+  }
+
+  public boolean isStrict() {
+    return strict;
+  }
+
+  public void setStrict(boolean strict) {
+    this.strict = strict;
+  }
+
+  public Double getReplaySpeed() {
+    return replaySpeed;
+  }
+
+  public void setReplaySpeed(Double replaySpeed) {
+    this.replaySpeed = replaySpeed;
+  }
+
+  public boolean isReplayOriginalConcurrency() {
+    return replayOriginalConcurrency;
+  }
+
+  public void setReplayOriginalConcurrency(boolean replayOriginalConcurrency) {
+    this.replayOriginalConcurrency = replayOriginalConcurrency;
+  }
+
+  public Protocol getProtocol() {
+    return protocol;
+  }
+
+  public void setProtocol(Protocol protocol) {
+    this.protocol = protocol;
+  }
+
+  public String getDremioHost() {
+    return dremioHost;
+  }
+
+  public void setDremioHost(String dremioHost) {
+    this.dremioHost = dremioHost;
+  }
+
+  public String getDremioUser() {
+    return dremioUser;
+  }
+
+  public void setDremioUser(String dremioUser) {
+    this.dremioUser = dremioUser;
+  }
+
+  public String getDremioPassword() {
+    return dremioPassword;
+  }
+
+  public void setDremioPassword(String dremioPassword) {
+    this.dremioPassword = dremioPassword;
+  }
+
+  public String getCompareHost() {
+    return compareHost;
+  }
+
+  public void setCompareHost(String compareHost) {
+    this.compareHost = compareHost;
+  }
+
+  public String getShadowHost() {
+    return shadowHost;
+  }
+
+  public void setShadowHost(String shadowHost) {
+    this.shadowHost = shadowHost;
+  }
+
+  public Double getShadowPercent() {
+    return shadowPercent;
+  }
+
+  public void setShadowPercent(Double shadowPercent) {
+    this.shadowPercent = shadowPercent;
+  }
+
+  public Integer getMaxQueriesInFlight() {
+    return maxQueriesInFlight;
+  }
+
+  public void setMaxQueriesInFlight(Integer maxQueriesInFlight) {
+    this.maxQueriesInFlight = maxQueriesInFlight;
+  }
+
+  public Integer getSessions() {
+    return sessions;
+  }
+
+  public void setSessions(Integer sessions) {
+    this.sessions = sessions;
+  }
+
+  public Integer getThinkTimeMS() {
+    return thinkTimeMS;
+  }
+
+  public void setThinkTimeMS(Integer thinkTimeMS) {
+    this.thinkTimeMS = thinkTimeMS;
+  }
+
+  public boolean isConnectionPerWorker() {
+    return connectionPerWorker;
+  }
+
+  public void setConnectionPerWorker(boolean connectionPerWorker) {
+    this.connectionPerWorker = connectionPerWorker;
+  }
+
+  public Integer getRecycleConnectionsSeconds() {
+    return recycleConnectionsSeconds;
+  }
+
+  public void setRecycleConnectionsSeconds(Integer recycleConnectionsSeconds) {
+    this.recycleConnectionsSeconds = recycleConnectionsSeconds;
+  }
+
+  public Integer getChaosIntervalSeconds() {
+    return chaosIntervalSeconds;
+  }
+
+  public void setChaosIntervalSeconds(Integer chaosIntervalSeconds) {
+    this.chaosIntervalSeconds = chaosIntervalSeconds;
+  }
+
+  public Double getChaosPercent() {
+    return chaosPercent;
+  }
+
+  public void setChaosPercent(Double chaosPercent) {
+    this.chaosPercent = chaosPercent;
+  }
+
+  public Integer getChaosDelayMS() {
+    return chaosDelayMS;
+  }
+
+  public void setChaosDelayMS(Integer chaosDelayMS) {
+    this.chaosDelayMS = chaosDelayMS;
+  }
+
+  public Integer getQueueSize() {
+    return queueSize;
+  }
+
+  public void setQueueSize(Integer queueSize) {
+    this.queueSize = queueSize;
+  }
+
+  public Double getScale() {
+    return scale;
+  }
+
+  public void setScale(Double scale) {
+    this.scale = scale;
+  }
+
+  public Double getFuzzRate() {
+    return fuzzRate;
+  }
+
+  public void setFuzzRate(Double fuzzRate) {
+    this.fuzzRate = fuzzRate;
+  }
+
+  public boolean isPreparedStatements() {
+    return preparedStatements;
+  }
+
+  public void setPreparedStatements(boolean preparedStatements) {
+    this.preparedStatements = preparedStatements;
+  }
+
+  public Integer getTimeoutSeconds() {
+    return timeoutSeconds;
+  }
+
+  public void setTimeoutSeconds(Integer timeoutSeconds) {
+    this.timeoutSeconds = timeoutSeconds;
+  }
+
+  public Integer getWarmUpSeconds() {
+    return warmUpSeconds;
+  }
+
+  public void setWarmUpSeconds(Integer warmUpSeconds) {
+    this.warmUpSeconds = warmUpSeconds;
+  }
+
+  public String getWarmUpSql() {
+    return warmUpSql;
+  }
+
+  public void setWarmUpSql(String warmUpSql) {
+    this.warmUpSql = warmUpSql;
+  }
+
+  public Integer getDurationSeconds() {
+    return durationSeconds;
+  }
+
+  public void setDurationSeconds(Integer durationSeconds) {
+    this.durationSeconds = durationSeconds;
+  }
+
+  public Integer getMaxQueries() {
+    return maxQueries;
+  }
+
+  public void setMaxQueries(Integer maxQueries) {
+    this.maxQueries = maxQueries;
+  }
+
+  public Double getMaxErrorRate() {
+    return maxErrorRate;
+  }
+
+  public void setMaxErrorRate(Double maxErrorRate) {
+    this.maxErrorRate = maxErrorRate;
+  }
+
+  public Integer getPrintQueries() {
+    return printQueries;
+  }
+
+  public void setPrintQueries(Integer printQueries) {
+    this.printQueries = printQueries;
+  }
+
+  public boolean isHardDeadline() {
+    return hardDeadline;
+  }
+
+  public void setHardDeadline(boolean hardDeadline) {
+    this.hardDeadline = hardDeadline;
+  }
+
+  public Integer getWatchdogFactor() {
+    return watchdogFactor;
+  }
+
+  public void setWatchdogFactor(Integer watchdogFactor) {
+    this.watchdogFactor = watchdogFactor;
+  }
+
+  public boolean isWatchdogRestart() {
+    return watchdogRestart;
+  }
+
+  public void setWatchdogRestart(boolean watchdogRestart) {
+    this.watchdogRestart = watchdogRestart;
+  }
+
+  public Integer getRestartAfterFailures() {
+    return restartAfterFailures;
+  }
+
+  public void setRestartAfterFailures(Integer restartAfterFailures) {
+    this.restartAfterFailures = restartAfterFailures;
+  }
+
+  public Integer getHealthCheckSeconds() {
+    return healthCheckSeconds;
+  }
+
+  public void setHealthCheckSeconds(Integer healthCheckSeconds) {
+    this.healthCheckSeconds = healthCheckSeconds;
+  }
+
+  public Integer getProbeSeconds() {
+    return probeSeconds;
+  }
+
+  public void setProbeSeconds(Integer probeSeconds) {
+    this.probeSeconds = probeSeconds;
+  }
+
+  public String getProbeSql() {
+    return probeSql;
+  }
+
+  public void setProbeSql(String probeSql) {
+    this.probeSql = probeSql;
+  }
+
+  public Integer getBucketSeconds() {
+    return bucketSeconds;
+  }
+
+  public void setBucketSeconds(Integer bucketSeconds) {
+    this.bucketSeconds = bucketSeconds;
+  }
+
+  public String getNotifyWebhook() {
+    return notifyWebhook;
+  }
+
+  public void setNotifyWebhook(String notifyWebhook) {
+    this.notifyWebhook = notifyWebhook;
+  }
+
+  public File getResultsFile() {
+    return resultsFile;
+  }
+
+  public void setResultsFile(File resultsFile) {
+    this.resultsFile = resultsFile;
+  }
+
+  public File getResultsDb() {
+    return resultsDb;
+  }
+
+  public void setResultsDb(File resultsDb) {
+    this.resultsDb = resultsDb;
+  }
+
+  public File getOutputDir() {
+    return outputDir;
+  }
+
+  public void setOutputDir(File outputDir) {
+    this.outputDir = outputDir;
+  }
+
+  public String getUploadUri() {
+    return uploadUri;
+  }
+
+  public void setUploadUri(String uploadUri) {
+    this.uploadUri = uploadUri;
+  }
+
+  public String getProvisionSpace() {
+    return provisionSpace;
+  }
+
+  public void setProvisionSpace(String provisionSpace) {
+    this.provisionSpace = provisionSpace;
+  }
+
+  public boolean isReadOnly() {
+    return readOnly;
+  }
+
+  public void setReadOnly(boolean readOnly) {
+    this.readOnly = readOnly;
+  }
+
+  public boolean isExplainOnly() {
+    return explainOnly;
+  }
+
+  public void setExplainOnly(boolean explainOnly) {
+    this.explainOnly = explainOnly;
+  }
+
+  public boolean isSkipSSLVerification() {
+    return skipSSLVerification;
+  }
+
+  public void setSkipSSLVerification(boolean skipSSLVerification) {
+    this.skipSSLVerification = skipSSLVerification;
+  }
+}
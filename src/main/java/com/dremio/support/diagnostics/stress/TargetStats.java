@@ -0,0 +1,217 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.nio.ByteBuffer;
+import java.util.Arrays;
+import java.util.Base64;
+import java.util.LinkedHashMap;
+import java.util.Map;
+import java.util.concurrent.atomic.AtomicInteger;
+import java.util.zip.DataFormatException;
+import org.HdrHistogram.Histogram;
+import org.HdrHistogram.SynchronizedHistogram;
+
+/** latency and error statistics of the statements run against a single cluster */
+public class TargetStats {
+
+  private final String name;
+  private final String url;
+  private final AtomicInteger failures = new AtomicInteger(0);
+  // statements of a query group not run because an earlier step failed
+  private final AtomicInteger skipped = new AtomicInteger(0);
+  // bounded memory however long the run, percentiles are accurate to 3 significant digits
+  private final Histogram latencies = new SynchronizedHistogram(3);
+
+  /**
+   * @param name short name of the target used in reports, for example A or B
+   * @param url url of the cluster
+   */
+  public TargetStats(final String name, final String url) {
+    this.name = name;
+    this.url = url;
+  }
+
+  /**
+   * records the outcome of a statement
+   *
+   * @param successful true when the statement succeeded
+   * @param durationMS how long the statement took
+   */
+  public void record(final boolean successful, final long durationMS) {
+    if (successful) {
+      latencies.recordValue(Math.max(0, durationMS));
+    } else {
+      failures.incrementAndGet();
+    }
+  }
+
+  /** records a statement skipped because an earlier step of its query group failed */
+  public void recordSkipped() {
+    skipped.incrementAndGet();
+  }
+
+  public String getName() {
+    return name;
+  }
+
+  public String getUrl() {
+    return url;
+  }
+
+  public int getFailures() {
+    return failures.get();
+  }
+
+  public int getSkipped() {
+    return skipped.get();
+  }
+
+  public int getSuccessful() {
+    return (int) latencies.getTotalCount();
+  }
+
+  /** @return mean latency of successful statements in milliseconds, 0 when there are none */
+  public long mean() {
+    return Math.round(latencies.getMean());
+  }
+
+  /** @return latency of the slowest successful statement in milliseconds, 0 when there are none */
+  public long max() {
+    return latencies.getMaxValue();
+  }
+
+  /**
+   * percentile of the latency of successful statements
+   *
+   * @param percentile percentile between 1 and 100
+   * @return latency in milliseconds, 0 when there are no successful statements
+   */
+  public long percentile(final int percentile) {
+    return latencies.getValueAtPercentile(percentile);
+  }
+
+  /**
+   * the statistics for the results file
+   *
+   * @return map of stat name to value suitable for serializing to json
+   */
+  public Map<String, Object> toMap() {
+    final Map<String, Object> map = new LinkedHashMap<>();
+    map.put("name", name);
+    map.put("url", url);
+    map.put("successful", getSuccessful());
+    map.put("failures", getFailures());
+    map.put("skipped", getSkipped());
+    map.put("meanMS", mean());
+    map.put("p50MS", percentile(50));
+    map.put("p95MS", percentile(95));
+    map.put("p99MS", percentile(99));
+    map.put("histogram", encodedLatencies());
+    return map;
+  }
+
+  /**
+   * adds the statements of a map written by {@link #toMap()}, for example the same query run by
+   * another agent, so the percentiles are those of all the statements together
+   *
+   * @param map statistics written by toMap
+   * @throws IllegalArgumentException when the map has no latency histogram
+   */
+  public void add(final Map<String, Object> map) {
+    final Object encoded = map.get("histogram");
+    if (!(encoded instanceof String)) {
+      throw new IllegalArgumentException(
+          "no latency histogram for " + map.get("name") + ", written by an older dremio-stress");
+    }
+    final ByteBuffer buffer = ByteBuffer.wrap(Base64.getDecoder().decode((String) encoded));
+    try {
+      latencies.add(Histogram.decodeFromCompressedByteBuffer(buffer, 0));
+    } catch (DataFormatException e) {
+      throw new IllegalArgumentException("invalid latency histogram for " + map.get("name"), e);
+    }
+    failures.addAndGet(number(map.get("failures")));
+    skipped.addAndGet(number(map.get("skipped")));
+  }
+
+  private static int number(final Object value) {
+    return value instanceof Number ? ((Number) value).intValue() : 0;
+  }
+
+  /** @return the latencies of successful statements as a base64 compressed HDR histogram */
+  private String encodedLatencies() {
+    final Histogram copy = latencies.copy();
+    final ByteBuffer buffer = ByteBuffer.allocate(copy.getNeededByteBufferCapacity());
+    final int length = copy.encodeIntoCompressedByteBuffer(buffer);
+    return Base64.getEncoder().encodeToString(Arrays.copyOf(buffer.array(), length));
+  }
+
+  /**
+   * one line summary of the statistics
+   *
+   * @return the summary
+   */
+  public String summary() {
+    return String.format(
+        "%s (%s): successful: %d; failures: %d; skipped: %d; mean: %s; p95: %s; p99: %s",
+        name,
+        url,
+        getSuccessful(),
+        getFailures(),
+        getSkipped(),
+        Human.getHumanDurationFromMillis(mean()),
+        Human.getHumanDurationFromMillis(percentile(95)),
+        Human.getHumanDurationFromMillis(percentile(99)));
+  }
+
+  /**
+   * prints a side by side comparison of two targets. Deltas are relative to the first target so a
+   * positive delta means the second target is slower
+   *
+   * @param a the baseline target
+   * @param b the target compared to the baseline
+   * @return the report
+   */
+  public static String compare(final TargetStats a, final TargetStats b) {
+    final StringBuilder report = new StringBuilder();
+    report.append(String.format("A/B comparison%n"));
+    report.append(String.format("  %s: %s%n", a.getName(), a.getUrl()));
+    report.append(String.format("  %s: %s%n", b.getName(), b.getUrl()));
+    report.append(String.format("%-12s %15s %15s %10s%n", "", a.getName(), b.getName(), "delta"));
+    row(report, "successful", a.getSuccessful(), b.getSuccessful(), false);
+    row(report, "failures", a.getFailures(), b.getFailures(), false);
+    row(report, "skipped", a.getSkipped(), b.getSkipped(), false);
+    row(report, "mean", a.mean(), b.mean(), true);
+    row(report, "p50", a.percentile(50), b.percentile(50), true);
+    row(report, "p95", a.percentile(95), b.percentile(95), true);
+    row(report, "p99", a.percentile(99), b.percentile(99), true);
+    return report.toString();
+  }
+
+  private static void row(
+      final StringBuilder report,
+      final String label,
+      final long a,
+      final long b,
+      final boolean duration) {
+    final String delta = a == 0 ? "n/a" : String.format("%+.1f %%", (b - a) * 100.0 / a);
+    report.append(
+        String.format(
+            "%-12s %15s %15s %10s%n",
+            label,
+            duration ? Human.getHumanDurationFromMillis(a) : String.valueOf(a),
+            duration ? Human.getHumanDurationFromMillis(b) : String.valueOf(b),
+            delta));
+  }
+}
@@ -0,0 +1,116 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.time.Instant;
+import java.util.ArrayList;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+import org.HdrHistogram.Histogram;
+
+/**
+ * throughput, errors and latency of the run in fixed width time buckets, so charts show when the
+ * cluster started to degrade instead of only the aggregates of the whole run. Statements count in
+ * the bucket they completed in. Only the open bucket keeps a histogram, closed buckets are reduced
+ * to a row of aggregates so the memory stays small however long the run. Buckets are aligned to the
+ * wall clock so the time series of agents running at the same time line up.
+ */
+public class TimeSeries {
+
+  private final long bucketMS;
+  private final List<Map<String, Object>> rows = new ArrayList<>();
+  private final Histogram latencies = new Histogram(3);
+  private long startMS = -1;
+  private long bucket;
+  private int failures;
+
+  /** @param bucketSeconds width of a bucket in seconds */
+  public TimeSeries(final int bucketSeconds) {
+    this.bucketMS = bucketSeconds * 1000L;
+  }
+
+  /**
+   * starts the bucket of the given time, statements completed before are not recorded
+   *
+   * @param start when timing began
+   */
+  public synchronized void start(final Instant start) {
+    startMS = start.toEpochMilli() - start.toEpochMilli() % bucketMS;
+    bucket = 0;
+  }
+
+  /**
+   * records the outcome of a statement in the bucket of the current time
+   *
+   * @param successful true when the statement succeeded
+   * @param durationMS how long the statement took
+   */
+  public synchronized void record(final boolean successful, final long durationMS) {
+    if (startMS < 0) {
+      return;
+    }
+    roll(System.currentTimeMillis());
+    if (successful) {
+      latencies.recordValue(Math.max(0, durationMS));
+    } else {
+      failures++;
+    }
+  }
+
+  /**
+   * the rows of the buckets up to now, empty buckets included so gaps show on a chart
+   *
+   * @return one map per bucket suitable for serializing to json
+   */
+  public synchronized List<Map<String, Object>> toList() {
+    if (startMS < 0) {
+      return new ArrayList<>();
+    }
+    roll(System.currentTimeMillis());
+    final List<Map<String, Object>> list = new ArrayList<>(rows);
+    list.add(row());
+    return list;
+  }
+
+  /**
+   * closes the open bucket and any empty ones up to the bucket of now
+   *
+   * @param nowMS current time in epoch millis
+   */
+  private void roll(final long nowMS) {
+    final long current = Math.max(0, (nowMS - startMS) / bucketMS);
+    while (bucket < current) {
+      rows.add(row());
+      latencies.reset();
+      failures = 0;
+      bucket++;
+    }
+  }
+
+  private Map<String, Object> row() {
+    final Map<String, Object> row = new LinkedHashMap<>();
+    row.put("offsetSeconds", bucket * bucketMS / 1000);
+    row.put("start", Instant.ofEpochMilli(startMS + bucket * bucketMS).toString());
+    row.put("successful", latencies.getTotalCount());
+    row.put("failures", failures);
+    row.put("qps", (latencies.getTotalCount() + failures) * 1000.0 / bucketMS);
+    row.put("meanMS", Math.round(latencies.getMean()));
+    row.put("p50MS", latencies.getValueAtPercentile(50));
+    row.put("p95MS", latencies.getValueAtPercentile(95));
+    row.put("p99MS", latencies.getValueAtPercentile(99));
+    row.put("maxMS", latencies.getMaxValue());
+    return row;
+  }
+}
@@ -0,0 +1,62 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+/**
+ * TokenBucket caps the rate of a session, tokens refill at a steady rate up to the burst size and
+ * every query takes one.
+ */
+final class TokenBucket {
+  private final double tokensPerNano;
+  private final double capacity;
+  private double tokens;
+  private long refilledAtNanos;
+
+  /**
+   * @param perMinute tokens added per minute
+   * @param burst most tokens the bucket holds, the bucket starts full
+   */
+  TokenBucket(final double perMinute, final int burst) {
+    this.tokensPerNano = perMinute / 60_000_000_000.0;
+    this.capacity = burst;
+    this.tokens = burst;
+    this.refilledAtNanos = System.nanoTime();
+  }
+
+  /** @return tokens added per second */
+  double perSecond() {
+    return tokensPerNano * 1_000_000_000.0;
+  }
+
+  private void refill() {
+    final long now = System.nanoTime();
+    tokens = Math.min(capacity, tokens + (now - refilledAtNanos) * tokensPerNano);
+    refilledAtNanos = now;
+  }
+
+  /** takes a token, callers wait for {@link #nanosUntilAvailable()} first */
+  synchronized void take() {
+    refill();
+    tokens -= 1;
+  }
+
+  /** @return nanoseconds until a token is available, 0 when one is available now */
+  synchronized long nanosUntilAvailable() {
+    refill();
+    if (tokens >= 1) {
+      return 0;
+    }
+    return (long) Math.ceil((1 - tokens) / tokensPerNano);
+  }
+}
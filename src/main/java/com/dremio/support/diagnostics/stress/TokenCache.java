@@ -0,0 +1,180 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.IOException;
+import java.time.Instant;
+import java.util.Map;
+import java.util.concurrent.ConcurrentHashMap;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.concurrent.TimeUnit;
+import java.util.concurrent.atomic.AtomicBoolean;
+import java.util.concurrent.atomic.AtomicLong;
+import java.util.logging.Level;
+import java.util.logging.Logger;
+
+/**
+ * TokenCache shares the login of a url and user between every HTTP connection of a run. Hundreds of
+ * simulated sessions or recycled connections would otherwise each log in, a stampede on
+ * /apiv2/login at startup that slows the coordinator before the first query runs. The first
+ * connection logs in while the others wait for its token, and once most of the lifetime of a
+ * token has passed it is replaced in the background while the connections keep using the old one,
+ * so no statement waits for a login.
+ */
+public class TokenCache {
+  private static final Logger logger = Logger.getLogger(TokenCache.class.getName());
+  // Dremio keeps a login valid for 30 hours unless token.expiration.min is changed
+  private static final long defaultLifetimeMS = TimeUnit.HOURS.toMillis(30);
+  // share of the lifetime after which the token is refreshed ahead of its expiry
+  private static final double refreshAt = 0.8;
+
+  private final Map<String, Entry> entries = new ConcurrentHashMap<>();
+  private final AtomicLong logins = new AtomicLong(0);
+  private final AtomicLong reused = new AtomicLong(0);
+  private final ExecutorService refresher =
+      Executors.newSingleThreadExecutor(
+          r -> {
+            final Thread thread = new Thread(r, "token-refresh");
+            thread.setDaemon(true);
+            return thread;
+          });
+
+  /** logs in and returns the token */
+  public interface Login {
+    /**
+     * @return the token
+     * @throws IOException when the login fails
+     */
+    Token login() throws IOException;
+  }
+
+  /** the Authorization header of a login and when it expires */
+  public static final class Token {
+    private final String authorization;
+    private final long issuedMS;
+    private final long expiresMS;
+
+    /**
+     * @param authorization value of the Authorization header
+     * @param expiresMS epoch milliseconds the token expires at, null when the server did not say
+     */
+    public Token(final String authorization, final Long expiresMS) {
+      this.authorization = authorization;
+      this.issuedMS = System.currentTimeMillis();
+      this.expiresMS = expiresMS != null ? expiresMS : issuedMS + defaultLifetimeMS;
+    }
+
+    /** @return value of the Authorization header */
+    public String getAuthorization() {
+      return authorization;
+    }
+
+    private long refreshMS() {
+      return issuedMS + (long) ((expiresMS - issuedMS) * refreshAt);
+    }
+  }
+
+  /**
+   * the Authorization header for a url and user, logging in when there is no valid token yet
+   *
+   * @param key url, user and kind of login the token is for
+   * @param login logs in, called by one connection at a time
+   * @return the value of the Authorization header
+   * @throws IOException when the login fails
+   */
+  public String authorization(final String key, final Login login) throws IOException {
+    return entries.computeIfAbsent(key, Entry::new).get(login);
+  }
+
+  /**
+   * drops a token the server refused so the next request logs in again
+   *
+   * @param key url, user and kind of login the token is for
+   * @param authorization the refused Authorization header, a newer token is kept
+   */
+  public void invalidate(final String key, final String authorization) {
+    final Entry entry = entries.get(key);
+    if (entry != null) {
+      entry.invalidate(authorization);
+    }
+  }
+
+  /** @return the number of logins made and of requests that used a cached token */
+  public String summary() {
+    return String.format(
+        "cached logins: %d logins for %d url and user pairs, %d requests used a cached token",
+        logins.get(), entries.size(), reused.get());
+  }
+
+  private final class Entry {
+    private final String key;
+    private final AtomicBoolean refreshing = new AtomicBoolean(false);
+    private volatile Token token;
+
+    private Entry(final String key) {
+      this.key = key;
+    }
+
+    private String get(final Login login) throws IOException {
+      Token current = token;
+      if (current == null || System.currentTimeMillis() >= current.expiresMS) {
+        synchronized (this) {
+          current = token;
+          if (current == null || System.currentTimeMillis() >= current.expiresMS) {
+            current = login(login);
+          }
+        }
+        return current.authorization;
+      }
+      reused.incrementAndGet();
+      if (System.currentTimeMillis() >= current.refreshMS()
+          && refreshing.compareAndSet(false, true)) {
+        refresher.execute(
+            () -> {
+              try {
+                synchronized (this) {
+                  login(login);
+                }
+              } catch (IOException | RuntimeException e) {
+                // the current token stays in use until it expires
+                logger.log(Level.WARNING, "unable to refresh the login of " + key, e);
+              } finally {
+                refreshing.set(false);
+              }
+            });
+      }
+      return current.authorization;
+    }
+
+    private Token login(final Login login) throws IOException {
+      final Token fresh = login.login();
+      token = fresh;
+      logins.incrementAndGet();
+      logger.info(
+          () ->
+              String.format(
+                  "logged in to %s, token valid until %s",
+                  key,
+                  Instant.ofEpochMilli(fresh.expiresMS)));
+      return fresh;
+    }
+
+    private synchronized void invalidate(final String authorization) {
+      if (token != null && token.authorization.equals(authorization)) {
+        token = null;
+      }
+    }
+  }
+}
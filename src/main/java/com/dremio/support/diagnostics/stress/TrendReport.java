@@ -0,0 +1,199 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.core.type.TypeReference;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import java.io.File;
+import java.io.IOException;
+import java.io.PrintStream;
+import java.security.InvalidParameterException;
+import java.sql.Connection;
+import java.sql.DriverManager;
+import java.sql.ResultSet;
+import java.sql.SQLException;
+import java.sql.Statement;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.Comparator;
+import java.util.List;
+import java.util.Map;
+import java.util.TreeMap;
+
+/**
+ * TrendReport prints the latency of every query across historical runs and flags regressions. A
+ * query has regressed when its latest run is more than the threshold number of standard deviations
+ * slower than the mean of its earlier runs, so normal run to run noise is not reported.
+ */
+public class TrendReport {
+
+  /** a query in a single run */
+  static final class Point {
+    private final String start;
+    private final String runId;
+    private final long value;
+    private final long failures;
+
+    Point(final String start, final String runId, final long value, final long failures) {
+      this.start = start;
+      this.runId = runId;
+      this.value = value;
+      this.failures = failures;
+    }
+  }
+
+  // query label to its points ordered by run start
+  private final Map<String, List<Point>> trends = new TreeMap<>();
+  private final String metric;
+  private final double threshold;
+  private final int minRuns;
+
+  /**
+   * @param metric column of the results to trend, one of meanMS, p50MS, p95MS or p99MS
+   * @param threshold standard deviations above the baseline mean that count as a regression
+   * @param minRuns earlier runs needed before regressions are flagged
+   */
+  public TrendReport(final String metric, final double threshold, final int minRuns) {
+    if (!Arrays.asList("meanMS", "p50MS", "p95MS", "p99MS").contains(metric)) {
+      throw new InvalidParameterException(
+          String.format("metric '%s' must be one of meanMS, p50MS, p95MS or p99MS", metric));
+    }
+    this.metric = metric;
+    this.threshold = threshold;
+    this.minRuns = minRuns;
+  }
+
+  /**
+   * loads every run from a results store
+   *
+   * @param db sqlite file written with --results-db
+   * @throws SQLException when unable to read the store
+   */
+  public void loadStore(final File db) throws SQLException {
+    if (!db.exists()) {
+      throw new SQLException("results store " + db + " does not exist");
+    }
+    final String column = metric.replace("MS", "_ms");
+    try (Connection connection = DriverManager.getConnection("jdbc:sqlite:" + db.getPath());
+        Statement statement = connection.createStatement();
+        ResultSet rows =
+            statement.executeQuery(
+                "SELECT r.start_time, r.run_id, q.label, q."
+                    + column
+                    + ", q.failures FROM run_queries q JOIN runs r ON r.run_id = q.run_id")) {
+      while (rows.next()) {
+        final Point point =
+            new Point(rows.getString(1), rows.getString(2), rows.getLong(4), rows.getLong(5));
+        add(rows.getString(3), point);
+      }
+    }
+  }
+
+  /**
+   * loads every results file of a directory
+   *
+   * @param dir directory of files written with --results-file
+   * @throws IOException when unable to read a file
+   */
+  @SuppressWarnings("unchecked")
+  public void loadDirectory(final File dir) throws IOException {
+    final File[] files = dir.listFiles((d, name) -> name.endsWith(".json"));
+    if (files == null) {
+      throw new IOException(dir + " is not a directory");
+    }
+    final ObjectMapper mapper = new ObjectMapper();
+    for (final File file : files) {
+      final Map<String, Object> results =
+          mapper.readValue(file, new TypeReference<Map<String, Object>>() {});
+      final Object queries = results.get("queries");
+      if (!(queries instanceof List)) {
+        continue;
+      }
+      for (final Map<String, Object> query : (List<Map<String, Object>>) queries) {
+        add(
+            String.valueOf(query.get("name")),
+            new Point(
+                String.valueOf(results.get("start")),
+                String.valueOf(results.get("runId")),
+                number(query.get(metric)),
+                number(query.get("failures"))));
+      }
+    }
+  }
+
+  private void add(final String label, final Point point) {
+    trends.computeIfAbsent(label, k -> new ArrayList<>()).add(point);
+  }
+
+  /**
+   * prints the trend of every query
+   *
+   * @param out where to print the report
+   * @return number of queries that regressed
+   */
+  public int print(final PrintStream out) {
+    int regressions = 0;
+    if (trends.isEmpty()) {
+      out.println("no runs found");
+      return 0;
+    }
+    for (final Map.Entry<String, List<Point>> trend : trends.entrySet()) {
+      final List<Point> points = trend.getValue();
+      points.sort(Comparator.comparing(p -> p.start));
+      out.printf("%s%n", trend.getKey());
+      for (final Point point : points) {
+        out.printf(
+            "  %s  %s  %s %s  failures %d%n",
+            point.start,
+            point.runId,
+            metric,
+            Human.getHumanDurationFromMillis(point.value),
+            point.failures);
+      }
+      if (points.size() <= minRuns) {
+        out.printf("  not enough runs to detect regressions, need more than %d%n", minRuns);
+        continue;
+      }
+      final List<Point> baseline = points.subList(0, points.size() - 1);
+      final Point latest = points.get(points.size() - 1);
+      double mean = 0;
+      for (final Point point : baseline) {
+        mean += point.value;
+      }
+      mean /= baseline.size();
+      double variance = 0;
+      for (final Point point : baseline) {
+        variance += (point.value - mean) * (point.value - mean);
+      }
+      final double stddev = Math.sqrt(variance / (baseline.size() - 1));
+      final boolean regressed =
+          stddev == 0 ? latest.value > mean : (latest.value - mean) / stddev > threshold;
+      if (regressed) {
+        regressions++;
+      }
+      out.printf(
+          "  latest %s vs baseline %s +/- %s%s%n",
+          Human.getHumanDurationFromMillis(latest.value),
+          Human.getHumanDurationFromMillis((long) mean),
+          Human.getHumanDurationFromMillis((long) stddev),
+          regressed ? "  REGRESSION" : "");
+    }
+    out.printf("%d of %d queries regressed%n", regressions, trends.size());
+    return regressions;
+  }
+
+  private static long number(final Object value) {
+    return value instanceof Number ? ((Number) value).longValue() : 0;
+  }
+}
@@ -0,0 +1,61 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+/**
+ * a kind of simulated user with its own rate cap, so one power user can spam while the others
+ * trickle. Only used when sessions are simulated.
+ */
+public class UserRate {
+  private String name;
+  private Integer sessions;
+  private Double queriesPerMinute;
+  private Integer burst;
+
+  /** @return name shown in the logs */
+  public String getName() {
+    return name;
+  }
+
+  public void setName(String name) {
+    this.name = name;
+  }
+
+  /** @return how many of the simulated sessions are this user, defaults to 1 */
+  public Integer getSessions() {
+    return sessions;
+  }
+
+  public void setSessions(Integer sessions) {
+    this.sessions = sessions;
+  }
+
+  /** @return queries (or query group iterations) each session of this user may start per minute */
+  public Double getQueriesPerMinute() {
+    return queriesPerMinute;
+  }
+
+  public void setQueriesPerMinute(Double queriesPerMinute) {
+    this.queriesPerMinute = queriesPerMinute;
+  }
+
+  /** @return how many queries a session may start at once after being idle, defaults to 1 */
+  public Integer getBurst() {
+    return burst;
+  }
+
+  public void setBurst(Integer burst) {
+    this.burst = burst;
+  }
+}
@@ -13,6 +13,13 @@
  */
 package com.dremio.support.diagnostics.stress;
 
+import com.fasterxml.jackson.core.JsonProcessingException;
+import com.fasterxml.jackson.databind.ObjectMapper;
+import java.nio.charset.StandardCharsets;
+import java.util.Base64;
+import java.util.LinkedHashMap;
+import java.util.Map;
+
 /** Username Password wraps the username and password */
 public class UsernamePasswordAuth {
   public String getUsername() {
@@ -29,7 +36,41 @@ public class UsernamePasswordAuth {
   /** generates json string for the rest api authentication */
   @Override
   public String toString() {
-    return String.format("{\"userName\":\"%s\",\"password\":\"%s\"}", username, password);
+    final Map<String, String> body = new LinkedHashMap<>();
+    body.put("userName", username);
+    body.put("password", password);
+    try {
+      // escaped as users like DOMAIN\user and passwords with quotes are otherwise invalid json
+      return new ObjectMapper().writeValueAsString(body);
+    } catch (JsonProcessingException e) {
+      throw new RuntimeException(e);
+    }
+  }
+
+  /**
+   * qualifies a user with a directory domain for coordinators that log in against LDAP or active
+   * directory. A domain with a dot gives the user principal name user@domain, otherwise the
+   * down-level name DOMAIN\user. Users that already have a domain are kept as they are.
+   *
+   * @param username the user as given
+   * @param domain the domain, null keeps the user as it is
+   * @return the qualified user
+   */
+  public static String qualify(final String username, final String domain) {
+    if (username == null || domain == null || username.contains("@") || username.contains("\\")) {
+      return username;
+    }
+    if (domain.contains(".")) {
+      return username + "@" + domain;
+    }
+    return domain + "\\" + username;
+  }
+
+  /** @return the value of an Authorization header for HTTP basic auth */
+  public String toBasicHeader() {
+    final String credentials = username + ":" + password;
+    return "Basic "
+        + Base64.getEncoder().encodeToString(credentials.getBytes(StandardCharsets.UTF_8));
   }
 
   /**
@@ -0,0 +1,89 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.File;
+import java.io.IOException;
+import java.net.URL;
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.util.HashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * VaultCredentialProvider reads the password from a HashiCorp Vault secret, given as PATH#FIELD
+ * such as secret/data/dremio#password, field defaults to password. Both the KV version 1 and 2
+ * secret engines are read. The address comes from VAULT_ADDR and the token from VAULT_TOKEN or the
+ * ~/.vault-token file the vault login writes, as with the vault cli.
+ */
+public class VaultCredentialProvider implements CredentialProvider {
+
+  private final String path;
+  private final String field;
+
+  /** @param secret PATH#FIELD of the secret */
+  public VaultCredentialProvider(final String secret) {
+    final int hash = secret.lastIndexOf('#');
+    this.path = hash < 0 ? secret : secret.substring(0, hash);
+    this.field = hash < 0 ? "password" : secret.substring(hash + 1);
+  }
+
+  @Override
+  public String password(final String user) throws IOException {
+    final String address = System.getenv("VAULT_ADDR");
+    if (address == null) {
+      throw new IOException("VAULT_ADDR is not set");
+    }
+    final Map<String, String> headers = new HashMap<>();
+    headers.put("X-Vault-Token", token());
+    if (System.getenv("VAULT_NAMESPACE") != null) {
+      headers.put("X-Vault-Namespace", System.getenv("VAULT_NAMESPACE"));
+    }
+    final URL url = new URL(address.replaceAll("/+$", "") + "/v1/" + path.replaceAll("^/+", ""));
+    final HttpApiResponse response = new HttpApiCall(false).submitGet(url, headers);
+    if (response.getResponse() == null) {
+      throw new IOException(
+          String.format(
+              "unable to read vault secret %s: %d %s",
+              path,
+              response.getResponseCode(),
+              response.getMessage()));
+    }
+    Object data = response.getResponse().get("data");
+    // kv version 2 nests the secret in data.data
+    if (data instanceof Map && ((Map<?, ?>) data).get("data") instanceof Map) {
+      data = ((Map<?, ?>) data).get("data");
+    }
+    if (!(data instanceof Map) || ((Map<?, ?>) data).get(field) == null) {
+      throw new IOException(String.format("vault secret %s has no field %s", path, field));
+    }
+    return String.valueOf(((Map<?, ?>) data).get(field));
+  }
+
+  private static String token() throws IOException {
+    final String token = System.getenv("VAULT_TOKEN");
+    if (token != null) {
+      return token;
+    }
+    final File tokenFile = new File(System.getProperty("user.home"), ".vault-token");
+    if (tokenFile.isFile()) {
+      final List<String> lines = Files.readAllLines(tokenFile.toPath(), StandardCharsets.UTF_8);
+      if (!lines.isEmpty()) {
+        return lines.get(0).trim();
+      }
+    }
+    throw new IOException("VAULT_TOKEN is not set and there is no ~/.vault-token, run vault login");
+  }
+}
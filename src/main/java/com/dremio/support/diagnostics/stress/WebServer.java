@@ -0,0 +1,146 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import com.fasterxml.jackson.databind.ObjectMapper;
+import com.sun.net.httpserver.HttpExchange;
+import com.sun.net.httpserver.HttpServer;
+import java.io.ByteArrayOutputStream;
+import java.io.IOException;
+import java.io.InputStream;
+import java.io.OutputStream;
+import java.nio.charset.StandardCharsets;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.logging.Logger;
+
+/**
+ * WebServer serves a page with a live chart of the throughput and latency of the run and a button
+ * to stop it, so a run started on a shared host can be watched from a browser.
+ *
+ * <ul>
+ *   <li>GET / - the page
+ *   <li>GET /events - server sent events with the status of the run every 2 seconds
+ *   <li>POST /stop - end the run and print the summary
+ * </ul>
+ */
+public class WebServer {
+
+  private static final Logger logger = Logger.getLogger(WebServer.class.getName());
+
+  private final HttpServer server;
+  private final StressControl control;
+  private final ExecutorService executor;
+  private volatile boolean running = true;
+
+  /**
+   * @param address host:port to listen on
+   * @param control the run to watch
+   * @throws IOException when unable to bind to the address
+   */
+  public WebServer(final String address, final StressControl control) throws IOException {
+    this.control = control;
+    this.server = HttpServer.create(ControlServer.parseAddress(address), 0);
+    // every open page holds a thread for its event stream
+    this.executor =
+        Executors.newCachedThreadPool(
+            r -> {
+              final Thread thread = new Thread(r, "web");
+              thread.setDaemon(true);
+              return thread;
+            });
+    this.server.setExecutor(executor);
+    this.server.createContext("/", this::handlePage);
+    this.server.createContext("/events", this::handleEvents);
+    this.server.createContext("/stop", this::handleStop);
+  }
+
+  /** starts listening in the background */
+  public void start() {
+    server.start();
+    logger.info(() -> String.format("web ui listening on http://%s/", server.getAddress()));
+  }
+
+  /** stops listening and closes the open event streams */
+  public void stop() {
+    running = false;
+    server.stop(0);
+    executor.shutdownNow();
+  }
+
+  private void handlePage(final HttpExchange exchange) throws IOException {
+    if (!"/".equals(exchange.getRequestURI().getPath())) {
+      respond(exchange, 404, "text/plain", "not found");
+      return;
+    }
+    respond(exchange, 200, "text/html; charset=utf-8", page());
+  }
+
+  private void handleStop(final HttpExchange exchange) throws IOException {
+    if (!"POST".equals(exchange.getRequestMethod())) {
+      respond(exchange, 405, "text/plain", "POST required");
+      return;
+    }
+    control.stop();
+    final String body = new ObjectMapper().writeValueAsString(control.status());
+    respond(exchange, 200, "application/json", body);
+  }
+
+  private void handleEvents(final HttpExchange exchange) throws IOException {
+    exchange.getResponseHeaders().add("Content-Type", "text/event-stream");
+    exchange.getResponseHeaders().add("Cache-Control", "no-cache");
+    // a length of 0 streams the body until it is closed
+    exchange.sendResponseHeaders(200, 0);
+    final ObjectMapper mapper = new ObjectMapper();
+    try (OutputStream stream = exchange.getResponseBody()) {
+      while (running) {
+        final String event = "data: " + mapper.writeValueAsString(control.status()) + "\n\n";
+        stream.write(event.getBytes(StandardCharsets.UTF_8));
+        stream.flush();
+        Thread.sleep(2 * 1000);
+      }
+    } catch (IOException e) {
+      // the browser went away
+      logger.fine(() -> "event stream closed " + e.getMessage());
+    } catch (InterruptedException e) {
+      Thread.currentThread().interrupt();
+    }
+  }
+
+  private static String page() throws IOException {
+    try (InputStream in = WebServer.class.getResourceAsStream("/web/index.html")) {
+      if (in == null) {
+        throw new IOException("web/index.html is missing from the jar");
+      }
+      final ByteArrayOutputStream out = new ByteArrayOutputStream();
+      final byte[] buffer = new byte[8192];
+      int read;
+      while ((read = in.read(buffer)) != -1) {
+        out.write(buffer, 0, read);
+      }
+      return new String(out.toByteArray(), StandardCharsets.UTF_8);
+    }
+  }
+
+  private void respond(
+      final HttpExchange exchange, final int code, final String contentType, final String body)
+      throws IOException {
+    final byte[] bytes = body.getBytes(StandardCharsets.UTF_8);
+    exchange.getResponseHeaders().add("Content-Type", contentType);
+    exchange.sendResponseHeaders(code, bytes.length);
+    try (OutputStream stream = exchange.getResponseBody()) {
+      stream.write(bytes);
+    }
+  }
+}
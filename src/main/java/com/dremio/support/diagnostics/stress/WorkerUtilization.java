@@ -0,0 +1,138 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.util.LinkedHashMap;
+import java.util.Map;
+import java.util.TreeMap;
+import java.util.concurrent.ConcurrentHashMap;
+
+/**
+ * busy and idle time of every worker. Workers that are busy nearly all the time limit the run, so
+ * more concurrency would submit more, while idle workers mean the submission (pacing, think time,
+ * the generator itself) limits the run and more workers would only idle too.
+ */
+public class WorkerUtilization {
+
+  // at or above this mean utilization the workers are considered saturated
+  private static final double SATURATED_PERCENT = 90.0;
+
+  private final Map<String, Worker> workers = new ConcurrentHashMap<>();
+  private volatile long startNanos = System.nanoTime();
+
+  /** busy time of a single worker */
+  private static final class Worker {
+    private long busyNanos;
+    private long busySince;
+  }
+
+  /** starts measuring, time before the run started counts neither as busy nor idle */
+  public void start() {
+    startNanos = System.nanoTime();
+  }
+
+  /**
+   * a worker starts a task
+   *
+   * @param thread the worker
+   */
+  public void begin(final Thread thread) {
+    final Worker worker = workers.computeIfAbsent(thread.getName(), k -> new Worker());
+    synchronized (worker) {
+      worker.busySince = System.nanoTime();
+    }
+  }
+
+  /**
+   * a worker finished its task
+   *
+   * @param thread the worker
+   */
+  public void end(final Thread thread) {
+    final Worker worker = workers.get(thread.getName());
+    if (worker == null) {
+      return;
+    }
+    synchronized (worker) {
+      if (worker.busySince != 0) {
+        worker.busyNanos += System.nanoTime() - Math.max(worker.busySince, startNanos);
+        worker.busySince = 0;
+      }
+    }
+  }
+
+  /** @return percent of the run each worker was busy, by worker name */
+  private Map<String, Double> percents() {
+    final long now = System.nanoTime();
+    final long elapsed = Math.max(now - startNanos, 1);
+    final Map<String, Double> percents = new LinkedHashMap<>();
+    for (final Map.Entry<String, Worker> entry : new TreeMap<>(workers).entrySet()) {
+      final Worker worker = entry.getValue();
+      synchronized (worker) {
+        long busy = worker.busyNanos;
+        if (worker.busySince != 0) {
+          busy += now - Math.max(worker.busySince, startNanos);
+        }
+        percents.put(entry.getKey(), Math.min(busy * 100.0 / elapsed, 100.0));
+      }
+    }
+    return percents;
+  }
+
+  /**
+   * utilization for the results file
+   *
+   * @return map suitable for serializing to json
+   */
+  public Map<String, Object> toMap() {
+    final Map<String, Double> percents = percents();
+    final Map<String, Object> map = new LinkedHashMap<>();
+    map.put("workers", percents.size());
+    map.put("meanPercent", mean(percents));
+    map.put(
+        "minPercent", percents.values().stream().mapToDouble(Double::doubleValue).min().orElse(0));
+    map.put(
+        "maxPercent", percents.values().stream().mapToDouble(Double::doubleValue).max().orElse(0));
+    map.put("busyPercentByWorker", percents);
+    return map;
+  }
+
+  /**
+   * one line summary of the utilization with what it means for the concurrency
+   *
+   * @return the summary
+   */
+  public String summary() {
+    final Map<String, Double> percents = percents();
+    if (percents.isEmpty()) {
+      return "worker utilization: no worker ran a statement";
+    }
+    final double mean = mean(percents);
+    return String.format(
+        "worker utilization: %d workers busy %.1f %% of the time on average (min %.1f %%, max %.1f"
+            + " %%) - %s",
+        percents.size(),
+        mean,
+        percents.values().stream().mapToDouble(Double::doubleValue).min().orElse(0),
+        percents.values().stream().mapToDouble(Double::doubleValue).max().orElse(0),
+        mean >= SATURATED_PERCENT
+            ? "the workers are saturated, more concurrency would submit more if the cluster keeps"
+                + " up"
+            : "the workers are waiting on the submission, more concurrency would not help");
+  }
+
+  private static double mean(final Map<String, Double> percents) {
+    return percents.values().stream().mapToDouble(Double::doubleValue).average().orElse(0);
+  }
+}
@@ -0,0 +1,157 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import java.io.PrintStream;
+import java.util.ArrayList;
+import java.util.Collections;
+import java.util.LinkedHashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * WorkloadDescription prints what a stress.json will run without connecting to dremio: every
+ * query with its weight, expected share of the traffic and parameter cardinalities, and the size of
+ * every query group, so a workload can be sanity checked when it is reviewed.
+ */
+public class WorkloadDescription {
+
+  // width of the share bars
+  private static final int barWidth = 30;
+
+  private final StressConfig config;
+
+  /** @param config the parsed stress.json */
+  public WorkloadDescription(final StressConfig config) {
+    this.config = config;
+  }
+
+  /**
+   * prints the queries, the query groups and the totals
+   *
+   * @param out where to print
+   */
+  public void print(final PrintStream out) {
+    final List<QueryConfig> queries =
+        config.getQueries() == null ? Collections.emptyList() : config.getQueries();
+    final Map<String, QueryGroup> groups = new LinkedHashMap<>();
+    if (config.getQueryGroups() != null) {
+      for (final QueryGroup group : config.getQueryGroups()) {
+        groups.put(group.getName(), group);
+      }
+    }
+    double totalWeight = 0;
+    for (final QueryConfig q : queries) {
+      totalWeight += QueryPool.weightOf(q);
+    }
+    out.printf("%-8s %9s %-" + barWidth + "s %5s  %s%n", "share", "weight", "", "stmts", "query");
+    for (final QueryConfig q : queries) {
+      final double weight = QueryPool.weightOf(q);
+      final double share = totalWeight == 0 ? 0 : weight / totalWeight;
+      out.printf(
+          "%7.2f%% %9s %-" + barWidth + "s %5d  %s%n",
+          share * 100.0,
+          QueryPool.format(weight),
+          bar(share),
+          statements(q, groups),
+          StressExec.describe(q) + StressExec.describeSchedule(q, groups.get(q.getQueryGroup())));
+      final String cardinalities = cardinalities(q.getParameters());
+      if (!cardinalities.isEmpty()) {
+        out.printf("%" + (8 + 1 + 9 + 1 + barWidth + 1 + 5 + 2) + "s%s%n", "", cardinalities);
+      }
+    }
+    if (!groups.isEmpty()) {
+      out.printf("%nquery groups:%n");
+      for (final QueryGroup group : groups.values()) {
+        final int steps = group.getQueries() == null ? 0 : group.getQueries().size();
+        final List<String> traits = new ArrayList<>();
+        traits.add(steps + " steps");
+        if (group.getWorkers() > 0) {
+          traits.add(group.getWorkers() + " dedicated workers");
+        }
+        if (group.isDedicatedConnection()) {
+          traits.add("dedicated connection");
+        }
+        if (group.getTimeout() != null) {
+          traits.add("timeout " + group.getTimeout());
+        }
+        if (group.getStartAfter() != null) {
+          traits.add("starts after " + group.getStartAfter());
+        }
+        if (group.getDependsOn() != null && !group.getDependsOn().isEmpty()) {
+          traits.add("depends on " + String.join(", ", group.getDependsOn()));
+        }
+        final long referenced =
+            queries.stream().filter(q -> group.getName().equals(q.getQueryGroup())).count();
+        if (referenced == 0) {
+          traits.add("not referenced by any query");
+        }
+        out.printf("  %s: %s%n", group.getName(), String.join(", ", traits));
+        if (group.getQueries() != null) {
+          for (final QueryGroupMember member : group.getQueries()) {
+            final String cardinalities = cardinalities(member.getParameters());
+            out.printf(
+                "    - %s%s%n",
+                shorten(member.getQuery()),
+                cardinalities.isEmpty() ? "" : " (" + cardinalities + ")");
+          }
+        }
+      }
+    }
+    out.printf(
+        "%n%d queries, %d query groups, total weight %s%n",
+        queries.size(), groups.size(), QueryPool.format(totalWeight));
+  }
+
+  private static String bar(final double share) {
+    final StringBuilder bar = new StringBuilder();
+    // every query with a share gets at least one mark
+    final int marks = share > 0 ? Math.max((int) Math.round(share * barWidth), 1) : 0;
+    for (int i = 0; i < marks; i++) {
+      bar.append('#');
+    }
+    return bar.toString();
+  }
+
+  private static int statements(final QueryConfig q, final Map<String, QueryGroup> groups) {
+    final QueryGroup group = groups.get(q.getQueryGroup());
+    if (group != null && group.getQueries() != null) {
+      return group.getQueries().size();
+    }
+    return q.getQuery() == null ? 1 : StatementSplitter.split(q.getQuery()).size();
+  }
+
+  /**
+   * @param parameters parameter name to its values
+   * @return name=number of values for every parameter and the number of combinations
+   */
+  private static String cardinalities(final Map<String, List<Object>> parameters) {
+    if (parameters == null || parameters.isEmpty()) {
+      return "";
+    }
+    final List<String> parts = new ArrayList<>();
+    long combinations = 1;
+    for (final Map.Entry<String, List<Object>> parameter : parameters.entrySet()) {
+      final int values = parameter.getValue() == null ? 0 : parameter.getValue().size();
+      parts.add(parameter.getKey() + "=" + values);
+      combinations *= Math.max(values, 1);
+    }
+    return String.format("parameters %s, %d combinations", String.join(" ", parts), combinations);
+  }
+
+  private static String shorten(final String sql) {
+    final String text = String.valueOf(sql).replaceAll("\\s+", " ").trim();
+    return text.length() > 80 ? text.substring(0, 77) + "..." : text;
+  }
+}
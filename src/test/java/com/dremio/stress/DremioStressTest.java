@@ -0,0 +1,56 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.stress;
+
+import static org.junit.Assert.assertEquals;
+import static org.junit.Assert.assertNotNull;
+
+import java.util.Collections;
+import org.junit.Test;
+import picocli.CommandLine;
+
+public class DremioStressTest {
+
+  @Test
+  public void runOptionsFollowTheSubcommand() {
+    final CommandLine.ParseResult parsed =
+        new CommandLine(new DremioStress())
+            .parseArgs("-l", "http://localhost:9047", "run", "-q", "4", "stress.json");
+    final CommandLine.ParseResult run = parsed.subcommand();
+    assertEquals("run", run.commandSpec().name());
+    assertEquals(Integer.valueOf(4), run.matchedOptionValue("-q", 0));
+  }
+
+  @Test
+  public void refusesRunOptionsBeforeTheSubcommand() {
+    final int rc =
+        new CommandLine(new DremioStress())
+            .execute("-l", "http://localhost:9047", "-q", "4", "run", "stress.json");
+    assertEquals(CommandLine.ExitCode.USAGE, rc);
+  }
+
+  @Test
+  public void sharedRunOptionsReadTheUnqualifiedVariable() {
+    final CommandLine commandLine =
+        new CommandLine(new DremioStress())
+            .setDefaultValueProvider(
+                new EnvironmentDefaults(
+                    Collections.singletonMap("DREMIO_STRESS_MAX_QUERIES_IN_FLIGHT", "7")));
+    final CommandLine.ParseResult parsed = commandLine.parseArgs("run", "stress.json");
+    final CommandLine.Model.OptionSpec option =
+        parsed.subcommand().commandSpec().findOption("--max-queries-in-flight");
+    assertNotNull(option);
+    assertEquals(Integer.valueOf(7), option.getValue());
+  }
+}
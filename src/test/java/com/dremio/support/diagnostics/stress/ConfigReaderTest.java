@@ -0,0 +1,58 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import static org.junit.Assert.assertEquals;
+import static org.junit.Assert.assertTrue;
+
+import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import org.junit.Test;
+
+public class ConfigReaderTest {
+
+  private static final String group =
+      "{\"queries\": [{\"queryGroup\": \"g\", \"frequency\": 1}],"
+          + " \"queryGroups\": [{\"name\": \"g\", \"queries\": [\"select 1\", %s]}]}";
+
+  private static StressConfig parse(final String member, final boolean strict) throws IOException {
+    return ConfigReader.parse(
+        "test", String.format(group, member).getBytes(StandardCharsets.UTF_8), strict);
+  }
+
+  @Test
+  public void readsStringAndObjectMembers() throws IOException {
+    final StressConfig config =
+        parse(
+            "{\"query\": \"select :p\", \"parameters\": {\"p\": [1]}, \"expectFailure\": true}",
+            true);
+    final QueryGroup g = config.getQueryGroups().get(0);
+    assertEquals("select 1", g.getQueries().get(0).getQuery());
+    assertEquals("select :p", g.getQueries().get(1).getQuery());
+    assertEquals(1, g.getQueries().get(1).getParameters().get("p").size());
+    assertTrue(g.getQueries().get(1).isExpectFailure());
+  }
+
+  @Test(expected = IOException.class)
+  public void strictRefusesUnknownMemberFields() throws IOException {
+    parse("{\"query\": \"select :p\", \"paramters\": {\"p\": [1]}}", true);
+  }
+
+  @Test
+  public void lenientIgnoresUnknownMemberFields() throws IOException {
+    final StressConfig config =
+        parse("{\"query\": \"select 2\", \"paramters\": {\"p\": [1]}}", false);
+    assertEquals("select 2", config.getQueryGroups().get(0).getQueries().get(1).getQuery());
+  }
+}
@@ -0,0 +1,42 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import static org.junit.Assert.assertEquals;
+import static org.junit.Assert.assertTrue;
+
+import java.net.InetSocketAddress;
+import java.security.InvalidParameterException;
+import org.junit.Test;
+
+public class ControlServerTest {
+
+  @Test
+  public void listensOnLoopbackWithoutAHost() {
+    final InetSocketAddress address = ControlServer.parseAddress(":9048");
+    assertTrue(address.getAddress().isLoopbackAddress());
+    assertEquals(9048, address.getPort());
+  }
+
+  @Test
+  public void listensOnEveryInterfaceWhenAskedTo() {
+    final InetSocketAddress address = ControlServer.parseAddress("0.0.0.0:9048");
+    assertTrue(address.getAddress().isAnyLocalAddress());
+  }
+
+  @Test(expected = InvalidParameterException.class)
+  public void refusesAnAddressWithoutAPort() {
+    ControlServer.parseAddress("localhost");
+  }
+}
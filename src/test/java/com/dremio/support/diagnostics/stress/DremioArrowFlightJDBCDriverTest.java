@@ -0,0 +1,53 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import static org.junit.Assert.assertEquals;
+import static org.junit.Assert.assertTrue;
+
+import java.util.Arrays;
+import java.util.Properties;
+import org.junit.Test;
+
+public class DremioArrowFlightJDBCDriverTest {
+
+  @Test
+  public void defaultContextFromTheUrl() {
+    assertEquals(
+        Arrays.asList("Samples", "samples.dremio.com"),
+        DremioArrowFlightJDBCDriver.defaultContext(
+                "jdbc:arrow-flight-sql://localhost:32010/?useEncryption=false"
+                    + "&schema=Samples.%22samples.dremio.com%22",
+                new Properties())
+            .getParts());
+  }
+
+  @Test
+  public void defaultContextFromTheProperties() {
+    final Properties properties = new Properties();
+    properties.setProperty("schema", "\"My Space\".folder");
+    assertEquals(
+        Arrays.asList("My Space", "folder"),
+        DremioArrowFlightJDBCDriver.defaultContext(
+                "jdbc:arrow-flight-sql://localhost:32010/?useEncryption=false", properties)
+            .getParts());
+  }
+
+  @Test
+  public void noDefaultContext() {
+    assertTrue(
+        DremioArrowFlightJDBCDriver.defaultContext("jdbc:arrow-flight-sql://localhost:32010", null)
+            .isEmpty());
+  }
+}
@@ -0,0 +1,65 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import static org.junit.Assert.assertEquals;
+import static org.junit.Assert.assertFalse;
+import static org.junit.Assert.assertNull;
+import static org.junit.Assert.assertTrue;
+
+import org.junit.Test;
+
+public class ReadOnlyGuardTest {
+
+  @Test
+  public void skipsLeadingCommentsAndParentheses() {
+    assertTrue(
+        ReadOnlyGuard.isReadOnly("-- nightly\n/* dashboard */ ((SELECT 1) UNION (SELECT 2))"));
+    assertFalse(ReadOnlyGuard.isReadOnly("/* looks harmless */ -- really\nDELETE FROM t"));
+  }
+
+  @Test
+  public void readsWith() {
+    assertTrue(ReadOnlyGuard.isReadOnly("with a as (select 1) select * from a"));
+  }
+
+  @Test
+  public void allowsSessionChanges() {
+    assertTrue(ReadOnlyGuard.isReadOnly("ALTER SESSION SET planner.slice_target = 1"));
+    assertTrue(ReadOnlyGuard.isReadOnly("USE Samples"));
+    assertFalse(ReadOnlyGuard.isReadOnly("ALTER TABLE t REFRESH METADATA"));
+  }
+
+  @Test
+  public void findsATrailingWrite() {
+    assertEquals(
+        "DROP TABLE t", ReadOnlyGuard.firstWrite("SELECT 1; -- then\nSELECT 2;\nDROP TABLE t;"));
+  }
+
+  @Test
+  public void onlyPlansExplainedWrites() {
+    assertTrue(ReadOnlyGuard.isReadOnly("EXPLAIN PLAN FOR INSERT INTO t SELECT 1"));
+  }
+
+  @Test
+  public void treatsAParameterOnlyStatementAsAWrite() {
+    assertEquals(":stmt", ReadOnlyGuard.firstWrite(":stmt"));
+  }
+
+  @Test
+  public void ignoresCommentOnlyStatements() {
+    assertNull(ReadOnlyGuard.firstWrite("SELECT 1; -- done"));
+    assertNull(ReadOnlyGuard.firstWrite(null));
+  }
+}
@@ -0,0 +1,92 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import static org.junit.Assert.assertEquals;
+import static org.junit.Assert.assertTrue;
+
+import java.util.Arrays;
+import java.util.Collections;
+import org.junit.Test;
+
+public class SqlContextTest {
+
+  @Test
+  public void parsesNestedFolders() {
+    final SqlContext context = SqlContext.parse("[Samples, \"samples.dremio.com\", folder, sub]");
+    assertEquals(
+        Arrays.asList("Samples", "samples.dremio.com", "folder", "sub"), context.getParts());
+    assertEquals("\"Samples\".\"samples.dremio.com\".\"folder\".\"sub\"", context.toSqlPath());
+  }
+
+  @Test
+  public void parsesSpacesInNames() {
+    final SqlContext context = SqlContext.parse("[My Space, \"Folder With Spaces\"]");
+    assertEquals(Arrays.asList("My Space", "Folder With Spaces"), context.getParts());
+    assertEquals("\"My Space\".\"Folder With Spaces\"", context.toSqlPath());
+  }
+
+  @Test
+  public void keepsSpacesInsideQuotes() {
+    final SqlContext context = SqlContext.parse("[ \" leading\", \"trailing \" , \" both \"]");
+    assertEquals(Arrays.asList(" leading", "trailing ", " both "), context.getParts());
+    assertEquals("\" leading\".\"trailing \".\" both \"", context.toSqlPath());
+  }
+
+  @Test
+  public void trimsSpacesOutsideQuotes() {
+    assertEquals(
+        Arrays.asList("Samples", "samples.dremio.com"),
+        SqlContext.parse("  [  Samples ,  samples.dremio.com  ]  ").getParts());
+  }
+
+  @Test
+  public void unescapesDoubledQuotes() {
+    final SqlContext context = SqlContext.parse("[\"say \"\"hi\"\"\", plain]");
+    assertEquals(Arrays.asList("say \"hi\"", "plain"), context.getParts());
+    assertEquals("\"say \"\"hi\"\"\".\"plain\"", context.toSqlPath());
+  }
+
+  @Test
+  public void keepsCommasInsideQuotes() {
+    assertEquals(Arrays.asList("a,b", "c"), SqlContext.parse("[\"a,b\", c]").getParts());
+  }
+
+  @Test
+  public void emptyContexts() {
+    assertTrue(SqlContext.parse(null).isEmpty());
+    assertTrue(SqlContext.parse("[]").isEmpty());
+    assertTrue(SqlContext.parse("").isEmpty());
+    assertTrue(new SqlContext(null).isEmpty());
+    assertTrue(new SqlContext(Collections.singletonList("")).isEmpty());
+    assertEquals("", SqlContext.empty().toSqlPath());
+  }
+
+  @Test
+  public void parsesSqlPaths() {
+    assertEquals(
+        Arrays.asList("Samples", "samples.dremio.com", "Folder With Spaces"),
+        SqlContext.parseSqlPath("Samples.\"samples.dremio.com\".\"Folder With Spaces\"")
+            .getParts());
+    assertEquals(Arrays.asList(" padded "), SqlContext.parseSqlPath("\" padded \"").getParts());
+    assertTrue(SqlContext.parseSqlPath(null).isEmpty());
+  }
+
+  @Test
+  public void roundTripsThroughTheSqlPath() {
+    final SqlContext context =
+        new SqlContext(Arrays.asList("My Space", " padded ", "dots.in.name", "quote\"d"));
+    assertEquals(context, SqlContext.parseSqlPath(context.toSqlPath()));
+  }
+}
@@ -0,0 +1,73 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import static org.junit.Assert.assertArrayEquals;
+import static org.junit.Assert.assertEquals;
+import static org.junit.Assert.assertFalse;
+import static org.junit.Assert.assertNull;
+import static org.junit.Assert.assertTrue;
+
+import java.util.Arrays;
+import java.util.Random;
+import org.junit.Test;
+
+public class SqlFuzzerTest {
+
+  @Test
+  public void splitsColumnsOutsideFunctionCalls() {
+    assertEquals(
+        Arrays.asList("coalesce(a, b) AS c", "'x,y' AS s", "d"),
+        SqlFuzzer.columns(" coalesce(a, b) AS c, 'x,y' AS s, d "));
+  }
+
+  @Test
+  public void skipsTheFromOfFunctionCalls() {
+    final String sql = "SELECT extract(year from d) AS y, a FROM t";
+    assertArrayEquals(new int[] {7, 36}, SqlFuzzer.selectList(sql));
+    assertEquals("extract(year from d) AS y, a ", sql.substring(7, 36));
+  }
+
+  @Test
+  public void noSelectList() {
+    assertNull(SqlFuzzer.selectList("SELECT 1"));
+    assertNull(SqlFuzzer.selectList("WITH x AS (SELECT 1) SELECT * FROM x"));
+  }
+
+  @Test
+  public void keepsColumnsWhole() {
+    final SqlFuzzer fuzzer = new SqlFuzzer(new Random(1), 1);
+    final String sql = "SELECT coalesce(a, b) AS c, extract(year from d) AS y FROM t";
+    for (int i = 0; i < 20; i++) {
+      final String mutated = fuzzer.randomColumnSubset(sql);
+      assertTrue(mutated, mutated.endsWith(" FROM t"));
+      for (final String column : SqlFuzzer.columns(mutated.substring(7, mutated.length() - 7))) {
+        assertTrue(
+            column,
+            column.equals("coalesce(a, b) AS c") || column.equals("extract(year from d) AS y"));
+      }
+    }
+  }
+
+  @Test
+  public void onlyFuzzesQueries() {
+    assertTrue(SqlFuzzer.isQuery("select 1"));
+    assertTrue(SqlFuzzer.isQuery("/* label */ WITH x AS (select 1) select * from x"));
+    assertFalse(SqlFuzzer.isQuery("CREATE TABLE t AS SELECT 1"));
+    assertFalse(SqlFuzzer.isQuery("INSERT INTO t SELECT 1"));
+    assertFalse(SqlFuzzer.isQuery("select 1; select 2"));
+    final SqlFuzzer fuzzer = new SqlFuzzer(new Random(1), 1);
+    assertEquals("DROP TABLE t", fuzzer.fuzz("DROP TABLE t"));
+  }
+}
@@ -0,0 +1,119 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import static org.junit.Assert.assertEquals;
+
+import java.security.InvalidParameterException;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.Collections;
+import java.util.List;
+import java.util.Map;
+import org.junit.Test;
+
+public class StressExecTest {
+
+  @Test
+  public void substitutesUntypedUnquotedStringsVerbatim() {
+    assertEquals("space.t1", StressExec.toSql(null, "table", "space.t1", false));
+    assertEquals("space.t1", StressExec.toSql(Collections.emptyMap(), "table", "space.t1", false));
+  }
+
+  @Test
+  public void escapesUntypedQuotedStrings() {
+    assertEquals("'O''Brien'", StressExec.toSql(null, "name", "O'Brien", true));
+  }
+
+  @Test
+  public void quotesDeclaredStrings() {
+    final Map<String, String> types = Collections.singletonMap("name", "string");
+    assertEquals("'O''Brien'", StressExec.toSql(types, "name", "O'Brien", false));
+  }
+
+  @Test
+  public void rendersInferredAndDeclaredTypes() {
+    assertEquals("42", StressExec.toSql(null, "id", 42, false));
+    final Map<String, String> types = Collections.singletonMap("start", "date");
+    assertEquals("DATE '2018-02-04'", StressExec.toSql(types, "start", "2018-02-04", false));
+  }
+
+  @Test
+  public void acceptsAWorkloadThatOnlyReads() {
+    final QueryGroup group = group("dashboard", "USE Samples", "SELECT * FROM t");
+    StressExec.validateReadOnly(
+        Arrays.asList(query("SELECT 1"), groupQuery("dashboard"), catalog("LIST")),
+        Collections.singletonMap("dashboard", group),
+        Collections.singletonList("ALTER SESSION SET planner.slice_target = 1"));
+  }
+
+  @Test(expected = InvalidParameterException.class)
+  public void refusesAWritingSessionInit() {
+    StressExec.validateReadOnly(
+        Collections.singletonList(query("SELECT 1")),
+        Collections.emptyMap(),
+        Arrays.asList("USE Samples", "CREATE TABLE t AS SELECT 1"));
+  }
+
+  @Test(expected = InvalidParameterException.class)
+  public void refusesAQueryGroupWithOneWritingMember() {
+    final QueryGroup group = group("etl", "SELECT 1", "INSERT INTO t SELECT 1", "SELECT 2");
+    StressExec.validateReadOnly(
+        Collections.singletonList(groupQuery("etl")),
+        Collections.singletonMap("etl", group),
+        null);
+  }
+
+  @Test(expected = InvalidParameterException.class)
+  public void refusesPromote() {
+    StressExec.validateReadOnly(
+        Collections.singletonList(catalog("PROMOTE")), Collections.emptyMap(), null);
+  }
+
+  @Test(expected = InvalidParameterException.class)
+  public void refusesUnpromote() {
+    StressExec.validateReadOnly(
+        Collections.singletonList(catalog("unpromote")), Collections.emptyMap(), null);
+  }
+
+  private static QueryConfig query(final String sql) {
+    final QueryConfig q = new QueryConfig();
+    q.setQuery(sql);
+    return q;
+  }
+
+  private static QueryConfig groupQuery(final String name) {
+    final QueryConfig q = new QueryConfig();
+    q.setQueryGroup(name);
+    return q;
+  }
+
+  private static QueryConfig catalog(final String operation) {
+    final QueryConfig q = new QueryConfig();
+    q.setCatalog(operation);
+    q.setCatalogPath(Arrays.asList("Samples", "samples.dremio.com", "zips.json"));
+    return q;
+  }
+
+  private static QueryGroup group(final String name, final String... statements) {
+    final QueryGroup group = new QueryGroup();
+    group.setName(name);
+    final List<QueryGroupMember> members = new ArrayList<>();
+    for (final String statement : statements) {
+      members.add(new QueryGroupMember(statement));
+    }
+    group.setQueries(members);
+    return group;
+  }
+}
@@ -0,0 +1,115 @@
+/**
+ * Copyright 2023 Dremio
+ *
+ * <p>Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+ * except in compliance with the License. You may obtain a copy of the License at
+ *
+ * <p>http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * <p>Unless required by applicable law or agreed to in writing, software distributed under the
+ * License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package com.dremio.support.diagnostics.stress;
+
+import static org.junit.Assert.assertEquals;
+
+import java.util.ArrayList;
+import java.util.List;
+import java.util.concurrent.Callable;
+import java.util.concurrent.CountDownLatch;
+import java.util.concurrent.ExecutorService;
+import java.util.concurrent.Executors;
+import java.util.concurrent.Future;
+import java.util.concurrent.atomic.AtomicInteger;
+import org.junit.Test;
+
+public class TokenCacheTest {
+
+  private static final String key = "http://localhost:9047 dremio";
+
+  /** counts the logins, the nth login returns the token "Bearer n" */
+  private static final class CountingLogin implements TokenCache.Login {
+    private final AtomicInteger logins = new AtomicInteger(0);
+    private final long firstLifetimeMS;
+
+    /** @param firstLifetimeMS lifetime of the first token, 0 for the default lifetime */
+    private CountingLogin(final long firstLifetimeMS) {
+      this.firstLifetimeMS = firstLifetimeMS;
+    }
+
+    @Override
+    public TokenCache.Token login() {
+      final int n = logins.incrementAndGet();
+      try {
+        // slow enough for the other callers to arrive while the login runs
+        Thread.sleep(100);
+      } catch (InterruptedException e) {
+        Thread.currentThread().interrupt();
+      }
+      final Long expiresMS =
+          n == 1 && firstLifetimeMS > 0 ? System.currentTimeMillis() + firstLifetimeMS : null;
+      return new TokenCache.Token("Bearer " + n, expiresMS);
+    }
+  }
+
+  @Test
+  public void logsInOnceForConcurrentCallers() throws Exception {
+    final TokenCache cache = new TokenCache();
+    final CountingLogin login = new CountingLogin(0);
+    final int callers = 16;
+    final CountDownLatch start = new CountDownLatch(1);
+    final ExecutorService executor = Executors.newFixedThreadPool(callers);
+    try {
+      final List<Future<String>> results = new ArrayList<>();
+      for (int i = 0; i < callers; i++) {
+        final Callable<String> caller =
+            () -> {
+              start.await();
+              return cache.authorization(key, login);
+            };
+        results.add(executor.submit(caller));
+      }
+      start.countDown();
+      for (final Future<String> result : results) {
+        assertEquals("Bearer 1", result.get());
+      }
+    } finally {
+      executor.shutdownNow();
+    }
+    assertEquals(1, login.logins.get());
+  }
+
+  @Test
+  public void refreshesInTheBackgroundOnceMostOfTheLifetimePassed() throws Exception {
+    final TokenCache cache = new TokenCache();
+    // refreshed after 80% of 2 seconds, expires after 2 seconds
+    final CountingLogin login = new CountingLogin(2000);
+    assertEquals("Bearer 1", cache.authorization(key, login));
+    Thread.sleep(1700);
+    // the caller keeps the current token while the refresh runs
+    assertEquals("Bearer 1", cache.authorization(key, login));
+    final long deadline = System.currentTimeMillis() + 5000;
+    String authorization = cache.authorization(key, login);
+    while (!"Bearer 2".equals(authorization) && System.currentTimeMillis() < deadline) {
+      Thread.sleep(50);
+      authorization = cache.authorization(key, login);
+    }
+    assertEquals("Bearer 2", authorization);
+    assertEquals(2, login.logins.get());
+  }
+
+  @Test
+  public void keepsANewerTokenWhenAStaleOneIsInvalidated() throws Exception {
+    final TokenCache cache = new TokenCache();
+    final CountingLogin login = new CountingLogin(0);
+    assertEquals("Bearer 1", cache.authorization(key, login));
+    cache.invalidate(key, "Bearer 1");
+    assertEquals("Bearer 2", cache.authorization(key, login));
+    // a connection that still held the first token reports it refused
+    cache.invalidate(key, "Bearer 1");
+    assertEquals("Bearer 2", cache.authorization(key, login));
+    assertEquals(2, login.logins.get());
+  }
+}